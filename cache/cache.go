@@ -0,0 +1,85 @@
+// Package cache keeps rendered responses of the API on the server. Each
+// response is tagged with the data it shows, such as shop:3, so that a change
+// drops exactly the responses showing it. The services note the tags of their
+// changes in the context of the request, invalidated once it is answered and
+// its transactions committed.
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	Body        []byte
+	ContentType string
+	ETag        string
+	// Tags name the data the response shows.
+	Tags []string
+	// Created is when the data was read: an entry is dropped rather than
+	// stored when one of its tags was invalidated since.
+	Created time.Time
+	Expires time.Time
+}
+
+// Backend stores the entries. The memory one keeps them in each replica; a
+// shared one, such as Redis, would share them and their invalidations between
+// the replicas.
+type Backend interface {
+	// Get returns the entry of key, unless missing or expired.
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	// Set stores the entry of key, unless one of its tags was invalidated
+	// since entry.Created.
+	Set(ctx context.Context, key string, entry Entry) error
+	// Invalidate drops the entries carrying any of tags.
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// Tag names a row of a kind, such as shop:3; the kind alone names every
+// response showing rows of the kind.
+func Tag(kind string, id uint) string {
+	return kind + ":" + strconv.FormatUint(uint64(id), 10)
+}
+
+// ListTag names the lists of the rows of a kind.
+func ListTag(kind string) string {
+	return kind + ":list"
+}
+
+// Changes collects the tags of the data changed while serving a request.
+type Changes struct {
+	mu   sync.Mutex
+	tags []string
+}
+
+type changesKey struct{}
+
+// Track returns a context collecting the tags noted by Changed into the
+// returned Changes.
+func Track(ctx context.Context) (context.Context, *Changes) {
+	changes := &Changes{}
+	return context.WithValue(ctx, changesKey{}, changes), changes
+}
+
+// Changed notes that the data of tags changed, for the Changes tracking ctx
+// if any. Changes noted by transactions rolled back are kept: invalidating
+// too much only costs a cache miss.
+func Changed(ctx context.Context, tags ...string) {
+	changes, ok := ctx.Value(changesKey{}).(*Changes)
+	if !ok {
+		return
+	}
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+	changes.tags = append(changes.tags, tags...)
+}
+
+// Tags returns the tags noted so far.
+func (c *Changes) Tags() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tags
+}
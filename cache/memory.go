@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// invalidationMemory is how long Memory remembers an invalidation to refuse
+// the entries read before it, longer than requests take.
+const invalidationMemory = time.Minute
+
+// Memory is the Backend keeping the entries in memory, evicting the least
+// recently used ones beyond its size.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List // Of *memoryEntry, most recently used first
+	entries    map[string]*list.Element
+	tagged     map[string]map[string]struct{} // Keys by tag
+	// invalidated holds the time of the recent invalidations by tag.
+	invalidated map[string]time.Time
+}
+
+type memoryEntry struct {
+	key string
+	Entry
+}
+
+// NewMemory creates a Memory backend holding up to maxEntries entries.
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries:  maxEntries,
+		lru:         list.New(),
+		entries:     map[string]*list.Element{},
+		tagged:      map[string]map[string]struct{}{},
+		invalidated: map[string]time.Time{},
+	}
+}
+
+// Get returns the entry of key, unless missing or expired.
+func (m *Memory) Get(_ context.Context, key string) (Entry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if time.Now().After(entry.Expires) {
+		m.remove(elem)
+		return Entry{}, false, nil
+	}
+	m.lru.MoveToFront(elem)
+	return entry.Entry, true, nil
+}
+
+// Set stores the entry of key, unless one of its tags was invalidated since
+// entry.Created, evicting the least recently used entries beyond the size.
+func (m *Memory) Set(_ context.Context, key string, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range entry.Tags {
+		if at, ok := m.invalidated[tag]; ok && !at.Before(entry.Created) {
+			return nil
+		}
+	}
+
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+	m.entries[key] = m.lru.PushFront(&memoryEntry{key: key, Entry: entry})
+	for _, tag := range entry.Tags {
+		if m.tagged[tag] == nil {
+			m.tagged[tag] = map[string]struct{}{}
+		}
+		m.tagged[tag][key] = struct{}{}
+	}
+
+	for m.lru.Len() > m.maxEntries {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+// Invalidate drops the entries carrying any of tags.
+func (m *Memory) Invalidate(_ context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for tag, at := range m.invalidated {
+		if now.Sub(at) > invalidationMemory {
+			delete(m.invalidated, tag)
+		}
+	}
+
+	for _, tag := range tags {
+		m.invalidated[tag] = now
+		for key := range m.tagged[tag] {
+			m.remove(m.entries[key])
+		}
+	}
+	return nil
+}
+
+// remove drops the entry of elem.
+func (m *Memory) remove(elem *list.Element) {
+	entry := m.lru.Remove(elem).(*memoryEntry)
+	delete(m.entries, entry.key)
+	for _, tag := range entry.Tags {
+		delete(m.tagged[tag], entry.key)
+		if len(m.tagged[tag]) == 0 {
+			delete(m.tagged, tag)
+		}
+	}
+}
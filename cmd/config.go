@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mohamedhabas11/golang-api/config"
+)
+
+// configUsage documents the config subcommand.
+const configUsage = `usage: config <command>
+
+commands:
+  print          print the effective settings with their source, secrets masked`
+
+// runConfig implements the "config" subcommand. cfg is printed even when
+// invalid, loadErr being reported after it.
+func runConfig(cfg *config.Config, loadErr error, args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		log.Fatalf("Error printing the configuration: %v", err)
+	}
+	if loadErr != nil {
+		log.Fatalf("Invalid configuration:\n%v", loadErr)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/mohamedhabas11/golang-api/config"
+	"github.com/mohamedhabas11/golang-api/events"
+)
+
+// eventSink assembles the sinks the outbox dispatcher publishes to: the
+// in-process bus, the shop webhooks and the live streams, plus the webhook and
+// broker configured in cfg.
+func eventSink(cfg config.Events, bus *events.Bus, shopWebhooks, streams events.Sink) (events.Sink, error) {
+	sinks := events.Fanout{bus, shopWebhooks, streams}
+
+	// Log every event, handy during development
+	if cfg.Log {
+		bus.Subscribe(events.AllTypes, func(_ context.Context, event events.Event) error {
+			log.Printf("Event %d %s %s:%d %s", event.ID, event.Type, event.AggregateType, event.AggregateID, event.Data)
+			return nil
+		})
+	}
+
+	// POST every event to EVENTS_WEBHOOK_URL, signed with EVENTS_WEBHOOK_SECRET if set
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, events.NewWebhookSink(cfg.WebhookURL, cfg.WebhookSecret.Reveal(), cfg.WebhookTimeout))
+	}
+
+	// Produce every event to a broker; the file producer stands in for NATS or
+	// Kafka locally, EVENTS_BROKER_FILE=- writes to stdout
+	if cfg.BrokerFile != "" {
+		producer, err := events.NewFileProducer(cfg.BrokerFile)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, events.NewBrokerSink(producer, cfg.TopicPrefix))
+	}
+
+	return sinks, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mohamedhabas11/golang-api/config"
+	"github.com/mohamedhabas11/golang-api/database"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// healthChecks assembles the readiness checks besides the database ping: the
+// schema migrations, the read replicas if any, and the HTTP dependencies of
+// cfg.CheckURLs, name=url pairs answering 2xx when up. Every check gets
+// cfg.CheckTimeout.
+func healthChecks(cfg config.Health, db *gorm.DB, replicas *database.ReplicaSet) ([]services.HealthCheck, error) {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	checks := []services.HealthCheck{{Name: "migrations", Timeout: cfg.CheckTimeout, Check: migrator.UpToDate}}
+
+	// Reads fall back to the primary without replicas
+	if replicas != nil {
+		checks = append(checks, services.HealthCheck{Name: "replicas", Timeout: cfg.CheckTimeout, Optional: true, Check: replicas.Healthy})
+	}
+
+	for _, pair := range cfg.CheckURLs {
+		name, url, _ := strings.Cut(pair, "=") // Checked by the config
+		checks = append(checks, services.HealthCheck{Name: name, Timeout: cfg.CheckTimeout, Check: httpCheck(url)})
+	}
+	return checks, nil
+}
+
+// httpCheck checks that a GET of url answers 2xx.
+func httpCheck(url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("answered %s", resp.Status)
+		}
+		return nil
+	}
+}
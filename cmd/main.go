@@ -2,70 +2,313 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/config"
 	"github.com/mohamedhabas11/golang-api/controllers"
 	"github.com/mohamedhabas11/golang-api/database"
+	"github.com/mohamedhabas11/golang-api/events"
 	"github.com/mohamedhabas11/golang-api/initializers"
+	"github.com/mohamedhabas11/golang-api/logging"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/telemetry"
 )
 
-func init() {
-	initializers.LoadEnvVariables()
-}
-
 func main() {
-	database.ConnectDB()
-
-	// Check if we need to seed the database
-	if os.Getenv("DB_SEED") == "TRUE" {
-		seedFile := os.Getenv("DB_SEED_FILE") // Get the seed file path from env
-		if seedFile != "" {
-			// Seed the database with data from the specified seed file
-			initializers.SeedDatabase(seedFile)
-		} else {
-			log.Println("No seed file specified")
+	// Load the configuration from the defaults, CONFIG_FILE, .env and the
+	// environment; the config command prints it, even invalid
+	cfg, err := config.Load()
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		runConfig(cfg, err, os.Args[2:])
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	// Write JSON logs to stderr, or text ones for development; the admin API
+	// changes the level at runtime
+	if err := logging.Setup(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		log.Fatal(err)
+	}
+
+	// Subcommands run instead of the server.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		runOpenAPI(cfg, os.Args[2:])
+		return
+	}
+
+	// Sign the tokens and guard the admin endpoints with the configured secrets
+	if err := middlewares.SetJWTSecret(cfg.Auth.JWTSecret.Reveal()); err != nil {
+		log.Fatal(err)
+	}
+	middlewares.SetAdminToken(cfg.Auth.AdminToken.Reveal())
+	middlewares.SetCookieOptions(middlewares.CookieOptions{
+		Secure:   cfg.Cookies.Secure,
+		SameSite: cfg.Cookies.SameSite,
+		Domain:   cfg.Cookies.Domain,
+	})
+
+	// Trace the requests and their queries, exported to otlp, console or none
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), cfg.Telemetry.TracesExporter)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
+	db := database.ConnectDB(cfg.Database)
+
+	// Time and trace every SQL statement, and export the pool statistics
+	if err := db.Use(telemetry.GormPlugin{}); err != nil {
+		log.Fatalf("Failed to instrument the database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get the database connection pool: %v", err)
+	}
+	telemetry.RegisterDBStats(sqlDB, "primary")
+
+	// Optional read replicas serve the queries of read-only requests
+	replicas := database.OpenReplicas(db, cfg.Database)
+	if replicas != nil {
+		if err := replicas.Register(db); err != nil {
+			log.Fatalf("Failed to set up read replicas: %v", err)
 		}
 	}
 
-	// Create a new Fiber app
-	app := fiber.New()
+	svc := services.New(repositories.NewGormStore(db))
 
-	// Set up the routes
-	controllers.SetupRoutes(app)
+	// Report the replica ready only while the database, its schema and the
+	// configured dependencies are
+	checks, err := healthChecks(cfg.Health, db, replicas)
+	if err != nil {
+		log.Fatalf("Failed to set up the health checks: %v", err)
+	}
+	svc.Health.Checks = checks
 
-	// Get the port from the environment variable or use default port 3000
-	port := os.Getenv("APP_PORT")
-	if port == "" {
-		port = "3000" // Default port if APP_PORT is not set
+	// Context cancelled on shutdown to stop background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Check the replicas regularly, an unhealthy one gets no reads until it recovers
+	if replicas != nil {
+		go replicas.Run(jobsCtx, 5*time.Second)
 	}
 
-	// Start the server in a goroutine to handle graceful shutdown
+	// Stream the changes of inventories and items live; on Postgres the events
+	// reach the streams of every replica through LISTEN/NOTIFY
+	if database.CapabilitiesOf(db).ListenNotify {
+		notifier, err := database.NewNotifier(db, "api_stream_events")
+		if err != nil {
+			log.Fatalf("Failed to set up the stream relay: %v", err)
+		}
+		svc.Streams.Relay = notifier
+	} else {
+		log.Println("No LISTEN/NOTIFY on this database, live streams only get the events published by this replica")
+	}
+	svc.Streams.Heartbeat = cfg.Streams.Heartbeat
+	go svc.Streams.Run(jobsCtx)
+
+	// Limit the request rate of the clients and the monthly API requests of the
+	// shops; the idle buckets are purged every minute
+	setupRateLimits(svc, cfg.RateLimit)
+	go svc.RateLimits.Run(jobsCtx, time.Minute)
+
+	// Export the number of items below ITEMS_REORDER_POINT, the stock under
+	// which an item needs restocking
+	svc.Items.ReorderPoint = cfg.Items.ReorderPoint
+	telemetry.RegisterGauge("items_below_reorder_point", "Active items holding less than ITEMS_REORDER_POINT.",
+		func(ctx context.Context) (float64, error) {
+			count, err := svc.Items.CountBelowReorderPoint(ctx)
+			return float64(count), err
+		})
+
+	// Publish the domain events of the outbox to the configured sinks
+	bus := events.NewBus()
+	sink, err := eventSink(cfg.Events, bus, svc.Webhooks, svc.Streams)
+	if err != nil {
+		log.Fatalf("Failed to set up the event sinks: %v", err)
+	}
+	go svc.Outbox.Run(jobsCtx, sink, cfg.Events.PollInterval)
+
+	// Deliver the events queued for the shop webhooks; private targets are only
+	// reachable when WEBHOOK_ALLOW_PRIVATE_TARGETS is TRUE, for local development
+	svc.Webhooks.AllowPrivateTargets = cfg.Webhooks.AllowPrivateTargets
+	go svc.Webhooks.Run(jobsCtx, cfg.Events.PollInterval)
+
+	// Permanently purge soft-deleted rows, published events, finished webhook deliveries and finished jobs once they exceed the retention period
+	if cfg.Retention.SoftDeleteDays > 0 {
+		payload := services.PurgeExpiredPayload{RetentionDays: cfg.Retention.SoftDeleteDays}
+		if err := svc.Jobs.Schedule("purge-expired", "@hourly", services.JobPurgeExpired, payload); err != nil {
+			log.Fatalf("Failed to schedule the retention job: %v", err)
+		}
+	} else {
+		log.Println("SOFT_DELETE_RETENTION_DAYS is 0, soft-deleted rows are kept forever")
+	}
+
+	// Run the background jobs; on shutdown running jobs get JOBS_DRAIN_TIMEOUT to finish
+	jobOptions := services.RunOptions{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: cfg.Jobs.PollInterval,
+		DrainTimeout: cfg.Jobs.DrainTimeout,
+	}
+	jobsDone := make(chan struct{})
 	go func() {
-		log.Printf("Server started on port %s", port)
-		if err := app.Listen(":" + port); err != nil {
+		defer close(jobsDone)
+		svc.Jobs.Run(jobsCtx, jobOptions)
+	}()
+
+	// The API versions, with the deprecation and sunset dates of the retired ones
+	versions := apiVersions(cfg.API)
+
+	// Serve HTTPS when TLS_CERT_FILE is set, reloading the certificate once
+	// renewed, and verify the client certificates of the internal services
+	var tlsConfig *tls.Config
+	if cfg.TLS.Enabled() {
+		certs, err := newCertReloader(cfg.TLS)
+		if err != nil {
+			log.Fatalf("Failed to set up TLS: %v", err)
+		}
+		tlsConfig = certs.Config()
+		go certs.Run(jobsCtx, cfg.TLS.ReloadInterval)
+	}
+
+	// The peers of a Unix socket are local processes, the proxy in front of
+	// the API, whose headers are trusted; they have no IP address
+	trustedProxies := cfg.Security.TrustedProxies
+	if cfg.App.Socket != "" {
+		trustedProxies = append(trustedProxies, net.IPv4zero.String())
+	}
+
+	// Cache the reads of the shops, inventories and items on the server
+	if cfg.Cache.Backend == "memory" {
+		middlewares.SetResponseCache(cache.NewMemory(cfg.Cache.MaxEntries), cfg.Cache.TTL)
+	}
+
+	// Create a new Fiber app with the central problem+json error handler,
+	// bounding the size and the read, write and idle times of the requests;
+	// the client IPs and schemes given by the trusted proxies are used, the
+	// others ignored
+	app := fiber.New(fiber.Config{
+		ErrorHandler:            middlewares.ErrorHandler,
+		BodyLimit:               cfg.App.BodyLimit,
+		ReadBufferSize:          cfg.App.MaxHeaderBytes,
+		ReadTimeout:             cfg.App.ReadTimeout,
+		WriteTimeout:            cfg.App.WriteTimeout,
+		IdleTimeout:             cfg.App.IdleTimeout,
+		Concurrency:             cfg.App.Concurrency,
+		ProxyHeader:             cfg.Security.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies,
+		EnableIPValidation:      true,
+	})
+
+	// Tag every request with an ID used in error responses and logs
+	app.Use(middlewares.RequestIDMiddleware())
+
+	// Log every request once answered
+	app.Use(middlewares.AccessLog())
+
+	// Harden the responses in browsers and let the CORS_ALLOWED_ORIGINS call the API
+	app.Use(middlewares.SecurityHeaders(cfg.Security.HSTSMaxAge, cfg.Security.ContentSecurityPolicy))
+	app.Use(middlewares.CORS(cfg.Security.CORSAllowedOrigins, cfg.Security.CORSAllowCredentials, cfg.Security.CORSMaxAge))
+
+	// Trace every request and count it by route and status for /metrics
+	app.Use(middlewares.Telemetry())
+
+	// Attribute mutations to their caller in the audit log
+	app.Use(middlewares.ActorContext())
+
+	// Drop the cached responses showing the data changed by each request
+	app.Use(middlewares.InvalidateCache)
+
+	// Cancel the database queries of requests running longer than DB_QUERY_TIMEOUT
+	app.Use(middlewares.QueryTimeout(cfg.Database.QueryTimeout))
+
+	// Send the reads of GET requests to the replicas, except right after a client wrote
+	if replicas != nil {
+		app.Use(middlewares.ReadRouting(cfg.Database.Replicas.StickyWindow))
+	}
+
+	// Require the CSRF token on the mutations authenticated by the auth cookie
+	app.Use(middlewares.RequireCSRF)
+
+	// Check every response against the OpenAPI document, failing those that
+	// break the contract; for development and CI runs
+	if cfg.OpenAPI.ValidateResponses {
+		app.Use(middlewares.ValidateResponses(controllers.OpenAPIDocuments(app, versions)))
+	}
+
+	// Set up the routes
+	controllers.SetupRoutes(app, svc, versions)
+
+	// Start the server in a goroutine to handle graceful shutdown, before the
+	// seed so that the startup probe can answer
+	ln, err := listen(cfg.App, tlsConfig)
+	if err != nil {
+		log.Fatalf("Error starting server: %v", err)
+	}
+	go func() {
+		log.Printf("Server started on %s", ln.Addr())
+		if err := app.Listener(ln); err != nil {
 			log.Fatalf("Error starting server: %v", err)
 		}
 	}()
 
+	// Check if we need to seed the database; the startup probe fails until then
+	if cfg.Seed.Enabled {
+		// Seed the database with data from the specified seed file
+		initializers.SeedDatabase(db, cfg.Seed.File)
+	}
+	svc.Health.MarkStarted()
+
 	// Set up signal handling for graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
 	<-quit // Block until a signal is received
+	// Keep serving for SHUTDOWN_DRAIN_DELAY once draining, for the load
+	// balancers to notice /readyz failing
+	svc.Health.Drain()
+	if drainDelay := cfg.Health.DrainDelay; drainDelay > 0 {
+		log.Printf("Draining for %s before shutting down, signal again to skip", drainDelay)
+		select {
+		case <-time.After(drainDelay):
+		case <-quit:
+		}
+	}
 	log.Println("Shutting down server...")
+	stopJobs()
 
-	// Gracefully shutdown the Fiber app
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Gracefully shutdown the Fiber app, letting the requests in flight
+	// finish for SHUTDOWN_TIMEOUT
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
 	defer cancel()
 
 	if err := app.ShutdownWithContext(ctx); err != nil {
 		log.Fatalf("Error during server shutdown: %v", err)
 	}
 
+	// Wait for the running jobs to finish or be requeued
+	<-jobsDone
+
+	// Export the spans still buffered
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Error flushing the traces: %v", err)
+	}
+
 	log.Println("Server exited gracefully")
 }
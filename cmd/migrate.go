@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/mohamedhabas11/golang-api/config"
+	"github.com/mohamedhabas11/golang-api/database"
+)
+
+// migrateUsage documents the migrate subcommand.
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [N]       roll back the last N migrations (default 1)
+  status         list migrations and whether they are applied
+  create NAME    create an empty up/down migration pair for every dialect in -dir`
+
+// runMigrate implements the "migrate" subcommand.
+func runMigrate(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := flags.String("dir", "database/migrations", "directory holding the per-dialect migration directories")
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, migrateUsage) }
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	command, rest := flags.Arg(0), flags.Args()[1:]
+
+	// Creating a migration only touches the source tree.
+	if command == "create" {
+		if len(rest) != 1 {
+			log.Fatal("migrate create needs exactly one NAME argument")
+		}
+		paths, err := database.CreateMigration(*dir, rest[0])
+		if err != nil {
+			log.Fatalf("Error creating migration: %v", err)
+		}
+		for _, path := range paths {
+			fmt.Println("created", path)
+		}
+		return
+	}
+
+	migrator, err := database.NewMigrator(database.OpenDB(cfg.Database))
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("Error applying migrations: %v", err)
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		n := 1
+		if len(rest) > 0 {
+			if n, err = strconv.Atoi(rest[0]); err != nil || n < 1 {
+				log.Fatalf("Invalid number of migrations to roll back: %q", rest[0])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, n)
+		if err != nil {
+			log.Fatalf("Error rolling back migrations: %v", err)
+		}
+		fmt.Printf("rolled back %d migration(s)\n", rolledBack)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Error reading migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Missing:
+				state = "applied, missing from binary"
+			case status.Modified:
+				state = "applied, MODIFIED since"
+			case status.Applied:
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s  %s\n", status.Version, status.Name, state)
+		}
+
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/config"
+	"github.com/mohamedhabas11/golang-api/controllers"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// runOpenAPI implements the "openapi" subcommand: it prints the OpenAPI
+// document of an API version, the latest one by default, without a database,
+// for client generators and for reviewing contract changes.
+func runOpenAPI(cfg *config.Config, args []string) {
+	versions := apiVersions(cfg.API)
+	app := fiber.New()
+	controllers.SetupRoutes(app, services.New(repositories.NewMemoryStore()), versions)
+
+	version := ""
+	if len(args) > 0 {
+		version = args[0]
+	}
+	doc := controllers.OpenAPIDocuments(app, versions)(version)
+	if doc == nil {
+		log.Fatalf("Unknown API version %q", version)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		log.Fatalf("Error writing the OpenAPI document: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/mohamedhabas11/golang-api/config"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// setupRateLimits applies the rate limits and shop quotas of cfg. With
+// RATE_LIMIT_STORE=memory each replica keeps its own buckets, the clients
+// getting up to one limit per replica; with database the replicas share them.
+func setupRateLimits(svc *services.Services, cfg config.RateLimit) {
+	policy := func(name string, rate config.Rate) services.RatePolicy {
+		return services.RatePolicy{Name: name, Limit: rate.Limit, Window: rate.Window}
+	}
+	svc.RateLimits.Auth = policy("auth", cfg.Auth)
+	svc.RateLimits.Read = policy("read", cfg.Read)
+	svc.RateLimits.Write = policy("write", cfg.Write)
+	if cfg.Store == "memory" {
+		svc.RateLimits.Buckets = repositories.NewMemoryStore().RateLimits()
+	}
+
+	svc.Quotas.DefaultMonthly = int64(cfg.ShopMonthlyQuota)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/config"
+)
+
+// listen opens the listener of the server: the Unix socket of cfg.Socket,
+// replacing a stale one, or the TCP port of cfg.Port, serving HTTPS with
+// tlsConfig unless nil. The socket is removed when the listener closes.
+func listen(cfg config.App, tlsConfig *tls.Config) (net.Listener, error) {
+	network, address := "tcp", ":"+cfg.Port
+	if cfg.Socket != "" {
+		network, address = "unix", cfg.Socket
+		if err := os.Remove(cfg.Socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	// Let the proxy connect when it runs in the group of the API
+	if cfg.Socket != "" {
+		if err := os.Chmod(cfg.Socket, 0o660); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	return ln, nil
+}
+
+// certReloader serves the TLS configuration built from the certificate, key
+// and client CA files, rebuilt when they change so that renewed certificates
+// are served without a restart.
+type certReloader struct {
+	cfg     config.TLS
+	current atomic.Pointer[tls.Config]
+	// loaded are the modification times of the files in use.
+	loaded []time.Time
+}
+
+// newCertReloader loads the TLS files of cfg.
+func newCertReloader(cfg config.TLS) (*certReloader, error) {
+	r := &certReloader{cfg: cfg}
+	modTimes, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.loaded = modTimes
+	return r, nil
+}
+
+// Config returns the TLS configuration of the listener, handing each
+// handshake the configuration loaded last.
+func (r *certReloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.current.Load().MinVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load(), nil
+		},
+	}
+}
+
+// load builds the TLS configuration from the files.
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("loading the TLS certificate: %w", err)
+	}
+	conf := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if r.cfg.MinVersion == "1.3" {
+		conf.MinVersion = tls.VersionTLS13
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("loading the client CAs: %w", err)
+		}
+		conf.ClientCAs = x509.NewCertPool()
+		if !conf.ClientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("loading the client CAs: no PEM certificate in %s", r.cfg.ClientCAFile)
+		}
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.cfg.ClientAuth == "require" {
+			conf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	r.current.Store(conf)
+	return nil
+}
+
+// modTimes returns the modification times of the files.
+func (r *certReloader) modTimes() ([]time.Time, error) {
+	var modTimes []time.Time
+	for _, name := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// Run reloads the files every interval once they changed, until ctx is
+// cancelled. Files that fail to load, such as a certificate renewed before
+// its key, keep the previous configuration in use until the next attempt.
+func (r *certReloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTimes, err := r.modTimes()
+			if err != nil {
+				log.Printf("Failed to check the TLS files: %v", err)
+				continue
+			}
+			if slices.EqualFunc(modTimes, r.loaded, time.Time.Equal) {
+				continue
+			}
+			if err := r.load(); err != nil {
+				log.Printf("Failed to reload the TLS files, keeping the previous ones: %v", err)
+				continue
+			}
+			r.loaded = modTimes
+			log.Println("Reloaded the TLS certificate")
+		}
+	}
+}
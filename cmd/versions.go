@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/mohamedhabas11/golang-api/config"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+)
+
+// apiVersionNames are the versions of the API served, oldest first. Requests
+// without a version go to the oldest, the API of the clients predating
+// versioning; remove a version once its usage dropped to nothing. Each has
+// its retirement dates in config.API.
+var apiVersionNames = []string{"v1", "v2"}
+
+// apiVersions returns the API versions with their deprecation and sunset
+// dates, from API_<VERSION>_DEPRECATED_AT and API_<VERSION>_SUNSET_AT.
+func apiVersions(cfg config.API) []middlewares.APIVersion {
+	versions := make([]middlewares.APIVersion, len(apiVersionNames))
+	for i, name := range apiVersionNames {
+		dates, _ := cfg.Version(name)
+		versions[i] = middlewares.APIVersion{Name: name, Deprecated: dates.DeprecatedAt, Sunset: dates.SunsetAt}
+	}
+	return versions
+}
@@ -0,0 +1,494 @@
+// Package config loads the typed configuration of the API. Every setting has
+// a default, overridden in turn by the optional YAML or TOML file named by
+// CONFIG_FILE, the .env file and the environment. Secrets can also be read
+// from the file named by their variable suffixed with _FILE, such as
+// JWT_SECRET_FILE=/run/secrets/jwt.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// Config is the configuration of the API. The env tag names the environment
+// variable of a setting, relative to the env tag of its section if any, key
+// its name in the config file, relative to its section, and default its value
+// when no source sets it.
+type Config struct {
+	App       App       `key:"app"`
+	TLS       TLS       `key:"tls"`
+	Security  Security  `key:"security"`
+	Cookies   Cookies   `key:"cookies"`
+	Log       Log       `key:"log"`
+	Database  Database  `key:"database"`
+	Seed      Seed      `key:"seed"`
+	Auth      Auth      `key:"auth"`
+	API       API       `key:"api"`
+	OpenAPI   OpenAPI   `key:"openapi"`
+	Telemetry Telemetry `key:"telemetry"`
+	Health    Health    `key:"health"`
+	Events    Events    `key:"events"`
+	Webhooks  Webhooks  `key:"webhooks"`
+	Streams   Streams   `key:"streams"`
+	Jobs      Jobs      `key:"jobs"`
+	Retention Retention `key:"retention"`
+	Items     Items     `key:"items"`
+	RateLimit RateLimit `key:"rate_limit"`
+	Cache     Cache     `key:"cache"`
+
+	// sources records where each setting came from, by environment variable.
+	sources map[string]string
+}
+
+// App configures the HTTP server.
+type App struct {
+	Port string `env:"APP_PORT" key:"port" default:"3000"`
+	// BodyLimit is the largest request body accepted, in bytes; the larger
+	// ones are answered 413.
+	BodyLimit int `env:"APP_BODY_LIMIT" key:"body_limit" default:"1048576"`
+	// MaxHeaderBytes is the largest request line and headers accepted.
+	MaxHeaderBytes int `env:"APP_MAX_HEADER_BYTES" key:"max_header_bytes" default:"8192"`
+	// ReadTimeout bounds the time a client takes to send its request.
+	ReadTimeout time.Duration `env:"APP_READ_TIMEOUT" key:"read_timeout" default:"10s"`
+	// WriteTimeout bounds the time a client takes to receive a response, or
+	// each event of a live stream; 0 means unlimited.
+	WriteTimeout time.Duration `env:"APP_WRITE_TIMEOUT" key:"write_timeout" default:"30s"`
+	// IdleTimeout closes the keep-alive connections idle for longer; 0 uses
+	// ReadTimeout.
+	IdleTimeout time.Duration `env:"APP_IDLE_TIMEOUT" key:"idle_timeout" default:"1m"`
+	// Concurrency caps the connections served at once.
+	Concurrency int `env:"APP_CONCURRENCY" key:"concurrency" default:"262144"`
+	// Socket is the path of a Unix socket to listen on instead of Port, for a
+	// proxy on the same host.
+	Socket string `env:"APP_SOCKET" key:"socket"`
+	// ShutdownTimeout lets the requests in flight finish on shutdown.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" key:"shutdown_timeout" default:"5s"`
+}
+
+// TLS configures the HTTPS termination by the API itself, rather than by a
+// proxy in front of it.
+type TLS struct {
+	// CertFile and KeyFile hold the PEM certificate chain and private key;
+	// setting them serves HTTPS. The files are reloaded when they change, for
+	// the renewed certificates to be served without a restart.
+	CertFile string `env:"TLS_CERT_FILE" key:"cert_file"`
+	KeyFile  string `env:"TLS_KEY_FILE" key:"key_file"`
+	// MinVersion is 1.2 or 1.3.
+	MinVersion string `env:"TLS_MIN_VERSION" key:"min_version" default:"1.2"`
+	// ClientCAFile holds the PEM certificates of the CAs signing the client
+	// certificates of the internal services, also reloaded when it changes.
+	ClientCAFile string `env:"TLS_CLIENT_CA_FILE" key:"client_ca_file"`
+	// ClientAuth is none, verify to check the client certificates given, or
+	// require to refuse the clients without one.
+	ClientAuth string `env:"TLS_CLIENT_AUTH" key:"client_auth" default:"none"`
+	// ReloadInterval is how often the files are checked for changes.
+	ReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" key:"reload_interval" default:"30s"`
+}
+
+// Enabled reports whether the API serves HTTPS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Security configures the protections of the browsers and the handling of
+// the proxies in front of the API.
+type Security struct {
+	// CORSAllowedOrigins may call the API from a browser, such as
+	// https://app.example.com, or * for any origin without credentials. Empty
+	// leaves the API to its own origin.
+	CORSAllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" key:"cors_allowed_origins"`
+	// CORSAllowCredentials lets the allowed origins send the auth cookies.
+	CORSAllowCredentials bool          `env:"CORS_ALLOW_CREDENTIALS" key:"cors_allow_credentials"`
+	CORSMaxAge           time.Duration `env:"CORS_MAX_AGE" key:"cors_max_age" default:"10m"`
+	// HSTSMaxAge keeps the browsers on HTTPS once they reached the API over it;
+	// 0 disables Strict-Transport-Security.
+	HSTSMaxAge time.Duration `env:"HSTS_MAX_AGE" key:"hsts_max_age" default:"8760h"`
+	// ContentSecurityPolicy of the responses; the API serves no pages but its
+	// documentation, which has a policy of its own.
+	ContentSecurityPolicy string `env:"CONTENT_SECURITY_POLICY" key:"content_security_policy" default:"default-src 'none'; frame-ancestors 'none'"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies whose
+	// ProxyHeader gives the client IP and X-Forwarded-Proto the scheme. The
+	// headers of other peers are ignored.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" key:"trusted_proxies"`
+	// ProxyHeader carries the client IP, the first valid address of the list
+	// being used: the proxies must overwrite it rather than append to it.
+	ProxyHeader string `env:"PROXY_HEADER" key:"proxy_header" default:"X-Forwarded-For"`
+}
+
+// Cookies configures the auth cookies set by the login endpoints.
+type Cookies struct {
+	// Secure only sends the cookies over HTTPS; disable it for local HTTP
+	// development.
+	Secure bool `env:"COOKIE_SECURE" key:"secure" default:"true"`
+	// SameSite is Strict, Lax or None, None requiring Secure.
+	SameSite string `env:"COOKIE_SAMESITE" key:"same_site" default:"Lax"`
+	// Domain shares the cookies with the subdomains; empty for the API host only.
+	Domain string `env:"COOKIE_DOMAIN" key:"domain"`
+}
+
+// Log configures the logs.
+type Log struct {
+	// Level is debug, info, warn or error; the admin API changes it at runtime.
+	Level string `env:"LOG_LEVEL" key:"level" default:"info"`
+	// Format is json or text, for development.
+	Format string `env:"LOG_FORMAT" key:"format" default:"json"`
+}
+
+// Database configures the primary database, its pool and its read replicas.
+type Database struct {
+	// URL selects the database, see database.Dialector. Without it the API
+	// connects to the PostgreSQL server described by the settings below.
+	URL      models.Secret `env:"DATABASE_URL" key:"url" secret:"true"`
+	Host     string        `env:"DB_HOST" key:"host" default:"db"`
+	Port     string        `env:"DB_PORT" key:"port" default:"5432"`
+	User     string        `env:"DB_USER" key:"user"`
+	Password models.Secret `env:"DB_PASSWORD" key:"password" secret:"true"`
+	Name     string        `env:"DB_NAME" key:"name"`
+	SSLMode  string        `env:"DB_SSLMODE" key:"sslmode" default:"disable"`
+	TimeZone string        `env:"DB_TIMEZONE" key:"timezone" default:"UTC"`
+
+	// MigrateOnStart applies the pending migrations at startup instead of
+	// refusing to start.
+	MigrateOnStart bool `env:"DB_MIGRATE_ON_START" key:"migrate_on_start"`
+	// ConnectTimeout bounds the retries while the database starts up.
+	ConnectTimeout time.Duration `env:"DB_CONNECT_TIMEOUT" key:"connect_timeout" default:"1m"`
+	// SlowQueryThreshold logs the slower statements as warnings.
+	SlowQueryThreshold time.Duration `env:"DB_SLOW_QUERY_THRESHOLD" key:"slow_query_threshold" default:"200ms"`
+	// QueryTimeout cancels the queries of the requests running longer.
+	QueryTimeout time.Duration `env:"DB_QUERY_TIMEOUT" key:"query_timeout" default:"10s"`
+
+	Pool     Pool     `key:"pool"`
+	Replicas Replicas `key:"replicas"`
+}
+
+// Pool sizes the connection pools of the primary and of the replicas; SQLite
+// ignores it, keeping its single connection.
+type Pool struct {
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" key:"max_open_conns" default:"25"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" key:"max_idle_conns" default:"10"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" key:"conn_max_lifetime" default:"30m"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" key:"conn_max_idle_time" default:"5m"`
+}
+
+// Replicas configures the read replicas.
+type Replicas struct {
+	URLs []models.Secret `env:"DATABASE_REPLICA_URLS" key:"urls" secret:"true"`
+	// MaxLag marks the Postgres replicas replaying further behind as unhealthy.
+	MaxLag time.Duration `env:"DB_REPLICA_MAX_LAG" key:"max_lag" default:"10s"`
+	// StickyWindow sends the reads of a client to the primary after it wrote.
+	StickyWindow time.Duration `env:"DB_REPLICA_STICKY_WINDOW" key:"sticky_window" default:"5s"`
+}
+
+// Seed configures the seeding of the database at startup.
+type Seed struct {
+	Enabled bool   `env:"DB_SEED" key:"enabled"`
+	File    string `env:"DB_SEED_FILE" key:"file"`
+}
+
+// Auth holds the secrets of the authentication.
+type Auth struct {
+	// JWTSecret signs the tokens; a random one, lost on restart, is generated
+	// when it is empty.
+	JWTSecret models.Secret `env:"JWT_SECRET" key:"jwt_secret" secret:"true"`
+	// AdminToken guards the admin endpoints, which don't exist without it.
+	AdminToken models.Secret `env:"ADMIN_TOKEN" key:"admin_token" secret:"true"`
+}
+
+// API configures the API versions.
+type API struct {
+	V1 APIVersion `env:"API_V1" key:"v1"`
+	V2 APIVersion `env:"API_V2" key:"v2"`
+}
+
+// Version returns the configuration of the API version name, v1, v2...
+func (a API) Version(name string) (APIVersion, bool) {
+	switch name {
+	case "v1":
+		return a.V1, true
+	case "v2":
+		return a.V2, true
+	}
+	return APIVersion{}, false
+}
+
+// APIVersion holds the retirement dates of an API version, a date or an RFC
+// 3339 time.
+type APIVersion struct {
+	DeprecatedAt time.Time `env:"DEPRECATED_AT" key:"deprecated_at"`
+	SunsetAt     time.Time `env:"SUNSET_AT" key:"sunset_at"`
+}
+
+// OpenAPI configures the OpenAPI contract checks.
+type OpenAPI struct {
+	// ValidateResponses fails the responses breaking the contract, for
+	// development and CI runs.
+	ValidateResponses bool `env:"OPENAPI_VALIDATE_RESPONSES" key:"validate_responses"`
+}
+
+// Telemetry configures the traces; the standard OTEL_* variables configure
+// the exporter itself.
+type Telemetry struct {
+	// TracesExporter is otlp, console or none.
+	TracesExporter string `env:"OTEL_TRACES_EXPORTER" key:"traces_exporter" default:"none"`
+}
+
+// Health configures the probes.
+type Health struct {
+	// CheckTimeout bounds each readiness check.
+	CheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" key:"check_timeout" default:"2s"`
+	// CheckURLs are name=url pairs of the HTTP dependencies, up when they
+	// answer 2xx.
+	CheckURLs []string `env:"HEALTH_CHECK_URLS" key:"check_urls"`
+	// DrainDelay keeps a draining replica serving while the load balancers
+	// notice /readyz failing.
+	DrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" key:"drain_delay" default:"5s"`
+}
+
+// Events configures the publication of the domain events.
+type Events struct {
+	// Log logs every event, handy during development.
+	Log          bool          `env:"EVENTS_LOG" key:"log"`
+	PollInterval time.Duration `env:"EVENTS_POLL_INTERVAL" key:"poll_interval" default:"1s"`
+	// WebhookURL receives every event, signed with WebhookSecret if set.
+	WebhookURL     string        `env:"EVENTS_WEBHOOK_URL" key:"webhook_url"`
+	WebhookSecret  models.Secret `env:"EVENTS_WEBHOOK_SECRET" key:"webhook_secret" secret:"true"`
+	WebhookTimeout time.Duration `env:"EVENTS_WEBHOOK_TIMEOUT" key:"webhook_timeout" default:"10s"`
+	// BrokerFile stands in for NATS or Kafka locally, - writing to stdout.
+	BrokerFile  string `env:"EVENTS_BROKER_FILE" key:"broker_file"`
+	TopicPrefix string `env:"EVENTS_TOPIC_PREFIX" key:"topic_prefix"`
+}
+
+// Webhooks configures the delivery of the shop webhooks.
+type Webhooks struct {
+	// AllowPrivateTargets lets webhooks reach private addresses, for local
+	// development.
+	AllowPrivateTargets bool `env:"WEBHOOK_ALLOW_PRIVATE_TARGETS" key:"allow_private_targets"`
+}
+
+// Streams configures the live streams.
+type Streams struct {
+	Heartbeat time.Duration `env:"STREAM_HEARTBEAT_INTERVAL" key:"heartbeat_interval" default:"15s"`
+}
+
+// Jobs configures the background job runner.
+type Jobs struct {
+	Workers      int           `env:"JOBS_WORKERS" key:"workers" default:"4"`
+	PollInterval time.Duration `env:"JOBS_POLL_INTERVAL" key:"poll_interval" default:"1s"`
+	// DrainTimeout lets the running jobs finish on shutdown.
+	DrainTimeout time.Duration `env:"JOBS_DRAIN_TIMEOUT" key:"drain_timeout" default:"30s"`
+}
+
+// Retention configures the purge of the expired rows.
+type Retention struct {
+	// SoftDeleteDays keeps the soft-deleted rows, published events, finished
+	// deliveries and jobs that long; 0 keeps them forever.
+	SoftDeleteDays int `env:"SOFT_DELETE_RETENTION_DAYS" key:"soft_delete_days" default:"30"`
+}
+
+// Items configures the stock monitoring.
+type Items struct {
+	// ReorderPoint is the stock under which an item needs restocking.
+	ReorderPoint int `env:"ITEMS_REORDER_POINT" key:"reorder_point" default:"10"`
+}
+
+// RateLimit configures the rate limits of the clients and the API quotas of
+// the shops.
+type RateLimit struct {
+	// Auth limits the login and signup requests of each client, Read the GET
+	// requests of the API and Write its other requests; empty to disable.
+	Auth  Rate `env:"RATE_LIMIT_AUTH" key:"auth" default:"10/1m"`
+	Read  Rate `env:"RATE_LIMIT_READ" key:"read" default:"600/1m"`
+	Write Rate `env:"RATE_LIMIT_WRITE" key:"write" default:"120/1m"`
+	// Store keeps the buckets in memory, per replica, or in the database,
+	// shared by the replicas.
+	Store string `env:"RATE_LIMIT_STORE" key:"store" default:"memory"`
+	// ShopMonthlyQuota caps the API requests made for each shop a month,
+	// unless the shop has a quota of its own; 0 means unlimited.
+	ShopMonthlyQuota int `env:"SHOP_MONTHLY_API_QUOTA" key:"shop_monthly_quota"`
+}
+
+// Cache configures the server cache of the reads of the shops, inventories
+// and items.
+type Cache struct {
+	// Backend is none, or memory for an LRU cache in each replica.
+	Backend string `env:"CACHE_BACKEND" key:"backend" default:"none"`
+	// MaxEntries bounds the responses kept in memory, the least recently used
+	// being evicted.
+	MaxEntries int `env:"CACHE_MAX_ENTRIES" key:"max_entries" default:"10000"`
+	// TTL bounds the life of a response. A replica drops the responses changed
+	// by its own requests right away, but only sees the changes made through
+	// the other replicas once they expire.
+	TTL time.Duration `env:"CACHE_TTL" key:"ttl" default:"30s"`
+}
+
+// Rate is a number of requests per window, such as 100/1m, or 100/m for a
+// single unit. The zero Rate is unlimited.
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+// UnmarshalText parses a rate from its text form.
+func (r *Rate) UnmarshalText(text []byte) error {
+	count, window, ok := strings.Cut(string(text), "/")
+	limit, err := strconv.Atoi(count)
+	if !ok || err != nil || limit < 1 {
+		return errors.New("expected a rate such as 100/1m")
+	}
+	if window != "" && !strings.ContainsAny(window[:1], "0123456789") {
+		window = "1" + window
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return errors.New("expected a rate such as 100/1m")
+	}
+	r.Limit, r.Window = limit, d
+	return nil
+}
+
+// MarshalText returns the text form of the rate, empty when unlimited.
+func (r Rate) MarshalText() ([]byte, error) {
+	if r.Limit == 0 {
+		return nil, nil
+	}
+	// 1m rather than 1m0s.
+	window := r.Window.String()
+	if strings.HasSuffix(window, "m0s") {
+		window = strings.TrimSuffix(window, "0s")
+	}
+	if strings.HasSuffix(window, "h0m") {
+		window = strings.TrimSuffix(window, "0m")
+	}
+	return []byte(strconv.Itoa(r.Limit) + "/" + window), nil
+}
+
+// minJWTSecretLength is the length under which a JWT secret is guessable.
+const minJWTSecretLength = 32
+
+// validate checks the settings against each other and their allowed values,
+// reporting every problem.
+func (c *Config) validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.App.Port); err != nil || port < 1 || port > 65535 {
+		invalid("invalid APP_PORT: %q, expected a port number", c.App.Port)
+	}
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level) {
+		invalid("invalid LOG_LEVEL: %q, expected debug, info, warn or error", c.Log.Level)
+	}
+	if !slices.Contains([]string{"json", "text"}, c.Log.Format) {
+		invalid("invalid LOG_FORMAT: %q, expected json or text", c.Log.Format)
+	}
+	if !slices.Contains([]string{"otlp", "console", "none"}, c.Telemetry.TracesExporter) {
+		invalid("invalid OTEL_TRACES_EXPORTER: %q, expected otlp, console or none", c.Telemetry.TracesExporter)
+	}
+
+	for _, origin := range c.Security.CORSAllowedOrigins {
+		if u, err := url.Parse(origin); origin != "*" && (err != nil || u.Scheme == "" || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "") {
+			invalid("invalid CORS_ALLOWED_ORIGINS entry %q, expected an origin such as https://app.example.com", origin)
+		}
+	}
+	if c.Security.CORSAllowCredentials && slices.Contains(c.Security.CORSAllowedOrigins, "*") {
+		invalid("CORS_ALLOW_CREDENTIALS can't be set with the * origin, list the allowed origins")
+	}
+	for _, proxy := range c.Security.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			invalid("invalid TRUSTED_PROXIES entry %q, expected an IP address or a CIDR range", proxy)
+		}
+	}
+	if !slices.Contains([]string{"Strict", "Lax", "None"}, c.Cookies.SameSite) {
+		invalid("invalid COOKIE_SAMESITE: %q, expected Strict, Lax or None", c.Cookies.SameSite)
+	} else if c.Cookies.SameSite == "None" && !c.Cookies.Secure {
+		invalid("COOKIE_SAMESITE is None but COOKIE_SECURE isn't set, browsers reject such cookies")
+	}
+	if c.App.BodyLimit == 0 {
+		invalid("invalid APP_BODY_LIMIT: must be positive")
+	}
+	if c.App.Concurrency < 1 {
+		invalid("invalid APP_CONCURRENCY: %d, expected at least 1", c.App.Concurrency)
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		invalid("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if !slices.Contains([]string{"1.2", "1.3"}, c.TLS.MinVersion) {
+		invalid("invalid TLS_MIN_VERSION: %q, expected 1.2 or 1.3", c.TLS.MinVersion)
+	}
+	switch {
+	case !slices.Contains([]string{"none", "verify", "require"}, c.TLS.ClientAuth):
+		invalid("invalid TLS_CLIENT_AUTH: %q, expected none, verify or require", c.TLS.ClientAuth)
+	case c.TLS.ClientAuth != "none" && c.TLS.ClientCAFile == "":
+		invalid("TLS_CLIENT_AUTH is %s but TLS_CLIENT_CA_FILE isn't set", c.TLS.ClientAuth)
+	case c.TLS.ClientAuth == "none" && c.TLS.ClientCAFile != "":
+		invalid("TLS_CLIENT_CA_FILE is set but TLS_CLIENT_AUTH is none, set it to verify or require")
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.Enabled() {
+		invalid("TLS_CLIENT_CA_FILE is set but TLS isn't, set TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	if c.Seed.Enabled && c.Seed.File == "" {
+		invalid("DB_SEED is set but DB_SEED_FILE isn't")
+	}
+
+	if secret := c.Auth.JWTSecret.Reveal(); secret != "" && len(secret) < minJWTSecretLength {
+		invalid("JWT_SECRET is too weak; must be at least %d characters long", minJWTSecretLength)
+	}
+
+	for _, version := range []struct {
+		name string
+		APIVersion
+	}{{"V1", c.API.V1}, {"V2", c.API.V2}} {
+		switch {
+		case !version.SunsetAt.IsZero() && version.DeprecatedAt.IsZero():
+			invalid("API_%s_SUNSET_AT is set but the version isn't deprecated, set API_%s_DEPRECATED_AT", version.name, version.name)
+		case !version.SunsetAt.IsZero() && version.SunsetAt.Before(version.DeprecatedAt):
+			invalid("API_%s_SUNSET_AT is before API_%s_DEPRECATED_AT", version.name, version.name)
+		}
+	}
+
+	for _, check := range c.Health.CheckURLs {
+		name, target, ok := strings.Cut(check, "=")
+		if _, err := url.ParseRequestURI(target); !ok || name == "" || err != nil {
+			invalid("invalid HEALTH_CHECK_URLS entry %q, expected name=url", check)
+		}
+	}
+
+	if c.Streams.Heartbeat == 0 {
+		invalid("invalid STREAM_HEARTBEAT_INTERVAL: must be positive")
+	}
+	if c.Jobs.Workers < 1 {
+		invalid("invalid JOBS_WORKERS: %d, expected at least 1", c.Jobs.Workers)
+	}
+	if !slices.Contains([]string{"memory", "database"}, c.RateLimit.Store) {
+		invalid("invalid RATE_LIMIT_STORE: %q, expected memory or database", c.RateLimit.Store)
+	}
+	if !slices.Contains([]string{"none", "memory"}, c.Cache.Backend) {
+		invalid("invalid CACHE_BACKEND: %q, expected none or memory", c.Cache.Backend)
+	} else if c.Cache.Backend != "none" && (c.Cache.MaxEntries < 1 || c.Cache.TTL <= 0) {
+		invalid("CACHE_MAX_ENTRIES and CACHE_TTL must be positive with CACHE_BACKEND %s", c.Cache.Backend)
+	}
+	for _, interval := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"EVENTS_POLL_INTERVAL", c.Events.PollInterval},
+		{"JOBS_POLL_INTERVAL", c.Jobs.PollInterval},
+		{"TLS_RELOAD_INTERVAL", c.TLS.ReloadInterval},
+	} {
+		if interval.value == 0 {
+			invalid("invalid %s: must be positive", interval.name)
+		}
+	}
+	return errors.Join(errs...)
+}
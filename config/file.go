@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads the settings of a YAML or TOML config file, by dotted key
+// such as database.pool.max_open_conns, in their text form.
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the config file: %w", err)
+	}
+
+	var tree map[string]any
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten(values, "", tree)
+	return values, nil
+}
+
+// flatten stores the leaves of tree in values, lists comma separated.
+func flatten(values map[string]string, prefix string, tree map[string]any) {
+	for key, value := range tree {
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(values, prefix+key+".", value)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = scalar(item)
+			}
+			values[prefix+key] = strings.Join(items, ",")
+		default:
+			values[prefix+key] = scalar(value)
+		}
+	}
+}
+
+// scalar returns the text form of a scalar of a config file.
+func scalar(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return value.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
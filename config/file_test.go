@@ -0,0 +1,85 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/config"
+)
+
+// loadFile loads the configuration from a config file named name holding
+// content.
+func loadFile(t *testing.T, name, content string) (*config.Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	return config.Load()
+}
+
+// TestLoadTOMLFile checks that a TOML config file is read with the whole
+// syntax: multi-line and literal strings, inline tables and arrays spanning
+// lines.
+func TestLoadTOMLFile(t *testing.T) {
+	cfg, err := loadFile(t, "config.toml", `
+[app]
+port = "8080" # A comment
+body_limit = 2_048
+read_timeout = "15s"
+
+[security]
+content_security_policy = """
+default-src 'self'"""
+cors_allowed_origins = [
+  "https://app.example.com",
+  "https://admin.example.com", # Trailing comma
+]
+
+[database]
+name = 'C:\data\shop'
+pool = { max_open_conns = 7, conn_max_lifetime = "1h" }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.App.Port != "8080" || cfg.App.BodyLimit != 2048 || cfg.App.ReadTimeout != 15*time.Second {
+		t.Errorf("app: got port %q, body limit %d, read timeout %s", cfg.App.Port, cfg.App.BodyLimit, cfg.App.ReadTimeout)
+	}
+	if got := cfg.Security.ContentSecurityPolicy; got != "default-src 'self'" {
+		t.Errorf("multi-line string: got %q", got)
+	}
+	if got := cfg.Security.CORSAllowedOrigins; !slices.Equal(got, []string{"https://app.example.com", "https://admin.example.com"}) {
+		t.Errorf("array: got %q", got)
+	}
+	if got := cfg.Database.Name; got != `C:\data\shop` {
+		t.Errorf("literal string: got %q", got)
+	}
+	if pool := cfg.Database.Pool; pool.MaxOpenConns != 7 || pool.ConnMaxLifetime != time.Hour {
+		t.Errorf("inline table: got %d open connections for %s", pool.MaxOpenConns, pool.ConnMaxLifetime)
+	}
+}
+
+// TestLoadInvalidTOMLFile checks that malformed TOML is reported rather than
+// misread.
+func TestLoadInvalidTOMLFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"unterminated string": "[app]\nport = \"8080\n",
+		"invalid escape":      "[database]\nname = \"C:\\data\"\n",
+		"missing value":       "[app]\nport =\n",
+		"duplicate key":       "[app]\nport = \"1\"\nport = \"2\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadFile(t, "config.toml", content)
+			if err == nil || !strings.Contains(err.Error(), "parsing") {
+				t.Errorf("got %v, want a parsing error", err)
+			}
+		})
+	}
+}
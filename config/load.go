@@ -0,0 +1,258 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// setting is a leaf of Config.
+type setting struct {
+	env    string // Environment variable
+	key    string // Dotted key in the config file
+	def    string // Default value
+	secret bool   // Masked when printed, readable from a file
+	value  reflect.Value
+}
+
+// settings lists the settings of cfg in declaration order, the values being
+// addressable.
+func settings(cfg *Config) []setting {
+	var list []setting
+	var walk func(v reflect.Value, env, key string)
+	walk = func(v reflect.Value, env, key string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Tag.Get("env")
+			if env != "" {
+				name = env + "_" + name
+			}
+			path := key + field.Tag.Get("key")
+			if field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+				walk(v.Field(i), strings.TrimSuffix(name, "_"), path+".")
+				continue
+			}
+			list = append(list, setting{
+				env:    name,
+				key:    path,
+				def:    field.Tag.Get("default"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "", "")
+	return list
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// layer is a source of settings; file layers are keyed by the dotted keys,
+// the others by environment variable.
+type layer struct {
+	name   string
+	file   bool
+	values map[string]string
+}
+
+// lookup returns the value of s in the layer, read from the file named by its
+// _FILE variant for secrets.
+func (l layer) lookup(s setting) (value, source string, ok bool, err error) {
+	name, fileName := s.env, s.env+"_FILE"
+	if l.file {
+		name, fileName = s.key, s.key+"_file"
+	}
+	value, ok = l.values[name]
+	path, fromFile := l.values[fileName]
+	if !s.secret || !fromFile {
+		return value, l.name, ok, nil
+	}
+	if ok {
+		return "", "", false, fmt.Errorf("both %s and %s are set in %s", name, fileName, l.name)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false, fmt.Errorf("reading %s: %w", fileName, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), path + " (" + fileName + ", " + l.name + ")", true, nil
+}
+
+// Load reads the configuration from its defaults, the file named by
+// CONFIG_FILE, a .yaml, .yml or .toml file, the .env file of the working
+// directory and the environment, each overriding the previous. Empty
+// variables count as unset. The variables of .env missing from the
+// environment are exported, for the libraries reading their own, such as the
+// OTEL_* ones.
+//
+// The configuration is returned along with every error found, in its values
+// or between them; it is only usable when the error is nil.
+func Load() (*Config, error) {
+	var errs []error
+
+	environment := layer{name: "environment", values: map[string]string{}}
+	for _, variable := range os.Environ() {
+		if name, value, _ := strings.Cut(variable, "="); value != "" {
+			environment.values[name] = value
+		}
+	}
+
+	dotenv := layer{name: ".env", values: map[string]string{}}
+	values, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("reading .env: %w", err))
+	}
+	for name, value := range values {
+		if value == "" {
+			continue
+		}
+		dotenv.values[name] = value
+		if _, set := environment.values[name]; !set {
+			os.Setenv(name, value)
+		}
+	}
+
+	file := layer{file: true, values: map[string]string{}}
+	if file.name = environment.values["CONFIG_FILE"]; file.name == "" {
+		file.name = dotenv.values["CONFIG_FILE"]
+	}
+	if file.name != "" {
+		if file.values, err = readFile(file.name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	layers := []layer{file, dotenv, environment}
+
+	cfg := &Config{sources: map[string]string{}}
+	known := map[string]bool{}
+	for _, s := range settings(cfg) {
+		known[s.key], known[s.key+"_file"] = true, s.secret
+		value, source := s.def, "default"
+		for _, l := range layers {
+			v, from, ok, err := l.lookup(s)
+			if err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				value, source = v, from
+			}
+		}
+		// Validate the default instead, not to report the error twice.
+		if err := parse(s.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s from the %s: %q, %w", s.env, source, value, err))
+			value, source = s.def, "default"
+			_ = parse(s.value, value)
+		}
+		cfg.sources[s.env] = source
+	}
+
+	// Typos in the file would go unnoticed otherwise.
+	for key := range file.values {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("unknown setting %s in %s", key, file.name))
+		}
+	}
+
+	errs = append(errs, cfg.validate())
+	return cfg, errors.Join(errs...)
+}
+
+// parse sets v from its text form: durations in Go syntax such as "30s",
+// times as a date or an RFC 3339 time, lists comma separated, and the other
+// types by their encoding.TextUnmarshaler.
+func parse(v reflect.Value, value string) error {
+	if value == "" {
+		v.SetZero()
+		return nil
+	}
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return errors.New("expected a duration such as 30s")
+		}
+		v.SetInt(int64(d))
+	case v.Type() == timeType:
+		for _, layout := range []string{time.DateOnly, time.RFC3339} {
+			if t, err := time.Parse(layout, value); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return errors.New("expected a date or an RFC 3339 time")
+	case v.Addr().Type().Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("expected TRUE or FALSE")
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return errors.New("expected an integer, 0 or more")
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			list.Index(i).SetString(item)
+		}
+		v.Set(list)
+	default:
+		panic("config: unsupported setting type " + v.Type().String())
+	}
+	return nil
+}
+
+// format returns the text form of v, as parsed by parse.
+func format(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	case v.Kind() == reflect.String:
+		// Not fmt, which would redact the models.Secret values.
+		return v.String()
+	case v.Type().Implements(textMarshalerType):
+		text, _ := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text)
+	}
+	return fmt.Sprint(v.Interface())
+}
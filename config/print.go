@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mohamedhabas11/golang-api/logging"
+)
+
+// Print writes the effective settings as environment variables, with the
+// source of each and the secrets masked.
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range settings(c) {
+		value := format(s.value)
+		if s.secret && value != "" {
+			value = mask(s.value)
+		}
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", s.env, value, c.sources[s.env])
+	}
+	return tw.Flush()
+}
+
+// mask hides a secret, keeping the URLs readable but their password.
+func mask(v reflect.Value) string {
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = maskValue(v.Index(i).String())
+		}
+		return strings.Join(items, ",")
+	}
+	return maskValue(v.String())
+}
+
+// maskValue hides value, or only the password of a URL.
+func maskValue(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return logging.Redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	// Query parameters such as password= may hold secrets too.
+	u.RawQuery = ""
+	return u.String()
+}
@@ -0,0 +1,183 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/controllers"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/repositories/storetest"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// testAdminToken is the operator token of the admin endpoints in the tests.
+const testAdminToken = "test-admin-token"
+
+// testPassword passes the password rules of the signups.
+const testPassword = "Passw0rd!23"
+
+func TestMain(m *testing.M) {
+	// A random secret signs the tokens of the tests
+	if err := middlewares.SetJWTSecret(""); err != nil {
+		panic(err)
+	}
+	middlewares.SetAdminToken(testAdminToken)
+	os.Exit(m.Run())
+}
+
+// testVersions are the API versions served in the tests.
+var testVersions = []middlewares.APIVersion{{Name: "v1"}, {Name: "v2"}}
+
+// testAPI serves the routes of the API on a store, behind the middlewares
+// main installs in front of them.
+type testAPI struct {
+	t   *testing.T
+	app *fiber.App
+	svc *services.Services
+	// observe, unless nil, sees every response of the API.
+	observe func(method, path string, resp testResponse)
+}
+
+// forEachAPI runs test on a testAPI of every store, see storetest.ForEach.
+func forEachAPI(t *testing.T, test func(t *testing.T, api *testAPI)) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		test(t, newTestAPI(t, store))
+	})
+}
+
+// newTestAPI creates a testAPI on store, empty.
+func newTestAPI(t *testing.T, store repositories.Store) *testAPI {
+	t.Helper()
+	svc := services.New(store)
+	app := fiber.New(fiber.Config{ErrorHandler: middlewares.ErrorHandler})
+	app.Use(middlewares.RequestIDMiddleware())
+	app.Use(middlewares.ActorContext())
+	app.Use(middlewares.InvalidateCache)
+	app.Use(middlewares.RequireCSRF)
+	controllers.SetupRoutes(app, svc, testVersions)
+	return &testAPI{t: t, app: app, svc: svc}
+}
+
+// testResponse is an answer of the testAPI.
+type testResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// decode unmarshals the JSON body of the response into v.
+func (r testResponse) decode(t *testing.T, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("decoding %s: %v", r.Body, err)
+	}
+}
+
+// do sends a request with body, JSON encoded unless nil, and headers given as
+// name, value pairs.
+func (api *testAPI) do(method, path string, body any, headers ...string) testResponse {
+	api.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			api.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := api.app.Test(req, -1)
+	if err != nil {
+		api.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		api.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	answer := testResponse{Status: resp.StatusCode, Header: resp.Header, Body: respBody}
+	if api.observe != nil {
+		api.observe(method, path, answer)
+	}
+	return answer
+}
+
+// expect sends a request like do and fails the test unless answered status.
+func (api *testAPI) expect(status int, method, path string, body any, headers ...string) testResponse {
+	api.t.Helper()
+	resp := api.do(method, path, body, headers...)
+	if resp.Status != status {
+		api.t.Fatalf("%s %s: got %d, want %d: %s", method, path, resp.Status, status, resp.Body)
+	}
+	return resp
+}
+
+// testShop is a shop created through the API, with an inventory holding an
+// item, an employee and its owner's token.
+type testShop struct {
+	ID, OwnerID, InventoryID, ItemID, EmployeeID uint
+	// Auth is the Authorization header of the owner.
+	Auth string
+}
+
+// createShop signs up a shop named name and fills it through the API.
+func (api *testAPI) createShop(name string) testShop {
+	api.t.Helper()
+	var shop struct {
+		ID    uint `json:"id"`
+		Owner struct {
+			ID uint `json:"id"`
+		} `json:"owner"`
+	}
+	api.expect(fiber.StatusCreated, fiber.MethodPost, "/api/v2/shop/signup", map[string]any{
+		"name":  name,
+		"email": name + "@shops.test",
+		"owner": map[string]any{"name": name + " owner", "email": name + "@owners.test", "password": testPassword},
+	}).decode(api.t, &shop)
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	api.expect(fiber.StatusOK, fiber.MethodPost, "/api/v2/shop/login", map[string]any{
+		"email": name + "@owners.test", "password": testPassword,
+	}).decode(api.t, &login)
+	created := testShop{ID: shop.ID, OwnerID: shop.Owner.ID, Auth: "Bearer " + login.Token}
+
+	var resource struct {
+		ID uint `json:"id"`
+	}
+	api.expect(fiber.StatusCreated, fiber.MethodPost, "/api/v2/inventories", map[string]any{
+		"shop_id": shop.ID, "inventory_name": name + " stock",
+	}, fiber.HeaderAuthorization, created.Auth).decode(api.t, &resource)
+	created.InventoryID = resource.ID
+	api.expect(fiber.StatusCreated, fiber.MethodPost, "/api/v2/items", map[string]any{
+		"inventory_id": created.InventoryID, "name": name + " item", "quantity": 3,
+	}, fiber.HeaderAuthorization, created.Auth).decode(api.t, &resource)
+	created.ItemID = resource.ID
+	api.expect(fiber.StatusCreated, fiber.MethodPost, "/api/v2/employees", map[string]any{
+		"shop_id": shop.ID, "name": name + " employee", "email": name + "@employees.test", "password": testPassword,
+	}, fiber.HeaderAuthorization, created.Auth).decode(api.t, &resource)
+	created.EmployeeID = resource.ID
+	return created
+}
+
+// id formats a row ID for a path.
+func id(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
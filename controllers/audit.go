@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// AuditController serves the audit log.
+type AuditController struct {
+	audit *services.AuditService
+}
+
+// NewAuditController creates an AuditController.
+func NewAuditController(audit *services.AuditService) *AuditController {
+	return &AuditController{audit: audit}
+}
+
+// GetAuditLog lists the audit entries of the authenticated owner's shops,
+// filtered by shop, entity, actor and time range.
+func (h *AuditController) GetAuditLog(c *fiber.Ctx) error {
+	var query dto.AuditQuery
+	if err := dto.BindQuery(c, &query); err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	entries, err := h.audit.ListForOwner(c.UserContext(), principal.UserID, query.ToFilter())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewAuditEntryResponses(entries))
+}
+
+// VerifyAuditLog checks the hash chain of the whole audit log.
+func (h *AuditController) VerifyAuditLog(c *fiber.Ctx) error {
+	result, err := h.audit.Verify(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.AuditVerificationResponse{
+		Valid:           result.Valid,
+		Checked:         result.Checked,
+		FirstInvalidSeq: result.FirstInvalidSeq,
+		Reason:          result.Reason,
+	})
+}
@@ -0,0 +1,47 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// TestAuditActorOfOptionalAuth checks that the mutations of the routes not
+// requiring a token are attributed to the caller of the token they carry.
+func TestAuditActorOfOptionalAuth(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		shop := api.createShop("audited")
+		path := "/api/v2/items/" + id(shop.ItemID)
+
+		var entries []struct {
+			ActorType string `json:"actor_type"`
+			ActorID   *uint  `json:"actor_id"`
+			Action    string `json:"action"`
+		}
+		latest := func() (string, uint) {
+			t.Helper()
+			api.expect(fiber.StatusOK, fiber.MethodGet, "/api/v2/audit?entity_type=item&limit=1", nil,
+				fiber.HeaderAuthorization, shop.Auth).decode(t, &entries)
+			if len(entries) != 1 || entries[0].Action != models.AuditUpdate {
+				t.Fatalf("got entries %+v, want the update of the item", entries)
+			}
+			if entries[0].ActorID == nil {
+				return entries[0].ActorType, 0
+			}
+			return entries[0].ActorType, *entries[0].ActorID
+		}
+
+		api.expect(fiber.StatusOK, fiber.MethodPut, path, map[string]any{"quantity": 4}, fiber.HeaderAuthorization, shop.Auth)
+		if actor, actorID := latest(); actor != middlewares.RoleShopOwner || actorID != shop.OwnerID {
+			t.Errorf("got actor %s %d, want shop owner %d", actor, actorID, shop.OwnerID)
+		}
+
+		api.expect(fiber.StatusOK, fiber.MethodPut, path, map[string]any{"quantity": 5})
+		if actor, actorID := latest(); actor != models.ActorAnonymous || actorID != 0 {
+			t.Errorf("got actor %s %d, want anonymous", actor, actorID)
+		}
+	})
+}
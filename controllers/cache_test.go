@@ -0,0 +1,61 @@
+package controllers_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+)
+
+// TestCacheForgetsMovedRows checks that moving an item to an inventory of
+// another shop, or an employee to another shop, drops the cached responses
+// of the inventory and the shop they left.
+func TestCacheForgetsMovedRows(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		middlewares.SetResponseCache(cache.NewMemory(100), time.Hour)
+		t.Cleanup(func() { middlewares.SetResponseCache(nil, 0) })
+
+		left := api.createShop("left")
+		joined := api.createShop("joined")
+
+		inventory := "/api/v2/inventories/" + id(left.InventoryID)
+		shop := "/api/v2/shops/" + id(left.ID) + "?expand=employees,inventories,items"
+		cached := func(path string) testResponse {
+			t.Helper()
+			api.expect(fiber.StatusOK, fiber.MethodGet, path, nil)
+			resp := api.expect(fiber.StatusOK, fiber.MethodGet, path, nil)
+			if got := resp.Header.Get(middlewares.HeaderCache); got != "HIT" {
+				t.Fatalf("GET %s: got X-Cache %q, want HIT", path, got)
+			}
+			return resp
+		}
+		fresh := func(path string, gone string) {
+			t.Helper()
+			resp := api.expect(fiber.StatusOK, fiber.MethodGet, path, nil)
+			if got := resp.Header.Get(middlewares.HeaderCache); got != "MISS" {
+				t.Errorf("GET %s: got X-Cache %q, want MISS", path, got)
+			}
+			if bytes.Contains(resp.Body, []byte(gone)) {
+				t.Errorf("GET %s still shows %s: %s", path, gone, resp.Body)
+			}
+		}
+
+		cached(inventory)
+		cached(shop)
+		api.expect(fiber.StatusOK, fiber.MethodPut, "/api/v2/items/"+id(left.ItemID), map[string]any{
+			"inventory_id": joined.InventoryID,
+		}, fiber.HeaderAuthorization, left.Auth)
+		fresh(inventory, "left item")
+		fresh(shop, "left item")
+
+		cached(shop)
+		api.expect(fiber.StatusOK, fiber.MethodPut, "/api/v2/employees/"+id(left.EmployeeID), map[string]any{
+			"shop_id": joined.ID,
+		}, fiber.HeaderAuthorization, left.Auth)
+		fresh(shop, "left employee")
+	})
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// versions derives a strong ETag from the rows a response shows: their
+// identity, last update and deletion, which change whenever the body does,
+// and the path and query of the request, which pick the representation,
+// version of the API included.
+type versions struct {
+	hash hash.Hash
+}
+
+// newVersions starts the versions of the response to c.
+func newVersions(c *fiber.Ctx) *versions {
+	v := &versions{hash: sha256.New()}
+	v.hash.Write([]byte(c.Path() + "?" + string(c.Request().URI().QueryString()) + "\n"))
+	return v
+}
+
+// add adds the version of a row of kind.
+func (v *versions) add(kind string, row gorm.Model) {
+	line := kind + ":" + strconv.FormatUint(uint64(row.ID), 10) + ":" + strconv.FormatInt(row.UpdatedAt.UnixNano(), 10)
+	if row.DeletedAt.Valid {
+		line += ":" + strconv.FormatInt(row.DeletedAt.Time.UnixNano(), 10)
+	}
+	v.hash.Write([]byte(line + "\n"))
+}
+
+// shop adds the versions of shop and of the relations it was loaded with.
+func (v *versions) shop(shop models.Shop) {
+	v.add(models.AuditShop, shop.Model)
+	if shop.Owner.ID != 0 {
+		v.add(models.AuditShopOwner, shop.Owner.Model)
+	}
+	for _, employee := range shop.Employees {
+		v.add(models.AuditEmployee, employee.Model)
+	}
+	for _, inventory := range shop.Inventories {
+		v.inventory(inventory)
+	}
+}
+
+// inventory adds the versions of inventory and of its items.
+func (v *versions) inventory(inventory models.Inventory) {
+	v.add(models.AuditInventory, inventory.Model)
+	for _, item := range inventory.Items {
+		v.add(models.AuditItem, item.Model)
+	}
+}
+
+// ETag returns the strong entity tag of the versions.
+func (v *versions) ETag() string {
+	return `"` + base64.RawURLEncoding.EncodeToString(v.hash.Sum(nil)[:18]) + `"`
+}
+
+// sendVersioned answers body, the representation of the rows of v, with their
+// ETag, or 304 Not Modified when the client's copy is current. The response
+// is cached on the server until one of tags is invalidated; without tags it
+// isn't.
+func sendVersioned(c *fiber.Ctx, v *versions, body any, tags ...string) error {
+	etag := v.ETag()
+	c.Set(fiber.HeaderETag, etag)
+	if middlewares.NotModified(c, etag) {
+		return c.Status(fiber.StatusNotModified).Send(nil)
+	}
+	if len(tags) > 0 {
+		middlewares.CacheResponse(c, tags...)
+	}
+	return c.Status(fiber.StatusOK).JSON(body)
+}
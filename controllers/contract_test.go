@@ -0,0 +1,36 @@
+package controllers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/controllers"
+	"github.com/mohamedhabas11/golang-api/openapi"
+)
+
+// TestResponsesMatchOpenAPI calls every route of the API and fails when a
+// response isn't the one the OpenAPI document of its version describes: an
+// undocumented status, or a body off the schema. The unversioned routes are
+// checked against the latest version, as they're part of every version.
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		docs := controllers.OpenAPIDocuments(api.app, testVersions)
+
+		called := api.scanRoutes(func(route fiber.Route, resp testResponse) {
+			version := ""
+			if rest, ok := strings.CutPrefix(route.Path, "/api/"); ok {
+				version, _, _ = strings.Cut(rest, "/")
+			}
+			path := openapi.PathTemplate(route.Path)
+			err := docs(version).ValidateResponse(route.Method, path, resp.Status, resp.Header.Get(fiber.HeaderContentType), resp.Body)
+			if err != nil {
+				t.Errorf("%s %s answered %d off the contract: %v: %s", route.Method, path, resp.Status, err, resp.Body)
+			}
+		})
+		if called == 0 {
+			t.Fatal("no route called")
+		}
+	})
+}
@@ -4,134 +4,77 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 
-	"github.com/mohamedhabas11/golang-api/database"
+	"github.com/mohamedhabas11/golang-api/dto"
 	"github.com/mohamedhabas11/golang-api/middlewares"
-	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/telemetry"
 	"github.com/mohamedhabas11/golang-api/utils"
 )
 
-var (
-	passwordValidationLength int
-)
-
-// CreateCustomer registers a new customer.
-func CreateCustomer(c *fiber.Ctx) error {
-	var customer models.Customer
-
-	// set default passwordValidationLength
-	if passwordValidationLength == 0 {
-		passwordValidationLength = 8
-	}
-
-	// Parse request body
-	if err := c.BodyParser(&customer); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
-	}
-
-	// Validate email format
-	if !utils.ValidateEmail(customer.Email) {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid email format")
-	}
+// CustomerController serves the customer endpoints.
+type CustomerController struct {
+	customers *services.CustomerService
+}
 
-	// Validate password (assuming a minimum length of 8)
-	if err := utils.ValidatePassword(customer.Password, utils.NewPasswordValidationConfig(passwordValidationLength)); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid password: " + err.Error())
-	}
+// NewCustomerController creates a CustomerController.
+func NewCustomerController(customers *services.CustomerService) *CustomerController {
+	return &CustomerController{customers: customers}
+}
 
-	// Check if customer already exists
-	var existing models.Customer
-	if err := database.DB.Where("email = ?", customer.Email).First(&existing).Error; err == nil {
-		return c.Status(fiber.StatusConflict).SendString("Customer with this email already exists")
-	} else if err != gorm.ErrRecordNotFound {
-		return c.Status(fiber.StatusInternalServerError).SendString("Database error")
+// CreateCustomer registers a new customer.
+func (h *CustomerController) CreateCustomer(c *fiber.Ctx) error {
+	// Parse and validate the request body
+	var req dto.CreateCustomerRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Hash the password
-	hashedPassword, err := utils.HashPassword(customer.Password)
+	customer, err := h.customers.Register(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error hashing password")
+		return err
 	}
-	customer.Password = hashedPassword
 
-	// Save the new customer
-	if err := database.DB.Create(&customer).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(customer)
-}
-
-type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	return c.Status(fiber.StatusCreated).JSON(dto.NewCustomerResponse(customer))
 }
 
 // LoginCustomer authenticates a customer and issues a JWT token.
-func LoginCustomer(c *fiber.Ctx) error {
-	var req LoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
+func (h *CustomerController) LoginCustomer(c *fiber.Ctx) error {
+	// Parse and validate the credentials
+	var req dto.LoginRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Validate email format
-	if !utils.ValidateEmail(req.Email) {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid email format")
-	}
-
-	// Lookup customer in the database
-	var customer models.Customer
-	if err := database.DB.Where("email = ?", req.Email).First(&customer).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusUnauthorized).SendString("Invalid credentials")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString("Database error")
-	}
-
-	// Compare provided password with hashed password
-	if !utils.ComparePassword(customer.Password, req.Password) {
-		return c.Status(fiber.StatusUnauthorized).SendString("Invalid credentials")
+	customer, err := h.customers.Authenticate(c.UserContext(), req.Email, req.Password)
+	telemetry.RecordLogin(middlewares.RoleCustomer, err)
+	if err != nil {
+		return err
 	}
 
 	// Generate a JWT token (expires in 24 hours)
-	token, err := middlewares.GenerateJWT(customer.ID, customer.Email, time.Hour*24)
+	token, err := middlewares.GenerateJWT(customer.ID, customer.Email, middlewares.RoleCustomer, time.Hour*24)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error generating token")
+		return utils.ErrInternal("Error generating token")
 	}
 
-	// Set the token in a secure HTTP-only cookie
-	c.Cookie(&fiber.Cookie{
-		Name:     "jwt_token",
-		Value:    token,
-		Expires:  time.Now().Add(time.Hour * 24),
-		HTTPOnly: true,
-		Secure:   false, // change to true if using HTTPS
-	})
+	// Set the token in an HTTP-only cookie, with the CSRF token its
+	// mutations must echo.
+	csrfToken := middlewares.SetAuthCookies(c, token, time.Now().Add(time.Hour*24))
 
-	return c.JSON(fiber.Map{
-		"message": "Login successful",
-		"token":   token,
+	return c.JSON(dto.TokenResponse{
+		Message:   "Login successful",
+		Token:     token,
+		CSRFToken: csrfToken,
 	})
 }
 
-// GetCustomers retrieves all customers with selected fields.
-func GetCustomers(c *fiber.Ctx) error {
-	// Define a lightweight struct to return only the necessary fields.
-	var customers []struct {
-		ID        uint      `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Name      string    `json:"name"`
-		Email     string    `json:"email"`
-	}
-
-	// Query only selected fields from the Customer model.
-	if err := database.DB.Model(&models.Customer{}).
-		Select("id, created_at, updated_at, name, email").
-		Scan(&customers).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+// GetCustomers retrieves all customers.
+func (h *CustomerController) GetCustomers(c *fiber.Ctx) error {
+	customers, err := h.customers.List(c.UserContext())
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(customers)
+	return c.Status(fiber.StatusOK).JSON(dto.NewCustomerResponses(customers))
 }
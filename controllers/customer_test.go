@@ -0,0 +1,40 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCustomerSignupAndLogin(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		signup := map[string]any{"name": "Ada", "email": "ada@customers.test", "password": testPassword}
+
+		var customer struct {
+			ID    uint   `json:"id"`
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		}
+		api.expect(fiber.StatusCreated, fiber.MethodPost, "/api/v2/customer/signup", signup).decode(t, &customer)
+		if customer.ID == 0 || customer.Name != "Ada" || customer.Email != "ada@customers.test" {
+			t.Errorf("signup answered %+v", customer)
+		}
+		api.expect(fiber.StatusConflict, fiber.MethodPost, "/api/v2/customer/signup", signup)
+		api.expect(fiber.StatusUnprocessableEntity, fiber.MethodPost, "/api/v2/customer/signup",
+			map[string]any{"name": "Weak", "email": "weak@customers.test", "password": "short"})
+
+		var login struct {
+			Token     string `json:"token"`
+			CSRFToken string `json:"csrf_token"`
+		}
+		api.expect(fiber.StatusOK, fiber.MethodPost, "/api/v2/customer/login",
+			map[string]any{"email": "ada@customers.test", "password": testPassword}).decode(t, &login)
+		if login.Token == "" || login.CSRFToken == "" {
+			t.Errorf("login answered %+v", login)
+		}
+		api.expect(fiber.StatusUnauthorized, fiber.MethodPost, "/api/v2/customer/login",
+			map[string]any{"email": "ada@customers.test", "password": "Wr0ng-password"})
+		api.expect(fiber.StatusUnauthorized, fiber.MethodPost, "/api/v2/customer/login",
+			map[string]any{"email": "nobody@customers.test", "password": testPassword})
+	})
+}
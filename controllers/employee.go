@@ -1,188 +1,115 @@
 package controllers
 
 import (
-	"time"
-
 	"github.com/gofiber/fiber/v2"
-	"github.com/mohamedhabas11/golang-api/database"
-	"github.com/mohamedhabas11/golang-api/models"
-	"github.com/mohamedhabas11/golang-api/utils"
-	"gorm.io/gorm"
-)
-
-// CreateEmployee registers a new shop employee.
-func CreateEmployee(c *fiber.Ctx) error {
-	var employee models.ShopEmployee
-	if err := c.BodyParser(&employee); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
-	}
 
-	// Validate email format.
-	if !utils.ValidateEmail(employee.Email) {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid email format")
-	}
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/services"
+)
 
-	// Check if an employee with the same email already exists.
-	var existing models.ShopEmployee
-	if err := database.DB.Where("email = ?", employee.Email).First(&existing).Error; err == nil {
-		return c.Status(fiber.StatusConflict).SendString("Employee with this email already exists")
-	} else if err != gorm.ErrRecordNotFound {
-		return c.Status(fiber.StatusInternalServerError).SendString("Database error")
-	}
+// EmployeeController serves the shop employee endpoints.
+type EmployeeController struct {
+	employees *services.EmployeeService
+}
 
-	// Ensure the associated shop exists.
-	var shop models.Shop
-	if err := database.DB.First(&shop, employee.ShopID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).SendString("Associated shop not found")
-	}
+// NewEmployeeController creates an EmployeeController.
+func NewEmployeeController(employees *services.EmployeeService) *EmployeeController {
+	return &EmployeeController{employees: employees}
+}
 
-	// Validate employee password (using minimum length of 8).
-	if err := utils.ValidatePassword(employee.Password, utils.NewPasswordValidationConfig(8)); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid password: " + err.Error())
+// CreateEmployee registers a new shop employee.
+func (h *EmployeeController) CreateEmployee(c *fiber.Ctx) error {
+	// Parse and validate the request body.
+	var req dto.CreateEmployeeRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Hash the password.
-	hashedPassword, err := utils.HashPassword(employee.Password)
+	employee, err := h.employees.Create(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error hashing password")
+		return err
 	}
-	employee.Password = hashedPassword
 
-	// Create the employee.
-	if err := database.DB.Create(&employee).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-	}
-
-	return c.Status(fiber.StatusCreated).JSON(employee)
+	return c.Status(fiber.StatusCreated).JSON(dto.NewEmployeeResponse(employee))
 }
 
-// GetEmployees retrieves all shop employees.
-func GetEmployees(c *fiber.Ctx) error {
-	var employees []models.ShopEmployee
-	if err := database.DB.Find(&employees).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-	}
-
-	// Prepare a response without sensitive info.
-	type EmployeeResponse struct {
-		ID        uint      `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Name      string    `json:"name"`
-		Email     string    `json:"email"`
-		ShopID    uint      `json:"shop_id"`
-	}
-
-	var response []EmployeeResponse
-	for _, emp := range employees {
-		response = append(response, EmployeeResponse{
-			ID:        emp.ID,
-			CreatedAt: emp.CreatedAt,
-			UpdatedAt: emp.UpdatedAt,
-			Name:      emp.Name,
-			Email:     emp.Email,
-			ShopID:    emp.ShopID,
-		})
+// GetEmployees retrieves all shop employees, or the deleted ones with ?deleted=true.
+func (h *EmployeeController) GetEmployees(c *fiber.Ctx) error {
+	employees, err := h.employees.List(c.UserContext(), listOptions(c))
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(response)
+	return c.Status(fiber.StatusOK).JSON(dto.NewEmployeeResponses(employees))
 }
 
 // GetEmployee retrieves a single shop employee by ID.
-func GetEmployee(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var employee models.ShopEmployee
-	if err := database.DB.First(&employee, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Employee not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+func (h *EmployeeController) GetEmployee(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Hide the password in the response.
-	employee.Password = ""
-	return c.Status(fiber.StatusOK).JSON(employee)
+	employee, err := h.employees.Get(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewEmployeeResponse(employee))
 }
 
 // UpdateEmployee updates an existing shop employee.
-func UpdateEmployee(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var employee models.ShopEmployee
-
-	// Find the employee.
-	if err := database.DB.First(&employee, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Employee not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+func (h *EmployeeController) UpdateEmployee(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	var updateData models.ShopEmployee
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
+	// Parse and validate update data.
+	var req dto.UpdateEmployeeRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Update allowed fields.
-	if updateData.Name != "" {
-		employee.Name = updateData.Name
-	}
-	if updateData.Email != "" && updateData.Email != employee.Email {
-		if !utils.ValidateEmail(updateData.Email) {
-			return c.Status(fiber.StatusBadRequest).SendString("Invalid email format")
-		}
-		// Check if new email is already taken.
-		var exists models.ShopEmployee
-		if err := database.DB.Where("email = ?", updateData.Email).First(&exists).Error; err == nil {
-			return c.Status(fiber.StatusConflict).SendString("Another employee with this email already exists")
-		}
-		employee.Email = updateData.Email
+	employee, err := h.employees.Update(c.UserContext(), id, req)
+	if err != nil {
+		return err
 	}
 
-	// Update password if provided.
-	if updateData.Password != "" {
-		if err := utils.ValidatePassword(updateData.Password, utils.NewPasswordValidationConfig(8)); err != nil {
-			return c.Status(fiber.StatusBadRequest).SendString("Invalid password: " + err.Error())
-		}
-		hashedPassword, err := utils.HashPassword(updateData.Password)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString("Error hashing password")
-		}
-		employee.Password = hashedPassword
-	}
+	return c.Status(fiber.StatusOK).JSON(dto.NewEmployeeResponse(employee))
+}
 
-	// Optionally update ShopID.
-	if updateData.ShopID != 0 && updateData.ShopID != employee.ShopID {
-		var shop models.Shop
-		if err := database.DB.First(&shop, updateData.ShopID).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("New associated shop not found")
-		}
-		employee.ShopID = updateData.ShopID
+// DeleteEmployee soft-deletes a shop employee by ID, or removes it permanently
+// with ?purge=true for the shop owner.
+func (h *EmployeeController) DeleteEmployee(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	if err := database.DB.Save(&employee).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	principal, _ := middlewares.CurrentPrincipal(c)
+	opts := deleteOptions(c)
+	if err := h.employees.Delete(c.UserContext(), principal.UserID, id, opts); err != nil {
+		return err
 	}
 
-	// Hide the password in the response.
-	employee.Password = ""
-	return c.Status(fiber.StatusOK).JSON(employee)
+	return c.Status(fiber.StatusOK).JSON(deletedMessage("Employee", opts))
 }
 
-// DeleteEmployee deletes a shop employee by ID.
-func DeleteEmployee(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var employee models.ShopEmployee
-
-	if err := database.DB.First(&employee, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Employee not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+// RestoreEmployee brings a soft-deleted shop employee back from the trash, for
+// the shop owner.
+func (h *EmployeeController) RestoreEmployee(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	if err := database.DB.Delete(&employee).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	principal, _ := middlewares.CurrentPrincipal(c)
+	employee, err := h.employees.Restore(c.UserContext(), principal.UserID, id)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).SendString("Employee deleted successfully")
+	return c.Status(fiber.StatusOK).JSON(dto.NewEmployeeResponse(employee))
 }
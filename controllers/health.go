@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// HealthController serves the health and monitoring endpoints.
+type HealthController struct {
+	health *services.HealthService
+}
+
+// NewHealthController creates a HealthController.
+func NewHealthController(health *services.HealthService) *HealthController {
+	return &HealthController{health: health}
+}
+
+// Database checks that the database is reachable and reports the statistics of
+// its connection pool.
+func (h *HealthController) Database(c *fiber.Ctx) error {
+	stats, err := h.health.Database(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewDatabaseHealthResponse(stats))
+}
+
+// Live answers as long as the server is up, draining included: restarting a
+// replica doesn't fix its dependencies.
+func (h *HealthController) Live(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(dto.StatusResponse{Status: "ok"})
+}
+
+// Started answers 503 until startup completed, migrations and seed included.
+func (h *HealthController) Started(c *fiber.Ctx) error {
+	if !h.health.Started() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(dto.StatusResponse{Status: services.ReadinessStarting})
+	}
+	return c.Status(fiber.StatusOK).JSON(dto.StatusResponse{Status: "started"})
+}
+
+// Ready runs the readiness checks and answers 503, with the outcome of every
+// check, when the replica shouldn't get traffic.
+func (h *HealthController) Ready(c *fiber.Ctx) error {
+	readiness := h.health.Readiness(c.UserContext())
+	response := dto.ReadinessResponse{Status: readiness.Status, Checks: make([]dto.CheckResponse, 0, len(readiness.Checks))}
+	for _, check := range readiness.Checks {
+		result := dto.CheckResponse{
+			Name:       check.Name,
+			Status:     check.Status,
+			Optional:   check.Optional,
+			DurationMs: float64(check.Duration.Microseconds()) / 1000,
+		}
+		if check.Error != nil {
+			result.Error = check.Error.Error()
+		}
+		response.Checks = append(response.Checks, result)
+	}
+
+	status := fiber.StatusOK
+	if !readiness.Ready() {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(response)
+}
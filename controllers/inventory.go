@@ -2,110 +2,128 @@ package controllers
 
 import (
 	"github.com/gofiber/fiber/v2"
-	"github.com/mohamedhabas11/golang-api/database"
+
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
 	"github.com/mohamedhabas11/golang-api/models"
-	"gorm.io/gorm"
+	"github.com/mohamedhabas11/golang-api/services"
 )
 
-// CreateInventory creates a new inventory for a shop.
-func CreateInventory(c *fiber.Ctx) error {
-	var inventory models.Inventory
+// InventoryController serves the inventory endpoints.
+type InventoryController struct {
+	inventories *services.InventoryService
+}
 
-	// Parse the incoming request body.
-	if err := c.BodyParser(&inventory); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
-	}
+// NewInventoryController creates an InventoryController.
+func NewInventoryController(inventories *services.InventoryService) *InventoryController {
+	return &InventoryController{inventories: inventories}
+}
 
-	// Ensure the associated shop exists.
-	var shop models.Shop
-	if err := database.DB.First(&shop, inventory.ShopID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).SendString("Shop not found")
+// CreateInventory creates a new inventory for a shop.
+func (h *InventoryController) CreateInventory(c *fiber.Ctx) error {
+	// Parse and validate the incoming request body.
+	var req dto.CreateInventoryRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Create the inventory.
-	if err := database.DB.Create(&inventory).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	inventory, err := h.inventories.Create(c.UserContext(), req)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(inventory)
+	return c.Status(fiber.StatusCreated).JSON(dto.NewInventoryResponse(inventory))
 }
 
-// GetInventories retrieves all inventories with their items.
-func GetInventories(c *fiber.Ctx) error {
-	var inventories []models.Inventory
-
-	// Preload Items so that each inventory returns its items.
-	if err := database.DB.Preload("Items").Find(&inventories).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+// GetInventories retrieves all inventories with their items, or the deleted
+// ones with ?deleted=true.
+func (h *InventoryController) GetInventories(c *fiber.Ctx) error {
+	opts := listOptions(c)
+	inventories, err := h.inventories.List(c.UserContext(), opts)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(inventories)
+	v := newVersions(c)
+	for _, inventory := range inventories {
+		v.inventory(inventory)
+	}
+	var tags []string // The trash isn't cached: the retention job purges it
+	if !opts.Deleted {
+		tags = []string{cache.ListTag(models.AuditInventory), models.AuditInventory}
+	}
+	return sendVersioned(c, v, dto.NewInventoryResponses(inventories), tags...)
 }
 
 // GetInventory retrieves a single inventory by ID, including its items.
-func GetInventory(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var inventory models.Inventory
-
-	if err := database.DB.Preload("Items").First(&inventory, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Inventory not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+func (h *InventoryController) GetInventory(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	inventory, err := h.inventories.Get(c.UserContext(), id)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(inventory)
+	v := newVersions(c)
+	v.inventory(inventory)
+	return sendVersioned(c, v, dto.NewInventoryResponse(inventory), cache.Tag(models.AuditInventory, inventory.ID), models.AuditInventory)
 }
 
 // UpdateInventory updates an existing inventory record.
-func UpdateInventory(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var inventory models.Inventory
-
-	// Find the inventory by ID.
-	if err := database.DB.First(&inventory, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Inventory not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+func (h *InventoryController) UpdateInventory(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Parse update data.
-	var updateData models.Inventory
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	// Parse and validate update data.
+	var req dto.UpdateInventoryRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Update only allowed fields (for example, InventoryName).
-	if updateData.InventoryName != "" {
-		inventory.InventoryName = updateData.InventoryName
+	inventory, err := h.inventories.Update(c.UserContext(), id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewInventoryResponse(inventory))
+}
+
+// DeleteInventory soft-deletes an inventory and its items, or removes them
+// permanently with ?purge=true, for the shop owner.
+func (h *InventoryController) DeleteInventory(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Save updates.
-	if err := database.DB.Save(&inventory).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	principal, _ := middlewares.CurrentPrincipal(c)
+	opts := deleteOptions(c)
+	if err := h.inventories.Delete(c.UserContext(), principal.UserID, id, opts); err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(inventory)
+	return c.Status(fiber.StatusOK).JSON(deletedMessage("Inventory", opts))
 }
 
-// DeleteInventory deletes an inventory by its ID.
-func DeleteInventory(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var inventory models.Inventory
-
-	// Ensure the inventory exists.
-	if err := database.DB.First(&inventory, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Inventory not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+// RestoreInventory brings a soft-deleted inventory back from the trash along
+// with the items that were deleted together with it, for the shop owner.
+func (h *InventoryController) RestoreInventory(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Delete the inventory.
-	if err := database.DB.Delete(&inventory).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	principal, _ := middlewares.CurrentPrincipal(c)
+	inventory, err := h.inventories.Restore(c.UserContext(), principal.UserID, id)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).SendString("Inventory deleted successfully")
+	return c.Status(fiber.StatusOK).JSON(dto.NewInventoryResponse(inventory))
 }
@@ -0,0 +1,32 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestInventoryConditionalReads(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		shop := api.createShop("bakery")
+		path := "/api/v2/inventories/" + id(shop.InventoryID)
+
+		etag := api.expect(fiber.StatusOK, fiber.MethodGet, path, nil).Header.Get(fiber.HeaderETag)
+		if etag == "" {
+			t.Fatal("no ETag")
+		}
+		api.expect(fiber.StatusNotModified, fiber.MethodGet, path, nil, fiber.HeaderIfNoneMatch, etag)
+
+		// A change of one of its items changes the inventory.
+		api.expect(fiber.StatusOK, fiber.MethodPut, "/api/v2/items/"+id(shop.ItemID), map[string]any{"quantity": 9})
+		resp := api.expect(fiber.StatusOK, fiber.MethodGet, path, nil, fiber.HeaderIfNoneMatch, etag)
+		if resp.Header.Get(fiber.HeaderETag) == etag {
+			t.Error("the ETag didn't change with the item")
+		}
+
+		// Each API version has its own representation.
+		if other := api.expect(fiber.StatusOK, fiber.MethodGet, "/api/v1/inventories/"+id(shop.InventoryID), nil).Header.Get(fiber.HeaderETag); other == resp.Header.Get(fiber.HeaderETag) {
+			t.Error("the API versions share the ETag")
+		}
+	})
+}
@@ -2,119 +2,126 @@ package controllers
 
 import (
 	"github.com/gofiber/fiber/v2"
-	"github.com/mohamedhabas11/golang-api/database"
+
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
 	"github.com/mohamedhabas11/golang-api/models"
-	"gorm.io/gorm"
+	"github.com/mohamedhabas11/golang-api/services"
 )
 
-// CreateItem creates a new item under a given inventory.
-func CreateItem(c *fiber.Ctx) error {
-	var item models.Item
+// ItemController serves the inventory item endpoints.
+type ItemController struct {
+	items *services.ItemService
+}
 
-	// Parse the incoming request body.
-	if err := c.BodyParser(&item); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
-	}
+// NewItemController creates an ItemController.
+func NewItemController(items *services.ItemService) *ItemController {
+	return &ItemController{items: items}
+}
 
-	// Ensure the associated inventory exists.
-	var inventory models.Inventory
-	if err := database.DB.First(&inventory, item.InventoryID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).SendString("Inventory not found")
+// CreateItem creates a new item under a given inventory.
+func (h *ItemController) CreateItem(c *fiber.Ctx) error {
+	// Parse and validate the incoming request body.
+	var req dto.CreateItemRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Create the item.
-	if err := database.DB.Create(&item).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	item, err := h.items.Create(c.UserContext(), req)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(item)
+	return c.Status(fiber.StatusCreated).JSON(dto.NewItemResponse(item))
 }
 
-// GetItems retrieves all items.
-func GetItems(c *fiber.Ctx) error {
-	var items []models.Item
-	if err := database.DB.Find(&items).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+// GetItems retrieves all items, or the deleted ones with ?deleted=true.
+func (h *ItemController) GetItems(c *fiber.Ctx) error {
+	opts := listOptions(c)
+	items, err := h.items.List(c.UserContext(), opts)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(items)
+	v := newVersions(c)
+	for _, item := range items {
+		v.add(models.AuditItem, item.Model)
+	}
+	var tags []string // The trash isn't cached: the retention job purges it
+	if !opts.Deleted {
+		tags = []string{cache.ListTag(models.AuditItem), models.AuditItem}
+	}
+	return sendVersioned(c, v, dto.NewItemResponses(items), tags...)
 }
 
 // GetItem retrieves a single item by its ID.
-func GetItem(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var item models.Item
-
-	if err := database.DB.First(&item, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Item not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+func (h *ItemController) GetItem(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	item, err := h.items.Get(c.UserContext(), id)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(item)
+	v := newVersions(c)
+	v.add(models.AuditItem, item.Model)
+	return sendVersioned(c, v, dto.NewItemResponse(item), cache.Tag(models.AuditItem, item.ID), models.AuditItem)
 }
 
 // UpdateItem updates an existing item.
-func UpdateItem(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var item models.Item
-
-	// Find the item.
-	if err := database.DB.First(&item, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Item not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+func (h *ItemController) UpdateItem(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Parse update data.
-	var updateData models.Item
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	// Parse and validate update data.
+	var req dto.UpdateItemRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Update allowed fields.
-	if updateData.Name != "" {
-		item.Name = updateData.Name
-	}
-	if updateData.Quantity != 0 {
-		item.Quantity = updateData.Quantity
+	item, err := h.items.Update(c.UserContext(), id, req)
+	if err != nil {
+		return err
 	}
-	// Optionally allow changing the inventory, but check that the new inventory exists.
-	if updateData.InventoryID != 0 && updateData.InventoryID != item.InventoryID {
-		var newInventory models.Inventory
-		if err := database.DB.First(&newInventory, updateData.InventoryID).Error; err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("New inventory not found")
-		}
-		item.InventoryID = updateData.InventoryID
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewItemResponse(item))
+}
+
+// DeleteItem soft-deletes an item by its ID, or removes it permanently with
+// ?purge=true for the shop owner.
+func (h *ItemController) DeleteItem(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Save updates.
-	if err := database.DB.Save(&item).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	principal, _ := middlewares.CurrentPrincipal(c)
+	opts := deleteOptions(c)
+	if err := h.items.Delete(c.UserContext(), principal.UserID, id, opts); err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(item)
+	return c.Status(fiber.StatusOK).JSON(deletedMessage("Item", opts))
 }
 
-// DeleteItem deletes an item by its ID.
-func DeleteItem(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var item models.Item
-
-	// Check if the item exists.
-	if err := database.DB.First(&item, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Item not found")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+// RestoreItem brings a soft-deleted item back from the trash, for the shop owner.
+func (h *ItemController) RestoreItem(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Delete the item.
-	if err := database.DB.Delete(&item).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	principal, _ := middlewares.CurrentPrincipal(c)
+	item, err := h.items.Restore(c.UserContext(), principal.UserID, id)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).SendString("Item deleted successfully")
+	return c.Status(fiber.StatusOK).JSON(dto.NewItemResponse(item))
 }
@@ -0,0 +1,57 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type testItem struct {
+	ID          uint   `json:"id"`
+	InventoryID uint   `json:"inventory_id"`
+	Name        string `json:"name"`
+	Quantity    int    `json:"quantity"`
+}
+
+func TestItemLifecycle(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		shop := api.createShop("grocer")
+		path := "/api/v2/items/" + id(shop.ItemID)
+
+		api.expect(fiber.StatusNotFound, fiber.MethodPost, "/api/v2/items",
+			map[string]any{"inventory_id": 999, "name": "Lost", "quantity": 1})
+		api.expect(fiber.StatusUnprocessableEntity, fiber.MethodPost, "/api/v2/items",
+			map[string]any{"inventory_id": shop.InventoryID, "name": "Negative", "quantity": -1})
+
+		// Omitted fields are left unchanged, a quantity of 0 is set.
+		var item testItem
+		api.expect(fiber.StatusOK, fiber.MethodPut, path, map[string]any{"quantity": 0}).decode(t, &item)
+		if item.Quantity != 0 || item.Name != "grocer item" || item.InventoryID != shop.InventoryID {
+			t.Errorf("updated item %+v", item)
+		}
+
+		var spare struct {
+			ID uint `json:"id"`
+		}
+		api.expect(fiber.StatusCreated, fiber.MethodPost, "/api/v2/inventories",
+			map[string]any{"shop_id": shop.ID, "inventory_name": "Spare"}).decode(t, &spare)
+		api.expect(fiber.StatusOK, fiber.MethodPut, path, map[string]any{"inventory_id": spare.ID}).decode(t, &item)
+		if item.InventoryID != spare.ID {
+			t.Errorf("moved item %+v, want inventory %d", item, spare.ID)
+		}
+
+		api.expect(fiber.StatusOK, fiber.MethodDelete, path, nil)
+		api.expect(fiber.StatusNotFound, fiber.MethodGet, path, nil)
+		var trash []testItem
+		api.expect(fiber.StatusOK, fiber.MethodGet, "/api/v2/items?deleted=true", nil).decode(t, &trash)
+		if len(trash) != 1 || trash[0].ID != shop.ItemID {
+			t.Errorf("got trash %+v, want item %d", trash, shop.ItemID)
+		}
+
+		api.expect(fiber.StatusOK, fiber.MethodPost, path+"/restore", nil, fiber.HeaderAuthorization, shop.Auth)
+		api.expect(fiber.StatusOK, fiber.MethodGet, path, nil).decode(t, &item)
+		if item.Quantity != 0 || item.InventoryID != spare.ID {
+			t.Errorf("restored item %+v", item)
+		}
+	})
+}
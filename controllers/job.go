@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// JobController lets operators inspect and steer the background job queue.
+type JobController struct {
+	jobs *services.JobService
+}
+
+// NewJobController creates a JobController.
+func NewJobController(jobs *services.JobService) *JobController {
+	return &JobController{jobs: jobs}
+}
+
+// GetJobs pages through the jobs, newest first.
+func (h *JobController) GetJobs(c *fiber.Ctx) error {
+	var query dto.JobQuery
+	if err := dto.BindQuery(c, &query); err != nil {
+		return err
+	}
+
+	jobs, err := h.jobs.ListJobs(c.UserContext(), query.ToFilter())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewJobResponses(jobs))
+}
+
+// GetJobStats summarizes the queue, the cron schedules and the scheduler leader.
+func (h *JobController) GetJobStats(c *fiber.Ctx) error {
+	stats, err := h.jobs.Stats(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	response := dto.JobStatsResponse{
+		Counts:    stats.Counts,
+		Types:     stats.Types,
+		Schedules: []dto.JobScheduleResponse{},
+	}
+	for _, schedule := range stats.Schedules {
+		response.Schedules = append(response.Schedules, dto.JobScheduleResponse{
+			Name:      schedule.Name,
+			Spec:      schedule.Spec,
+			JobType:   schedule.JobType,
+			NextRunAt: schedule.NextRunAt,
+			LastRunAt: schedule.LastRunAt,
+		})
+	}
+	if stats.Leader != nil {
+		response.Leader = &dto.JobLeaderResponse{Holder: stats.Leader.Holder, ExpiresAt: stats.Leader.ExpiresAt}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetJob returns a job.
+func (h *JobController) GetJob(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	job, err := h.jobs.GetJob(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewJobResponse(job))
+}
+
+// RetryJob queues a dead or cancelled job again.
+func (h *JobController) RetryJob(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	job, err := h.jobs.RetryJob(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewJobResponse(job))
+}
+
+// CancelJob stops a queued job from running.
+func (h *JobController) CancelJob(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	job, err := h.jobs.CancelJob(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewJobResponse(job))
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/logging"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// GetLogLevel returns the minimum level of the logs of this replica.
+func GetLogLevel(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(dto.LogLevelResponse{Level: strings.ToLower(logging.Level().String())})
+}
+
+// SetLogLevel changes the minimum level of the logs of this replica until it
+// restarts, such as debug to log every SQL statement while investigating.
+func SetLogLevel(c *fiber.Ctx) error {
+	var req dto.LogLevelRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
+	}
+
+	previous := logging.Level()
+	level, err := logging.SetLevel(req.Level)
+	if err != nil {
+		return utils.ErrBadRequest(err.Error())
+	}
+	slog.WarnContext(c.UserContext(), "Log level changed", "from", previous.String(), "to", level.String())
+
+	return c.Status(fiber.StatusOK).JSON(dto.LogLevelResponse{Level: strings.ToLower(level.String())})
+}
@@ -0,0 +1,406 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	swaggerfiles "github.com/swaggo/files/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/events"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/openapi"
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Security schemes of the API.
+const (
+	bearerAuth = "bearerAuth"
+	adminToken = "adminToken"
+)
+
+// Query parameters shared by the endpoints of soft-deletable resources.
+var (
+	deletedParams = []openapi.Parameter{
+		{Name: "deleted", In: "query", Description: "List the soft-deleted rows instead of the active ones", Schema: &openapi.Schema{Type: []string{"boolean"}}},
+	}
+	deleteParams = []openapi.Parameter{
+		{Name: "purge", In: "query", Description: "Remove the row permanently, even from the trash", Schema: &openapi.Schema{Type: []string{"boolean"}}},
+		{Name: "force", In: "query", Description: "Skip the safeguards of the delete", Schema: &openapi.Schema{Type: []string{"boolean"}}},
+	}
+	conditionalParams = []openapi.Parameter{
+		{Name: fiber.HeaderIfNoneMatch, In: "header", Description: "ETag of the client's copy, answered 304 Not Modified while current", Schema: &openapi.Schema{Type: []string{"string"}}},
+	}
+	versionParams = []openapi.Parameter{
+		{Name: "version", In: "query", Description: "API version of the document, v1, v2... The latest by default", Schema: &openapi.Schema{Type: []string{"string"}}},
+	}
+)
+
+// endpoints documents the handlers of the route table by name. The path, path
+// parameters and security of an operation come from the route it is
+// registered on.
+var endpoints = map[string]openapi.Endpoint{
+	// Default routes.
+	"DefaultRoute": {Tag: "meta", Summary: "Welcome message", Responses: ok(dto.MessageResponse{})},
+	"HealthRoute":  {Tag: "meta", Summary: "Liveness of the server", Responses: map[int]any{http.StatusOK: openapi.Content{MediaType: fiber.MIMETextPlain}}},
+	"ServeOpenAPI": {Tag: "meta", Summary: "The OpenAPI document of an API version", Params: versionParams, Responses: map[int]any{http.StatusOK: openapi.Content{MediaType: fiber.MIMEApplicationJSON}}, Problems: []int{http.StatusBadRequest}},
+	"MetricsRoute": {OperationID: "getMetrics", Tag: "meta", Summary: "Prometheus metrics", Responses: map[int]any{http.StatusOK: openapi.Content{MediaType: fiber.MIMETextPlain}}},
+	"Live":         {OperationID: "getLiveness", Tag: "meta", Summary: "Liveness probe, answering as long as the server is up", Responses: ok(dto.StatusResponse{})},
+	"Started":      {OperationID: "getStartup", Tag: "meta", Summary: "Startup probe, answering 503 until the migrations and seed are done", Responses: map[int]any{http.StatusOK: dto.StatusResponse{}, http.StatusServiceUnavailable: dto.StatusResponse{}}},
+	"Ready":        {OperationID: "getReadiness", Tag: "meta", Summary: "Readiness probe, answering 503 with the failed checks while starting, draining or missing a dependency", Responses: map[int]any{http.StatusOK: dto.ReadinessResponse{}, http.StatusServiceUnavailable: dto.ReadinessResponse{}}},
+	"Database":     {OperationID: "getDatabaseHealth", Tag: "meta", Summary: "Database reachability and connection pool statistics", Responses: ok(dto.DatabaseHealthResponse{}), Problems: []int{http.StatusServiceUnavailable}},
+
+	// Customers.
+	"CreateCustomer": {Tag: "customers", Summary: "Register a customer", Body: dto.CreateCustomerRequest{}, Responses: created(dto.CustomerResponse{}), Problems: []int{http.StatusConflict}},
+	"LoginCustomer":  {Tag: "customers", Summary: "Log a customer in", Body: dto.LoginRequest{}, Responses: ok(dto.TokenResponse{}), Problems: []int{http.StatusUnauthorized}},
+	"GetCustomers":   {Tag: "customers", Summary: "List the customers", Responses: ok([]dto.CustomerResponse{})},
+
+	// Shops and their owners.
+	"CreateShop":     {Tag: "shops", Summary: "Register a shop with its owner", Body: dto.CreateShopRequest{}, Responses: created(dto.ShopResponse{}), Problems: []int{http.StatusConflict}},
+	"LoginShopOwner": {Tag: "shops", Summary: "Log a shop owner in", Body: dto.LoginRequest{}, Responses: ok(dto.TokenResponse{}), Problems: []int{http.StatusUnauthorized}},
+	"GetShops":       {Tag: "shops", Summary: "List the shops with their owners", Params: conditionalParams, Responses: versioned([]dto.ShopResponse{})},
+	"GetShop": {
+		Tag:     "shops",
+		Summary: "Get a shop with its owner",
+		Params: append([]openapi.Parameter{
+			{Name: "expand", In: "query", Description: "Comma-separated relations to include: employees, inventories, items", Schema: &openapi.Schema{Type: []string{"string"}}},
+		}, conditionalParams...),
+		Responses: versioned(dto.ShopResponse{}),
+		Problems:  []int{http.StatusUnprocessableEntity},
+	},
+	"UpdateShop":   {Tag: "shops", Summary: "Update a shop", Body: dto.UpdateShopRequest{}, Responses: ok(dto.ShopResponse{}), Problems: []int{http.StatusForbidden, http.StatusConflict}},
+	"DeleteShop":   {Tag: "shops", Summary: "Delete a shop", Params: deleteParams, Responses: ok(dto.MessageResponse{}), Problems: []int{http.StatusForbidden, http.StatusConflict}},
+	"RestoreShop":  {Tag: "shops", Summary: "Restore a deleted shop", Responses: ok(dto.ShopResponse{}), Problems: []int{http.StatusForbidden, http.StatusConflict}},
+	"GetMyShops":   {Tag: "shops", Summary: "List the shops of the authenticated owner", Responses: ok([]dto.ShopResponse{})},
+	"CreateMyShop": {Tag: "shops", Summary: "Open another shop for the authenticated owner", Body: dto.CreateOwnedShopRequest{}, Responses: created(dto.ShopResponse{}), Problems: []int{http.StatusConflict}},
+
+	// Shop ownership transfers.
+	"CreateShopTransfer":  {Tag: "transfers", Summary: "Offer a shop to another owner", Body: dto.TransferShopRequest{}, Responses: created(dto.TransferResponse{}), Problems: []int{http.StatusForbidden, http.StatusConflict}},
+	"AcceptShopTransfer":  {Tag: "transfers", Summary: "Accept the transfer of a shop", Responses: ok(dto.ShopResponse{}), Problems: []int{http.StatusConflict}},
+	"DeclineShopTransfer": {Tag: "transfers", Summary: "Decline the transfer of a shop", Responses: ok(dto.TransferResponse{}), Problems: []int{http.StatusConflict}},
+	"CancelShopTransfer":  {Tag: "transfers", Summary: "Cancel the transfer of a shop", Responses: ok(dto.TransferResponse{}), Problems: []int{http.StatusConflict}},
+	"GetMyTransfers":      {Tag: "transfers", Summary: "List the transfers of the authenticated owner", Responses: ok([]dto.TransferResponse{})},
+
+	// Shop webhooks.
+	"GetWebhooks":              {Tag: "webhooks", Summary: "List the webhooks of a shop", Responses: ok([]dto.WebhookResponse{})},
+	"CreateWebhook":            {Tag: "webhooks", Summary: "Register a webhook, revealing its signing secret once", Body: dto.CreateWebhookRequest{}, Responses: created(dto.WebhookResponse{})},
+	"GetWebhook":               {Tag: "webhooks", Summary: "Get a webhook", Responses: ok(dto.WebhookResponse{})},
+	"UpdateWebhook":            {Tag: "webhooks", Summary: "Update a webhook", Body: dto.UpdateWebhookRequest{}, Responses: ok(dto.WebhookResponse{})},
+	"DeleteWebhook":            {Tag: "webhooks", Summary: "Delete a webhook", Responses: ok(dto.MessageResponse{})},
+	"PingWebhook":              {Tag: "webhooks", Summary: "Send a test event to a webhook", Responses: ok(dto.WebhookDeliveryResponse{})},
+	"GetWebhookDeliveries":     {Tag: "webhooks", Summary: "Page through the deliveries of a webhook, newest first", Query: dto.WebhookDeliveryQuery{}, Responses: ok([]dto.WebhookDeliveryResponse{})},
+	"RedeliverWebhookDelivery": {Tag: "webhooks", Summary: "Deliver an event to a webhook again", Responses: created(dto.WebhookDeliveryResponse{}), Problems: []int{http.StatusConflict}},
+
+	// Live streams.
+	"Stream": {
+		Tag:     "streams",
+		Summary: "Stream the changes of the inventories and items of a shop or of one inventory",
+		Description: "Answers a text/event-stream, or one JSON event per message after a WebSocket upgrade. " +
+			"Both resume after the event ID of the Last-Event-ID header or of last_event_id.",
+		Query: dto.StreamQuery{},
+		Params: []openapi.Parameter{
+			{Name: "Last-Event-ID", In: "header", Description: "Resume after this event", Schema: &openapi.Schema{Type: []string{"integer"}}},
+		},
+		Responses: map[int]any{
+			http.StatusOK:                 openapi.Content{MediaType: "text/event-stream", Body: events.Event{}},
+			http.StatusSwitchingProtocols: nil,
+		},
+		Problems: []int{http.StatusForbidden, http.StatusNotFound},
+	},
+
+	// Audit log.
+	"GetAuditLog":    {Tag: "audit", Summary: "Page through the audit log of the owner's shops, newest first", Query: dto.AuditQuery{}, Responses: ok([]dto.AuditEntryResponse{})},
+	"VerifyAuditLog": {Tag: "audit", Summary: "Check the hash chain of the audit log", Responses: ok(dto.AuditVerificationResponse{})},
+
+	// Background jobs.
+	"GetJobs":     {Tag: "jobs", Summary: "Page through the jobs, newest first", Query: dto.JobQuery{}, Responses: ok([]dto.JobResponse{})},
+	"GetJobStats": {Tag: "jobs", Summary: "Summarize the queue, the schedules and the scheduler leader", Responses: ok(dto.JobStatsResponse{})},
+	"GetJob":      {Tag: "jobs", Summary: "Get a job", Responses: ok(dto.JobResponse{})},
+	"RetryJob":    {Tag: "jobs", Summary: "Queue a dead or cancelled job again", Responses: ok(dto.JobResponse{}), Problems: []int{http.StatusConflict}},
+	"CancelJob":   {Tag: "jobs", Summary: "Cancel a queued job", Responses: ok(dto.JobResponse{}), Problems: []int{http.StatusConflict}},
+
+	// API usage and quotas.
+	"GetShopUsage": {Tag: "quotas", Summary: "Get the API usage of a shop this month against its quota", Responses: ok(dto.ShopUsageResponse{})},
+	"SetShopQuota": {Tag: "quotas", Summary: "Set the monthly API quota of a shop, null for the default", Body: dto.ShopQuotaRequest{}, Responses: ok(dto.ShopUsageResponse{})},
+
+	// API versions.
+	"GetVersionUsage": {Tag: "versions", Summary: "List the API versions with their deprecation and usage", Responses: ok([]dto.APIVersionResponse{})},
+
+	// Logging.
+	"GetLogLevel": {Tag: "logging", Summary: "Get the minimum level of the logs of the replica", Responses: ok(dto.LogLevelResponse{})},
+	"SetLogLevel": {Tag: "logging", Summary: "Change the minimum level of the logs of the replica until it restarts", Body: dto.LogLevelRequest{}, Responses: ok(dto.LogLevelResponse{})},
+
+	// Inventories.
+	"CreateInventory":  {Tag: "inventories", Summary: "Create an inventory", Body: dto.CreateInventoryRequest{}, Responses: created(dto.InventoryResponse{})},
+	"GetInventories":   {Tag: "inventories", Summary: "List the inventories", Params: slices.Concat(deletedParams, conditionalParams), Responses: versioned([]dto.InventoryResponse{})},
+	"GetInventory":     {Tag: "inventories", Summary: "Get an inventory with its items", Params: conditionalParams, Responses: versioned(dto.InventoryResponse{})},
+	"UpdateInventory":  {Tag: "inventories", Summary: "Update an inventory", Body: dto.UpdateInventoryRequest{}, Responses: ok(dto.InventoryResponse{})},
+	"DeleteInventory":  {Tag: "inventories", Summary: "Delete an inventory", Params: deleteParams, Responses: ok(dto.MessageResponse{})},
+	"RestoreInventory": {Tag: "inventories", Summary: "Restore a deleted inventory", Responses: ok(dto.InventoryResponse{}), Problems: []int{http.StatusConflict}},
+
+	// Items.
+	"CreateItem":  {Tag: "items", Summary: "Create an item", Body: dto.CreateItemRequest{}, Responses: created(dto.ItemResponse{})},
+	"GetItems":    {Tag: "items", Summary: "List the items", Params: slices.Concat(deletedParams, conditionalParams), Responses: versioned([]dto.ItemResponse{})},
+	"GetItem":     {Tag: "items", Summary: "Get an item", Params: conditionalParams, Responses: versioned(dto.ItemResponse{})},
+	"UpdateItem":  {Tag: "items", Summary: "Update an item", Body: dto.UpdateItemRequest{}, Responses: ok(dto.ItemResponse{})},
+	"DeleteItem":  {Tag: "items", Summary: "Delete an item", Params: deleteParams, Responses: ok(dto.MessageResponse{})},
+	"RestoreItem": {Tag: "items", Summary: "Restore a deleted item", Responses: ok(dto.ItemResponse{}), Problems: []int{http.StatusConflict}},
+
+	// Shop employees.
+	"CreateEmployee":  {Tag: "employees", Summary: "Create a shop employee", Body: dto.CreateEmployeeRequest{}, Responses: created(dto.EmployeeResponse{}), Problems: []int{http.StatusConflict}},
+	"GetEmployees":    {Tag: "employees", Summary: "List the shop employees", Params: deletedParams, Responses: ok([]dto.EmployeeResponse{})},
+	"GetEmployee":     {Tag: "employees", Summary: "Get a shop employee", Responses: ok(dto.EmployeeResponse{})},
+	"UpdateEmployee":  {Tag: "employees", Summary: "Update a shop employee", Body: dto.UpdateEmployeeRequest{}, Responses: ok(dto.EmployeeResponse{}), Problems: []int{http.StatusConflict}},
+	"DeleteEmployee":  {Tag: "employees", Summary: "Delete a shop employee", Params: deleteParams, Responses: ok(dto.MessageResponse{})},
+	"RestoreEmployee": {Tag: "employees", Summary: "Restore a deleted shop employee", Responses: ok(dto.EmployeeResponse{}), Problems: []int{http.StatusConflict}},
+}
+
+func ok(body any) map[int]any      { return map[int]any{http.StatusOK: body} }
+func created(body any) map[int]any { return map[int]any{http.StatusCreated: body} }
+
+// versioned documents the responses of the reads answering 304 Not Modified
+// to the clients whose copy is current.
+func versioned(body any) map[int]any {
+	return map[int]any{http.StatusOK: body, http.StatusNotModified: nil}
+}
+
+// OpenAPI builds the OpenAPI document of version of the API from the routes
+// registered on app: the unversioned ones and those under /api/<version>.
+// Undocumented routes are logged and listed without their payloads.
+func OpenAPI(app *fiber.App, version middlewares.APIVersion) *openapi.Document {
+	description := "Shops, their owners, employees, inventories and items. Errors are RFC 7807 problem details."
+	if !version.Deprecated.IsZero() {
+		description += " This version is deprecated since " + version.Deprecated.Format(time.DateOnly) + "."
+	}
+	if !version.Sunset.IsZero() {
+		description += " It will stop being served on " + version.Sunset.Format(time.DateOnly) + "."
+	}
+	doc := openapi.New(openapi.Info{
+		Title:       "golang-api",
+		Version:     version.Name,
+		Description: description,
+	}, utils.APIError{})
+	doc.AddSecurityScheme(bearerAuth, openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Token returned by the login endpoints"})
+	doc.AddSecurityScheme(adminToken, openapi.SecurityScheme{Type: "apiKey", In: "header", Name: middlewares.HeaderAdminToken, Description: "Operator token set in ADMIN_TOKEN"})
+
+	// The custom validation rules of the DTOs.
+	doc.Rule("notblank", func(s *openapi.Schema, _ string) { s.Pattern = `\S` })
+	doc.Rule("password", func(s *openapi.Schema, _ string) {
+		length := dto.MinPasswordLength
+		s.MinLength = &length
+	})
+	doc.Rule("eventtype", func(s *openapi.Schema, _ string) {
+		s.Enum = append(s.Enum, models.WebhookAllEvents)
+		for _, eventType := range events.Types {
+			s.Enum = append(s.Enum, eventType)
+		}
+	})
+
+	routes := app.GetRoutes(true)
+	var groups []fiber.Route
+	for _, route := range app.GetRoutes() {
+		if !slices.ContainsFunc(routes, func(r fiber.Route) bool { return sameRoute(r, route) }) {
+			groups = append(groups, route)
+		}
+	}
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead || len(route.Handlers) == 0 {
+			continue // Fiber answers HEAD with the GET handlers
+		}
+		if routePrefix("/api", route.Path) && !routePrefix("/api/"+version.Name, route.Path) {
+			continue // Another version
+		}
+		name := handlerName(route.Handlers[len(route.Handlers)-1])
+		endpoint, ok := endpoints[name]
+		if !ok {
+			slog.Warn("OpenAPI: route not documented", "method", route.Method, "path", route.Path, "handler", name)
+			endpoint.Summary = "Undocumented"
+		}
+		if endpoint.OperationID == "" {
+			first, size := utf8.DecodeRuneInString(name)
+			endpoint.OperationID = string(unicode.ToLower(first)) + name[size:]
+		}
+		security, problems := securityOf(route, groups)
+		endpoint.Security = security
+		endpoint.Problems = slices.Concat(endpoint.Problems, problems)
+		endpoint.Deprecated = !version.Deprecated.IsZero() && routePrefix("/api", route.Path)
+		doc.Add(route.Method, openapi.PathTemplate(route.Path), endpoint)
+	}
+	return doc
+}
+
+// closureSuffix matches what the runtime appends to the names of method
+// values and closures.
+var closureSuffix = regexp.MustCompile(`(-fm|\.func\d+(\.\d+)*)$`)
+
+// handlerName returns the name of the function or method behind a handler,
+// the function returning it for closures.
+func handlerName(handler fiber.Handler) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	name = closureSuffix.ReplaceAllString(name, "")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// sameRoute reports whether a and b are copies of the same route, Fiber
+// reporting the middlewares of Use with the methods they apply to.
+func sameRoute(a, b fiber.Route) bool {
+	return reflect.ValueOf(a.Handlers).Pointer() == reflect.ValueOf(b.Handlers).Pointer()
+}
+
+// handlerID identifies the code of a handler, shared by the closures of the
+// same function.
+func handlerID(handler fiber.Handler) uintptr {
+	return reflect.ValueOf(handler).Pointer()
+}
+
+// securityOf returns the security schemes of a route and the errors its
+// access checks answer, from its middlewares and those of its groups.
+func securityOf(route fiber.Route, groups []fiber.Route) ([]string, []int) {
+	handlers := slices.Clone(route.Handlers)
+	for _, group := range groups {
+		if routePrefix(group.Path, route.Path) {
+			handlers = append(handlers, group.Handlers...)
+		}
+	}
+
+	var security []string
+	var problems []int
+	for _, handler := range handlers {
+		switch handlerID(handler) {
+		case handlerID(middlewares.RequireAuth):
+			security = append(security, bearerAuth)
+		case handlerID(middlewares.RequireRole()):
+			problems = append(problems, http.StatusForbidden)
+		case handlerID(middlewares.RequireAuthWhen(nil)):
+			// Authenticated on some requests only, such as ?purge=true
+			problems = append(problems, http.StatusUnauthorized, http.StatusForbidden)
+		case handlerID(middlewares.RequireAdmin):
+			security = append(security, adminToken)
+			problems = append(problems, http.StatusNotFound) // Without ADMIN_TOKEN
+		case handlerID(middlewares.RequireCSRF):
+			if route.Method != fiber.MethodGet && route.Method != fiber.MethodHead {
+				problems = append(problems, http.StatusForbidden) // With the auth cookie
+			}
+		case handlerID(middlewares.RateLimit(nil, services.RatePolicy{}, nil)),
+			handlerID(middlewares.RateLimitByMethod(nil, services.RatePolicy{}, services.RatePolicy{}, nil)),
+			handlerID(middlewares.ShopQuota(nil)):
+			problems = append(problems, http.StatusTooManyRequests)
+		}
+	}
+	slices.Sort(security)
+	return slices.Compact(security), problems
+}
+
+// routePrefix reports whether the group path prefix covers the route path,
+// segment by segment.
+func routePrefix(prefix, path string) bool {
+	prefixSegments := strings.Split(strings.Trim(prefix, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if prefixSegments[0] == "" {
+		return true
+	}
+	if len(prefixSegments) > len(pathSegments) {
+		return false
+	}
+	for i, segment := range prefixSegments {
+		if segment != pathSegments[i] && !strings.HasPrefix(segment, ":") {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenAPIDocuments returns the OpenAPI documents of the versions of the API
+// by version name, the latest for "" and nil for an unknown version. Each is
+// built on its first use, once every route is registered.
+func OpenAPIDocuments(app *fiber.App, versions []middlewares.APIVersion) func(version string) *openapi.Document {
+	docs := map[string]func() *openapi.Document{}
+	for _, version := range versions {
+		docs[version.Name] = sync.OnceValue(func() *openapi.Document { return OpenAPI(app, version) })
+	}
+	docs[""] = docs[versions[len(versions)-1].Name]
+	return func(version string) *openapi.Document {
+		doc, ok := docs[version]
+		if !ok {
+			return nil
+		}
+		return doc()
+	}
+}
+
+// ServeOpenAPI serves the OpenAPI document of the API version of the version
+// query parameter, the latest by default.
+func ServeOpenAPI(app *fiber.App, versions []middlewares.APIVersion) fiber.Handler {
+	docs := OpenAPIDocuments(app, versions)
+	return func(c *fiber.Ctx) error {
+		doc := docs(c.Query("version"))
+		if doc == nil {
+			return utils.ErrBadRequest("Unknown API version " + strconv.Quote(c.Query("version")))
+		}
+		return c.Status(fiber.StatusOK).JSON(doc)
+	}
+}
+
+// swaggerInitializer configures the Swagger UI to read the documents of the
+// given URLs, a JSON array of their URLs and names.
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    urls: %s,
+    "urls.primaryName": %q,
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};`
+
+// docsPolicy is the content security policy of the documentation, whose
+// Swagger UI loads its scripts, styles and images from the API and inlines
+// styles.
+const docsPolicy = "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'"
+
+// DocsUI serves the interactive documentation, an embedded Swagger UI reading
+// the OpenAPI documents of the versions at specURL, the latest one first. It
+// is mounted with Use.
+func DocsUI(specURL string, versions []middlewares.APIVersion) fiber.Handler {
+	files := filesystem.New(filesystem.Config{Root: http.FS(swaggerfiles.FS)})
+	type swaggerURL struct {
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	}
+	var urls []swaggerURL
+	for _, version := range slices.Backward(versions) {
+		urls = append(urls, swaggerURL{URL: specURL + "?version=" + version.Name, Name: version.Name})
+	}
+	urlsJSON, _ := json.Marshal(urls)
+	initializer := fmt.Sprintf(swaggerInitializer, urlsJSON, urls[0].Name)
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, docsPolicy)
+		switch strings.TrimPrefix(c.Path(), c.Route().Path) {
+		case "":
+			// The page loads its assets relative to the directory.
+			return c.Redirect(c.Path()+"/", fiber.StatusMovedPermanently)
+		case "/swagger-initializer.js":
+			c.Type("js")
+			return c.SendString(initializer)
+		}
+		return files(c)
+	}
+}
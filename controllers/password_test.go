@@ -0,0 +1,68 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestNoRouteLeaksPasswords calls every route of the API as the shop owner
+// and the operator, the reads first, then the mutations and the restores of
+// what they deleted, and fails when a response shows a password hash or a
+// password field.
+func TestNoRouteLeaksPasswords(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		api.observe = func(method, path string, resp testResponse) {
+			t.Helper()
+			if bytes.Contains(resp.Body, []byte("$2a$")) || bytes.Contains(resp.Body, []byte("$2b$")) {
+				t.Errorf("%s %s answered a password hash: %s", method, path, resp.Body)
+			}
+			// The OpenAPI document describes the password fields of the requests.
+			var body any
+			if path != "/openapi.json" && json.Unmarshal(resp.Body, &body) == nil && hasPasswordKey(body) {
+				t.Errorf("%s %s answered a password field: %s", method, path, resp.Body)
+			}
+		}
+
+		if api.scanRoutes(nil) == 0 {
+			t.Fatal("no route called")
+		}
+	})
+}
+
+// hasPasswordKey reports whether the decoded JSON value holds an object with a
+// password field, at any depth. The changes of the audit log may only tell
+// that a password changed: their old and new values are redacted.
+func hasPasswordKey(value any) bool {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if strings.EqualFold(key, "password") && !redactedChange(field) || hasPasswordKey(field) {
+				return true
+			}
+		}
+	case []any:
+		for _, element := range value {
+			if hasPasswordKey(element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactedChange reports whether value is the change of a secret field in the
+// audit log, its old and new values redacted or empty.
+func redactedChange(value any) bool {
+	change, ok := value.(map[string]any)
+	if !ok || len(change) != 2 {
+		return false
+	}
+	for _, key := range []string{"old", "new"} {
+		if field, ok := change[key]; !ok || field != nil && field != "[REDACTED]" {
+			return false
+		}
+	}
+	return true
+}
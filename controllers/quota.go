@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// QuotaController serves the monthly API usage and quotas of the shops.
+type QuotaController struct {
+	quotas *services.QuotaService
+}
+
+// NewQuotaController creates a QuotaController.
+func NewQuotaController(quotas *services.QuotaService) *QuotaController {
+	return &QuotaController{quotas: quotas}
+}
+
+// GetShopUsage returns the API usage of a shop of the authenticated owner
+// this month.
+func (h *QuotaController) GetShopUsage(c *fiber.Ctx) error {
+	shopID, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	usage, err := h.quotas.Usage(c.UserContext(), principal.UserID, shopID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(newShopUsageResponse(usage))
+}
+
+// SetShopQuota sets the monthly API quota of a shop, for operators.
+func (h *QuotaController) SetShopQuota(c *fiber.Ctx) error {
+	shopID, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req dto.ShopQuotaRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
+	}
+
+	usage, err := h.quotas.SetQuota(c.UserContext(), shopID, req.MonthlyAPIQuota)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(newShopUsageResponse(usage))
+}
+
+// newShopUsageResponse maps a shop's usage onto its response.
+func newShopUsageResponse(usage services.Usage) dto.ShopUsageResponse {
+	response := dto.ShopUsageResponse{
+		ShopID:   usage.ShopID,
+		Period:   usage.Period,
+		Requests: usage.Requests,
+		ResetsAt: usage.Resets,
+	}
+	if usage.Quota > 0 {
+		remaining := usage.Remaining()
+		response.Quota, response.Remaining = &usage.Quota, &remaining
+	}
+	return response
+}
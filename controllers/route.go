@@ -2,77 +2,196 @@ package controllers
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/telemetry"
+	"github.com/mohamedhabas11/golang-api/utils"
 )
 
 // DefaultRoute handles the root endpoint.
 func DefaultRoute(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Welcome to the Go API!",
+	return c.Status(fiber.StatusOK).JSON(dto.MessageResponse{
+		Message: "Welcome to the Go API!",
 	})
 }
 
+// HealthRoute answers as long as the server is up; /livez and /readyz are
+// meant for the probes.
+func HealthRoute(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// metricsHandler serves the metrics of the telemetry package.
+var metricsHandler = adaptor.HTTPHandler(telemetry.Handler())
+
+// MetricsRoute exposes the metrics to Prometheus.
+func MetricsRoute(c *fiber.Ctx) error {
+	return metricsHandler(c)
+}
+
 // NotFoundRoute handles undefined endpoints.
 func NotFoundRoute(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-		"error":   "Route not found",
-		"message": "The requested resource does not exist.",
-	})
+	return utils.ErrNotFound("The requested resource does not exist.")
 }
 
-// SetupRoutes defines all the API routes.
-func SetupRoutes(app *fiber.App) {
+// SetupRoutes defines all the API routes on top of the given services, for
+// each of the API versions, oldest first.
+func SetupRoutes(app *fiber.App, svc *services.Services, versions []middlewares.APIVersion) {
+	customers := NewCustomerController(svc.Customers)
+	shops := NewShopController(svc.Shops)
+	transfers := NewTransferController(svc.Transfers)
+	inventories := NewInventoryController(svc.Inventories)
+	items := NewItemController(svc.Items)
+	employees := NewEmployeeController(svc.Employees)
+	health := NewHealthController(svc.Health)
+	audit := NewAuditController(svc.Audit)
+	webhooks := NewWebhookController(svc.Webhooks)
+	jobs := NewJobController(svc.Jobs)
+	streams := NewStreamController(svc.Streams)
+	quotas := NewQuotaController(svc.Quotas)
+	usage := middlewares.NewVersionUsage()
+	apiVersions := NewVersionController(versions, usage)
+
 	// Default routes.
 	app.Get("/", DefaultRoute)
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.SendStatus(fiber.StatusOK)
-	})
+	app.Get("/health", HealthRoute)
+	app.Get("/health/db", health.Database) // Database reachability and pool statistics
+	app.Get("/livez", health.Live)         // Liveness probe
+	app.Get("/readyz", health.Ready)       // Readiness probe: dependencies, draining
+	app.Get("/startupz", health.Started)   // Startup probe: migrations and seed done
+	app.Get("/metrics", MetricsRoute)      // Prometheus metrics
+
+	// OpenAPI documents of the API versions and their interactive documentation.
+	app.Get("/openapi.json", ServeOpenAPI(app, versions))
+	app.Use("/docs", DocsUI("/openapi.json", versions))
+
+	// API groups, one per version, sharing the handlers. Requests to the
+	// unversioned paths are routed to the version of their API-Version header,
+	// or to the oldest one.
+	app.Use("/api", middlewares.NegotiateVersion("/api", versions))
+	successor := "/api/" + versions[len(versions)-1].Name
+
+	// Rate limits of the clients, looser on reads, strict on the login and
+	// signup endpoints guessing passwords or creating accounts; the monthly
+	// quotas count the requests managing a shop.
+	rateLimit := middlewares.RateLimitByMethod(svc.RateLimits, svc.RateLimits.Read, svc.RateLimits.Write, middlewares.KeyByClient)
+	authLimit := middlewares.RateLimit(svc.RateLimits, svc.RateLimits.Auth, middlewares.KeyByIP)
+	quota := middlewares.ShopQuota(svc.Quotas)
+
+	// The clients and proxies don't store the responses of the API, except
+	// the reads of the shops, inventories and items, revalidated with their
+	// ETag; the server caches those too with a CACHE_BACKEND.
+	noStore := middlewares.CacheControl("no-store")
+	revalidate := middlewares.CacheControl("private, no-cache")
+	cached := middlewares.CacheResponses
+
+	for _, version := range versions {
+		api := app.Group("/api/"+version.Name, middlewares.Versioned(version, successor, usage), rateLimit, noStore)
+
+		// Public routes.
+		// Customer registration and login.
+		api.Post("/customer/signup", authLimit, customers.CreateCustomer) // Create a Customer
+		api.Post("/customer/login", authLimit, customers.LoginCustomer)   // Customer login
+
+		// Shop registration and ShopOwner login.
+		api.Post("/shop/signup", authLimit, shops.CreateShop)    // Create a Shop with its ShopOwner
+		api.Post("/shop/login", authLimit, shops.LoginShopOwner) // ShopOwner login
+
+		// (Optional) ShopEmployee signup can be added similarly.
+		// api.Post("/employee/signup", employees.CreateEmployee)
+
+		// Protected routes (authentication required).
+		protected := api.Group("/")
+		// protected.Use(middlewares.RequireAuth) // Uncomment when auth middleware is configured.
+
+		// Customer endpoints.
+		protected.Get("/customers", customers.GetCustomers)
+
+		// Shop endpoints. Mutations are restricted to the authenticated shop owner.
+		requireOwner := middlewares.RequireRole(middlewares.RoleShopOwner)
+		protected.Get("/shops", revalidate, cached, shops.GetShops)
+		protected.Get("/shops/:id", revalidate, cached, shops.GetShop)
+		protected.Put("/shops/:id", middlewares.RequireAuth, requireOwner, quota, shops.UpdateShop)
+		protected.Delete("/shops/:id", middlewares.RequireAuth, requireOwner, quota, shops.DeleteShop)
+		protected.Post("/shops/:id/restore", middlewares.RequireAuth, requireOwner, quota, shops.RestoreShop)
+		protected.Get("/shops/:id/usage", middlewares.RequireAuth, requireOwner, quotas.GetShopUsage) // API usage this month
+
+		// Shop ownership transfers: started by the current owner, accepted or
+		// declined by the receiving owner.
+		protected.Post("/shops/:id/transfers", middlewares.RequireAuth, requireOwner, quota, transfers.CreateShopTransfer)
+		protected.Post("/shops/:id/transfers/:transferId/accept", middlewares.RequireAuth, requireOwner, transfers.AcceptShopTransfer)
+		protected.Post("/shops/:id/transfers/:transferId/decline", middlewares.RequireAuth, requireOwner, transfers.DeclineShopTransfer)
+		protected.Post("/shops/:id/transfers/:transferId/cancel", middlewares.RequireAuth, requireOwner, quota, transfers.CancelShopTransfer)
+
+		// Webhooks notified of the shop's events, with their delivery log.
+		shopWebhooks := protected.Group("/shops/:id/webhooks", middlewares.RequireAuth, requireOwner, quota)
+		shopWebhooks.Get("/", webhooks.GetWebhooks)
+		shopWebhooks.Post("/", webhooks.CreateWebhook)
+		shopWebhooks.Get("/:webhookId", webhooks.GetWebhook)
+		shopWebhooks.Put("/:webhookId", webhooks.UpdateWebhook)
+		shopWebhooks.Delete("/:webhookId", webhooks.DeleteWebhook)
+		shopWebhooks.Post("/:webhookId/ping", webhooks.PingWebhook)
+		shopWebhooks.Get("/:webhookId/deliveries", webhooks.GetWebhookDeliveries)
+		shopWebhooks.Post("/:webhookId/deliveries/:deliveryId/redeliver", webhooks.RedeliverWebhookDelivery)
+
+		// Endpoints of the authenticated shop owner.
+		owners := api.Group("/owners/me", middlewares.RequireAuth, requireOwner)
+		owners.Get("/shops", shops.GetMyShops)
+		owners.Post("/shops", shops.CreateMyShop)
+		owners.Get("/transfers", transfers.GetMyTransfers)
+
+		// Live changes of the inventories and items of a shop, or of one inventory,
+		// over Server-Sent Events or WebSocket.
+		api.Get("/stream", middlewares.RequireAuth, requireOwner, streams.Stream)
+
+		// Audit log of the authenticated owner's shops and its tamper check.
+		api.Get("/audit", middlewares.RequireAuth, requireOwner, audit.GetAuditLog)
+		api.Get("/audit/verify", middlewares.RequireAuth, requireOwner, audit.VerifyAuditLog)
+
+		// Operator endpoints of the background job queue, behind the ADMIN_TOKEN.
+		admin := api.Group("/admin", middlewares.RequireAdmin)
+		admin.Get("/jobs", jobs.GetJobs)
+		admin.Get("/jobs/stats", jobs.GetJobStats)
+		admin.Get("/jobs/:id", jobs.GetJob)
+		admin.Post("/jobs/:id/retry", jobs.RetryJob)
+		admin.Post("/jobs/:id/cancel", jobs.CancelJob)
+		admin.Get("/versions", apiVersions.GetVersionUsage) // Requests per API version, before retiring one
+		admin.Get("/log-level", GetLogLevel)
+		admin.Put("/log-level", SetLogLevel)               // Until the replica restarts
+		admin.Put("/shops/:id/quota", quotas.SetShopQuota) // Monthly API quota of a shop
+
+		// List endpoints show the trash with ?deleted=true, DELETE accepts ?purge=true
+		// for permanent removal and POST .../restore undoes a soft delete; both
+		// are restricted to the authenticated shop owner.
+		ownerToPurge := middlewares.RequireAuthWhen(purging, middlewares.RoleShopOwner)
+
+		// Inventory endpoints.
+		protected.Post("/inventories", inventories.CreateInventory)
+		protected.Get("/inventories", revalidate, cached, inventories.GetInventories)
+		protected.Get("/inventories/:id", revalidate, cached, inventories.GetInventory)
+		protected.Put("/inventories/:id", inventories.UpdateInventory)
+		protected.Delete("/inventories/:id", ownerToPurge, inventories.DeleteInventory)
+		protected.Post("/inventories/:id/restore", middlewares.RequireAuth, requireOwner, inventories.RestoreInventory)
+
+		// Item endpoints.
+		protected.Post("/items", items.CreateItem)
+		protected.Get("/items", revalidate, cached, items.GetItems)
+		protected.Get("/items/:id", revalidate, cached, items.GetItem)
+		protected.Put("/items/:id", items.UpdateItem)
+		protected.Delete("/items/:id", ownerToPurge, items.DeleteItem)
+		protected.Post("/items/:id/restore", middlewares.RequireAuth, requireOwner, items.RestoreItem)
 
-	// API group.
-	api := app.Group("/api")
-
-	// Public routes.
-	// Customer registration and login.
-	api.Post("/customer/signup", CreateCustomer) // Create a Customer
-	api.Post("/customer/login", LoginCustomer)   // Customer login
-
-	// Shop registration and ShopOwner login.
-	api.Post("/shop/signup", CreateShop)    // Create a Shop with its ShopOwner
-	api.Post("/shop/login", LoginShopOwner) // ShopOwner login
-
-	// (Optional) ShopEmployee signup can be added similarly.
-	// api.Post("/employee/signup", CreateEmployee)
-
-	// Protected routes (authentication required).
-	protected := api.Group("/")
-	// protected.Use(middlewares.RequireAuth) // Uncomment when auth middleware is configured.
-
-	// Customer endpoints.
-	protected.Get("/customers", GetCustomers)
-
-	// Shop endpoints.
-	protected.Get("/shops", GetShops)
-	// (Additional shop update/delete endpoints can be added here)
-
-	// Inventory endpoints.
-	protected.Post("/inventories", CreateInventory)
-	protected.Get("/inventories", GetInventories)
-	protected.Get("/inventories/:id", GetInventory)
-	protected.Put("/inventories/:id", UpdateInventory)
-	protected.Delete("/inventories/:id", DeleteInventory)
-
-	// Item endpoints.
-	protected.Post("/items", CreateItem)
-	protected.Get("/items", GetItems)
-	protected.Get("/items/:id", GetItem)
-	protected.Put("/items/:id", UpdateItem)
-	protected.Delete("/items/:id", DeleteItem)
-
-	// ShopEmployee endpoints.
-	protected.Post("/employees", CreateEmployee)
-	protected.Get("/employees", GetEmployees)
-	protected.Get("/employees/:id", GetEmployee)
-	protected.Put("/employees/:id", UpdateEmployee)
-	protected.Delete("/employees/:id", DeleteEmployee)
+		// ShopEmployee endpoints.
+		protected.Post("/employees", employees.CreateEmployee)
+		protected.Get("/employees", employees.GetEmployees)
+		protected.Get("/employees/:id", employees.GetEmployee)
+		protected.Put("/employees/:id", employees.UpdateEmployee)
+		protected.Delete("/employees/:id", ownerToPurge, employees.DeleteEmployee)
+		protected.Post("/employees/:id/restore", middlewares.RequireAuth, requireOwner, employees.RestoreEmployee)
+	}
 
 	// Catch-all route.
 	app.Use(NotFoundRoute)
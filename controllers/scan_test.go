@@ -0,0 +1,117 @@
+package controllers_test
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// scanBodies are valid bodies of the routes taking one, by method and
+// path under the API version, so that they answer the resource rather than a
+// validation problem. The other routes get an empty object.
+var scanBodies = map[string]any{
+	"POST /customer/signup": map[string]any{"name": "Customer", "email": "customer@customers.test", "password": testPassword},
+	"POST /customer/login":  map[string]any{"email": "customer@customers.test", "password": testPassword},
+	"POST /shop/signup": map[string]any{"name": "Signup", "email": "signup@shops.test",
+		"owner": map[string]any{"name": "Signup owner", "email": "signup@owners.test", "password": testPassword}},
+	"POST /shop/login":                   map[string]any{"email": "scan@owners.test", "password": testPassword},
+	"PUT /shops/:id":                     map[string]any{"name": "Scanned"},
+	"POST /owners/me/shops":              map[string]any{"name": "Second", "email": "second@shops.test"},
+	"POST /shops/:id/transfers":          map[string]any{"new_owner_email": "receiver@owners.test", "password": testPassword},
+	"POST /shops/:id/webhooks/":          map[string]any{"url": "https://hooks.example.com/scan", "event_types": []string{"*"}},
+	"PUT /shops/:id/webhooks/:webhookId": map[string]any{"active": false},
+	"POST /inventories":                  map[string]any{"shop_id": 1, "inventory_name": "Second stock"},
+	"PUT /inventories/:id":               map[string]any{"inventory_name": "Renamed stock"},
+	"POST /items":                        map[string]any{"inventory_id": 1, "name": "Second item", "quantity": 0},
+	"PUT /items/:id":                     map[string]any{"quantity": 7},
+	"POST /employees": map[string]any{"shop_id": 1, "name": "Second employee", "email": "second@employees.test",
+		"password": testPassword},
+	"PUT /employees/:id":         map[string]any{"name": "Renamed", "password": "N3w-Passw0rd!", "password_confirmation": "N3w-Passw0rd!"},
+	"PUT /admin/log-level":       map[string]any{"level": "info"},
+	"PUT /admin/shops/:id/quota": map[string]any{"monthly_api_quota": 1000},
+}
+
+// scanRoutes calls every route of the API as the owner of a new shop and the
+// operator, the reads first, then the mutations and the restores of what
+// they deleted, and returns the number of calls. answered, unless nil, sees
+// the response of every route.
+func (api *testAPI) scanRoutes(answered func(route fiber.Route, resp testResponse)) int {
+	api.t.Helper()
+	shop := api.createShop("scan")
+	api.createShop("receiver")
+	api.expect(fiber.StatusCreated, fiber.MethodPost, "/api/v2/customer/signup", scanBodies["POST /customer/signup"])
+
+	params := map[string]string{
+		"shops/:id":       id(shop.ID),
+		"inventories/:id": id(shop.InventoryID),
+		"items/:id":       id(shop.ItemID),
+		"employees/:id":   id(shop.EmployeeID),
+	}
+	routes := api.app.GetRoutes(true)
+	slices.SortStableFunc(routes, func(a, b fiber.Route) int {
+		return scanStage(a) - scanStage(b)
+	})
+
+	called := 0
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead || route.Method == fiber.MethodConnect || route.Method == fiber.MethodTrace {
+			continue
+		}
+		path := route.Path
+		var body any
+		if route.Method == fiber.MethodPost || route.Method == fiber.MethodPut {
+			body = map[string]any{}
+			if known, ok := scanBodies[route.Method+" "+versionless(route.Path)]; ok {
+				body = known
+			}
+		}
+
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "1"
+				if value, ok := params[segments[i-1]+"/"+segment]; ok {
+					segments[i] = value
+				}
+			}
+		}
+		path = strings.Join(segments, "/")
+
+		resp := api.do(route.Method, path, body,
+			fiber.HeaderAuthorization, shop.Auth,
+			"X-Admin-Token", testAdminToken)
+		if answered != nil {
+			answered(route, resp)
+		}
+		called++
+	}
+	return called
+}
+
+// scanStage orders the calls of scanRoutes: the reads,
+// the creations and updates, the deletions, the restores, and the deletion of
+// the shop.
+func scanStage(route fiber.Route) int {
+	switch {
+	case route.Method == fiber.MethodGet:
+		return 0
+	case strings.HasSuffix(route.Path, "/restore"):
+		return 3
+	case route.Method == fiber.MethodDelete && strings.HasSuffix(route.Path, "/shops/:id"):
+		return 4
+	case route.Method == fiber.MethodDelete:
+		return 2
+	}
+	return 1
+}
+
+// versionless returns path without its /api/<version> prefix.
+func versionless(path string) string {
+	for _, version := range testVersions {
+		if rest, ok := strings.CutPrefix(path, "/api/"+version.Name); ok {
+			return rest
+		}
+	}
+	return path
+}
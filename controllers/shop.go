@@ -1,211 +1,213 @@
 package controllers
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 
-	"github.com/mohamedhabas11/golang-api/database"
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/dto"
 	"github.com/mohamedhabas11/golang-api/middlewares"
 	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/telemetry"
 	"github.com/mohamedhabas11/golang-api/utils"
 )
 
-// CreateShopRequest represents the JSON payload for creating a shop and its owner.
-type CreateShopRequest struct {
-	Name  string           `json:"name"`
-	Email string           `json:"email"`
-	Owner models.ShopOwner `json:"owner"`
-	// You can extend this struct to accept initial employees or inventories if needed.
+// ShopController serves the shop and shop owner endpoints.
+type ShopController struct {
+	shops *services.ShopService
 }
 
-// CreateShop creates a new shop along with its owner.
-func CreateShop(c *fiber.Ctx) error {
-	var req CreateShopRequest
+// NewShopController creates a ShopController.
+func NewShopController(shops *services.ShopService) *ShopController {
+	return &ShopController{shops: shops}
+}
 
-	// Parse the incoming request body.
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
+// CreateShop creates a new shop along with its owner.
+func (h *ShopController) CreateShop(c *fiber.Ctx) error {
+	// Parse and validate the incoming request body.
+	var req dto.CreateShopRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Validate shop email.
-	if !utils.ValidateEmail(req.Email) {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid shop email format")
+	shop, err := h.shops.Register(c.UserContext(), req)
+	if err != nil {
+		return err
 	}
 
-	// Validate owner email.
-	if !utils.ValidateEmail(req.Owner.Email) {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid owner email format")
-	}
+	return c.Status(fiber.StatusCreated).JSON(dto.NewShopResponse(shop))
+}
 
-	// Validate owner's password.
-	if err := utils.ValidatePassword(req.Owner.Password, utils.NewPasswordValidationConfig(8)); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid owner password: " + err.Error())
+// UpdateShop updates an existing shop managed by the authenticated owner.
+func (h *ShopController) UpdateShop(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Check if a shop with the same email already exists.
-	var existingShop models.Shop
-	if err := database.DB.Where("email = ?", req.Email).First(&existingShop).Error; err == nil {
-		return c.Status(fiber.StatusConflict).SendString("Shop with this email already exists")
+	// Parse and validate the allowed updates.
+	var req dto.UpdateShopRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Create or retrieve the shop owner.
-	var owner models.ShopOwner
-	if err := database.DB.Where("email = ?", req.Owner.Email).First(&owner).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Hash the owner's password.
-			hashedPassword, err := utils.HashPassword(req.Owner.Password)
-			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).SendString("Error hashing owner password")
-			}
-			req.Owner.Password = hashedPassword
-
-			// Create the shop owner.
-			if err := database.DB.Create(&req.Owner).Error; err != nil {
-				return c.Status(fiber.StatusInternalServerError).SendString("Error creating shop owner")
-			}
-			owner = req.Owner
-		} else {
-			return c.Status(fiber.StatusInternalServerError).SendString("Error checking shop owner")
-		}
+	principal, _ := middlewares.CurrentPrincipal(c)
+	shop, err := h.shops.Update(c.UserContext(), principal.UserID, id, req)
+	if err != nil {
+		return err
 	}
 
-	// Create the shop with the owner's ID.
-	shop := models.Shop{
-		Name:    req.Name,
-		Email:   req.Email,
-		OwnerID: owner.ID,
-	}
-	if err := database.DB.Create(&shop).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error creating shop")
-	}
+	return c.Status(fiber.StatusOK).JSON(dto.NewShopResponse(shop))
+}
 
-	// Optionally, load the owner details in the shop response.
-	if err := database.DB.Preload("Owner").First(&shop, shop.ID).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error loading created shop")
+// GetShops retrieves all shops with their owners.
+func (h *ShopController) GetShops(c *fiber.Ctx) error {
+	shops, err := h.shops.List(c.UserContext())
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(shop)
+	v := newVersions(c)
+	for _, shop := range shops {
+		v.shop(shop)
+	}
+	return sendVersioned(c, v, dto.NewShopResponses(shops), cache.ListTag(models.AuditShop))
 }
 
-// UpdateShop updates existing shop
-func UpdateShop(c *fiber.Ctx) error {
-	// Get the shop ID from the URL parameters
-	id := c.Params("id")
-	var shop models.Shop
-
-	// Retrieve the shop record by ID.
-	if err := database.DB.First(&shop, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).SendString("Shop not found")
+// GetShop retrieves a single shop with its owner. Related employees, inventories
+// and inventory items can be included with ?expand=employees,inventories,items.
+func (h *ShopController) GetShop(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	// Collect the requested expansions.
+	var relations repositories.ShopRelations
+	if expand := c.Query("expand"); expand != "" {
+		for _, name := range strings.Split(expand, ",") {
+			switch strings.TrimSpace(name) {
+			case "employees":
+				relations.Employees = true
+			case "inventories":
+				relations.Inventories = true
+			case "items":
+				relations.Items = true
+			default:
+				return utils.ErrValidation([]utils.FieldError{{
+					Field:   "expand",
+					Message: "must be a list of: employees, inventories, items",
+				}})
+			}
 		}
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
-	// Define a payload for allowed updates.
-	var updateData struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
+	shop, err := h.shops.Get(c.UserContext(), id, relations)
+	if err != nil {
+		return err
 	}
 
-	// Parse the request body.
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
-	}
+	v := newVersions(c)
+	v.shop(shop)
+	return sendVersioned(c, v, dto.NewShopResponse(shop), cache.Tag(models.AuditShop, shop.ID))
+}
 
-	// Update Name if provided and different.
-	if updateData.Name != "" && updateData.Name != shop.Name {
-		shop.Name = updateData.Name
+// DeleteShop archives (soft-deletes) a shop together with its inventories, items,
+// employees and pending transfers. Shops whose inventories still hold stock are
+// only deleted with ?force=true; ?purge=true removes everything permanently.
+func (h *ShopController) DeleteShop(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	// Update Email if provided and different.
-	if updateData.Email != "" && updateData.Email != shop.Email {
-		if !utils.ValidateEmail(updateData.Email) {
-			return c.Status(fiber.StatusBadRequest).SendString("Invalid email format")
-		}
-		// Check if the new email is already used by another shop.
-		var existingShop models.Shop
-		if err := database.DB.Where("email = ?", updateData.Email).First(&existingShop).Error; err == nil && existingShop.ID != shop.ID {
-			return c.Status(fiber.StatusConflict).SendString("Shop with this email already exists")
-		}
-
-		shop.Email = updateData.Name
+	principal, _ := middlewares.CurrentPrincipal(c)
+	opts := deleteOptions(c)
+	if err := h.shops.Delete(c.UserContext(), principal.UserID, id, opts); err != nil {
+		return err
 	}
 
-	// save update to the database.
-	if err := database.DB.Save(&shop).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-	}
+	return c.Status(fiber.StatusOK).JSON(deletedMessage("Shop", opts))
+}
 
-	// reload after update
-	if err := database.DB.Preload("Owner").First(&shop, shop.ID).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error preloading") // TODO: log on disk
+// RestoreShop brings an archived shop back together with the inventories, items
+// and employees that were deleted with it.
+func (h *ShopController) RestoreShop(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(shop)
+	principal, _ := middlewares.CurrentPrincipal(c)
+	shop, err := h.shops.Restore(c.UserContext(), principal.UserID, id)
+	if err != nil {
+		return err
+	}
 
+	return c.Status(fiber.StatusOK).JSON(dto.NewShopResponse(shop))
 }
 
-// GetShops retrieves all shops with their owners.
-func GetShops(c *fiber.Ctx) error {
-	var shops []models.Shop
+// GetMyShops lists every shop managed by the authenticated owner.
+func (h *ShopController) GetMyShops(c *fiber.Ctx) error {
+	principal, _ := middlewares.CurrentPrincipal(c)
 
-	// Preload the Owner relation.
-	if err := database.DB.Preload("Owner").Find(&shops).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	shops, err := h.shops.ListByOwner(c.UserContext(), principal.UserID)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(shops)
+	return c.Status(fiber.StatusOK).JSON(dto.NewShopResponses(shops))
 }
 
-// LoginShopOwner authenticates a shop owner and returns a JWT token.
-func LoginShopOwner(c *fiber.Ctx) error {
-	type LoginRequest struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	var req LoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid request body")
+// CreateMyShop opens an additional shop for the authenticated owner.
+func (h *ShopController) CreateMyShop(c *fiber.Ctx) error {
+	principal, _ := middlewares.CurrentPrincipal(c)
+
+	// Parse and validate the incoming request body.
+	var req dto.CreateOwnedShopRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Validate email format.
-	if !utils.ValidateEmail(req.Email) {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid email format")
+	shop, err := h.shops.CreateForOwner(c.UserContext(), principal.UserID, req)
+	if err != nil {
+		return err
 	}
 
-	// Lookup shop owner in the database.
-	var owner models.ShopOwner
-	if err := database.DB.Where("email = ?", req.Email).First(&owner).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusUnauthorized).SendString("Invalid credentials")
-		}
-		return c.Status(fiber.StatusInternalServerError).SendString("Database error")
+	return c.Status(fiber.StatusCreated).JSON(dto.NewShopResponse(shop))
+}
+
+// LoginShopOwner authenticates a shop owner and returns a JWT token.
+func (h *ShopController) LoginShopOwner(c *fiber.Ctx) error {
+	// Parse and validate the credentials.
+	var req dto.LoginRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
 	}
 
-	// Compare the provided password with the stored hashed password.
-	if !utils.ComparePassword(owner.Password, req.Password) {
-		return c.Status(fiber.StatusUnauthorized).SendString("Invalid credentials")
+	owner, err := h.shops.AuthenticateOwner(c.UserContext(), req.Email, req.Password)
+	telemetry.RecordLogin(middlewares.RoleShopOwner, err)
+	if err != nil {
+		return err
 	}
 
 	// Generate a JWT token (here, valid for 24 hours).
-	token, err := middlewares.GenerateJWT(owner.ID, owner.Email, time.Hour*24)
+	token, err := middlewares.GenerateJWT(owner.ID, owner.Email, middlewares.RoleShopOwner, time.Hour*24)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error generating token")
+		return utils.ErrInternal("Error generating token")
 	}
 
-	// Set the token in an HTTP-only cookie.
-	c.Cookie(&fiber.Cookie{
-		Name:     "jwt_token",
-		Value:    token,
-		Expires:  time.Now().Add(time.Hour * 24),
-		HTTPOnly: true,
-		Secure:   false, // Set to true in production if using HTTPS.
-	})
+	// Set the token in an HTTP-only cookie, with the CSRF token its
+	// mutations must echo.
+	csrfToken := middlewares.SetAuthCookies(c, token, time.Now().Add(time.Hour*24))
 
-	return c.JSON(fiber.Map{
-		"message": "Login successful",
-		"token":   token,
+	return c.JSON(dto.TokenResponse{
+		Message:   "Login successful",
+		Token:     token,
+		CSRFToken: csrfToken,
 	})
 }
@@ -0,0 +1,48 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestShopOwnerManagesOwnShop(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		shop := api.createShop("corner")
+		other := api.createShop("rival")
+		path := "/api/v2/shops/" + id(shop.ID)
+		rename := map[string]any{"name": "Corner shop"}
+
+		api.expect(fiber.StatusUnauthorized, fiber.MethodPut, path, rename)
+		api.expect(fiber.StatusForbidden, fiber.MethodPut, path, rename, fiber.HeaderAuthorization, other.Auth)
+		api.expect(fiber.StatusOK, fiber.MethodPut, path, rename, fiber.HeaderAuthorization, shop.Auth)
+
+		var got struct {
+			Name        string `json:"name"`
+			OwnerID     uint   `json:"owner_id"`
+			Inventories []struct {
+				ID    uint `json:"id"`
+				Items []struct {
+					ID uint `json:"id"`
+				} `json:"items"`
+			} `json:"inventories"`
+		}
+		api.expect(fiber.StatusOK, fiber.MethodGet, path+"?expand=inventories,items", nil).decode(t, &got)
+		if got.Name != "Corner shop" || got.OwnerID != shop.OwnerID {
+			t.Errorf("got shop %+v", got)
+		}
+		if len(got.Inventories) != 1 || got.Inventories[0].ID != shop.InventoryID ||
+			len(got.Inventories[0].Items) != 1 || got.Inventories[0].Items[0].ID != shop.ItemID {
+			t.Errorf("got inventories %+v, want inventory %d with item %d", got.Inventories, shop.InventoryID, shop.ItemID)
+		}
+
+		var mine []struct {
+			ID uint `json:"id"`
+		}
+		api.expect(fiber.StatusOK, fiber.MethodGet, "/api/v2/owners/me/shops", nil, fiber.HeaderAuthorization, other.Auth).decode(t, &mine)
+		if len(mine) != 1 || mine[0].ID != other.ID {
+			t.Errorf("got the shops %+v of the other owner, want only %d", mine, other.ID)
+		}
+		api.expect(fiber.StatusNotFound, fiber.MethodGet, "/api/v2/shops/999", nil)
+	})
+}
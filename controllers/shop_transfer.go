@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// TransferController serves the shop ownership transfer endpoints.
+type TransferController struct {
+	transfers *services.TransferService
+}
+
+// NewTransferController creates a TransferController.
+func NewTransferController(transfers *services.TransferService) *TransferController {
+	return &TransferController{transfers: transfers}
+}
+
+// CreateShopTransfer starts handing a shop over to another ShopOwner. The current
+// owner confirms by re-entering their password; the transfer only takes effect
+// once the receiving owner accepts it.
+func (h *TransferController) CreateShopTransfer(c *fiber.Ctx) error {
+	shopID, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	// Parse and validate the request body.
+	var req dto.TransferShopRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	transfer, err := h.transfers.Create(c.UserContext(), principal.UserID, shopID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.NewTransferResponse(transfer))
+}
+
+// AcceptShopTransfer completes a pending transfer; only the receiving owner may accept it.
+func (h *TransferController) AcceptShopTransfer(c *fiber.Ctx) error {
+	shopID, transferID, err := transferParams(c)
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	shop, err := h.transfers.Accept(c.UserContext(), principal.UserID, shopID, transferID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewShopResponse(shop))
+}
+
+// DeclineShopTransfer rejects a pending transfer; only the receiving owner may decline it.
+func (h *TransferController) DeclineShopTransfer(c *fiber.Ctx) error {
+	return h.respond(c, h.transfers.Decline)
+}
+
+// CancelShopTransfer withdraws a pending transfer; only the initiating owner may cancel it.
+func (h *TransferController) CancelShopTransfer(c *fiber.Ctx) error {
+	return h.respond(c, h.transfers.Cancel)
+}
+
+// GetMyTransfers lists the transfers the authenticated owner sent or received.
+func (h *TransferController) GetMyTransfers(c *fiber.Ctx) error {
+	principal, _ := middlewares.CurrentPrincipal(c)
+
+	transfers, err := h.transfers.ListByOwner(c.UserContext(), principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewTransferResponses(transfers))
+}
+
+// respond runs a decline or cancel action on the transfer identified by the route.
+func (h *TransferController) respond(c *fiber.Ctx, action func(ctx context.Context, ownerID, shopID, transferID uint) (models.ShopTransfer, error)) error {
+	shopID, transferID, err := transferParams(c)
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	transfer, err := action(c.UserContext(), principal.UserID, shopID, transferID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewTransferResponse(transfer))
+}
+
+// transferParams parses the :id and :transferId route parameters.
+func transferParams(c *fiber.Ctx) (shopID, transferID uint, err error) {
+	if shopID, err = paramID(c, "id"); err != nil {
+		return 0, 0, err
+	}
+	transferID, err = paramID(c, "transferId")
+	return shopID, transferID, err
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/events"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// sseRetry tells EventSource clients how long to wait before reconnecting.
+const sseRetry = 3 * time.Second
+
+// StreamController streams the changes of the inventories and items of the
+// authenticated owner's shops, over Server-Sent Events or WebSocket.
+type StreamController struct {
+	streams *services.StreamService
+}
+
+// NewStreamController creates a StreamController.
+func NewStreamController(streams *services.StreamService) *StreamController {
+	return &StreamController{streams: streams}
+}
+
+// Stream subscribes to the events of a shop or of an inventory. WebSocket
+// upgrade requests get one JSON event per message, the others a
+// text/event-stream. Both resume after the last event ID received.
+func (h *StreamController) Stream(c *fiber.Ctx) error {
+	var query dto.StreamQuery
+	if err := dto.BindQuery(c, &query); err != nil {
+		return err
+	}
+	if header := c.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			return utils.ErrBadRequest("Invalid Last-Event-ID header")
+		}
+		query.LastEventID = uint(id)
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	filter, err := h.streams.Authorize(c.UserContext(), principal.UserID, query.ShopID, query.InventoryID)
+	if err != nil {
+		return err
+	}
+
+	if websocket.IsWebSocketUpgrade(c) {
+		return websocket.New(func(conn *websocket.Conn) {
+			h.serveWebSocket(conn, filter, query.LastEventID)
+		})(c)
+	}
+	return h.serveEventStream(c, filter, query.LastEventID)
+}
+
+// serveEventStream streams the events as Server-Sent Events, with a comment
+// line as heartbeat.
+func (h *StreamController) serveEventStream(c *fiber.Ctx, filter services.StreamFilter, lastEventID uint) error {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Keeps nginx from buffering the stream
+
+	// The write timeout of the server bounds the sending of each event rather
+	// than of the whole stream, which stays open
+	conn, writeTimeout := c.Context().Conn(), c.App().Config().WriteTimeout
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		flush := func() error {
+			if writeTimeout > 0 {
+				conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+			return w.Flush()
+		}
+
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+		if flush() != nil {
+			return
+		}
+
+		sub := h.streams.Subscribe(filter, lastEventID)
+		err := sub.Serve(context.Background(), func(event events.Event) error {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			return flush()
+		}, func() error {
+			w.WriteString(": heartbeat\n\n")
+			return flush()
+		})
+		if err != nil {
+			slog.Info("Event stream ended", "shop_id", filter.ShopID, "error", err)
+		}
+	})
+	return nil
+}
+
+// serveWebSocket streams the events as JSON messages, with pings as heartbeat.
+// A client missing two heartbeats is disconnected.
+func (h *StreamController) serveWebSocket(conn *websocket.Conn, filter services.StreamFilter, lastEventID uint) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Clients send nothing but pongs: reading only notices them going away.
+	timeout := 2*h.streams.Heartbeat + 10*time.Second
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	sub := h.streams.Subscribe(filter, lastEventID)
+	err := sub.Serve(ctx, func(event events.Event) error {
+		return conn.WriteJSON(event)
+	}, func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+	})
+	if err != nil {
+		slog.Info("WebSocket stream ended", "shop_id", filter.ShopID, "error", err)
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// listOptions returns the options of a list endpoint: active rows by default,
+// or only soft-deleted rows when the request asks for ?deleted=true.
+func listOptions(c *fiber.Ctx) repositories.ListOptions {
+	return repositories.ListOptions{Deleted: c.QueryBool("deleted")}
+}
+
+// deleteOptions returns the options of a delete endpoint: ?purge=true removes
+// the row permanently, even from the trash, and ?force=true skips safeguards.
+func deleteOptions(c *fiber.Ctx) services.DeleteOptions {
+	return services.DeleteOptions{
+		Purge: purging(c),
+		Force: c.QueryBool("force"),
+	}
+}
+
+// purging reports whether a delete endpoint is asked to remove the row
+// permanently.
+func purging(c *fiber.Ctx) bool {
+	return c.QueryBool("purge")
+}
+
+// deletedMessage returns the success message of a delete endpoint.
+func deletedMessage(resource string, opts services.DeleteOptions) dto.MessageResponse {
+	if opts.Purge {
+		return dto.MessageResponse{Message: resource + " purged successfully"}
+	}
+	return dto.MessageResponse{Message: resource + " deleted successfully"}
+}
+
+// paramID parses a numeric ID route parameter. IDs that can't exist are reported
+// as a missing resource.
+func paramID(c *fiber.Ctx, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Params(name), 10, 0)
+	if err != nil || id == 0 {
+		return 0, utils.ErrNotFound("The requested resource does not exist.")
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,38 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestPurgeAndRestoreRequireShopOwner checks that only the owner of the shop
+// can remove its inventories, items and employees permanently or bring them
+// back from the trash, while soft deletions stay open.
+func TestPurgeAndRestoreRequireShopOwner(t *testing.T) {
+	forEachAPI(t, func(t *testing.T, api *testAPI) {
+		shop := api.createShop("owned")
+		other := api.createShop("other")
+
+		for _, resource := range []struct {
+			path string
+			id   uint
+		}{
+			{"/api/v2/items/", shop.ItemID},
+			{"/api/v2/employees/", shop.EmployeeID},
+			{"/api/v2/inventories/", shop.InventoryID},
+		} {
+			path := resource.path + id(resource.id)
+
+			api.expect(fiber.StatusOK, fiber.MethodDelete, path, nil)
+			api.expect(fiber.StatusUnauthorized, fiber.MethodPost, path+"/restore", nil)
+			api.expect(fiber.StatusForbidden, fiber.MethodPost, path+"/restore", nil, fiber.HeaderAuthorization, other.Auth)
+			api.expect(fiber.StatusOK, fiber.MethodPost, path+"/restore", nil, fiber.HeaderAuthorization, shop.Auth)
+
+			api.expect(fiber.StatusUnauthorized, fiber.MethodDelete, path+"?purge=true", nil)
+			api.expect(fiber.StatusForbidden, fiber.MethodDelete, path+"?purge=true", nil, fiber.HeaderAuthorization, other.Auth)
+			api.expect(fiber.StatusOK, fiber.MethodDelete, path+"?purge=true", nil, fiber.HeaderAuthorization, shop.Auth)
+			api.expect(fiber.StatusNotFound, fiber.MethodPost, path+"/restore", nil, fiber.HeaderAuthorization, shop.Auth)
+		}
+	})
+}
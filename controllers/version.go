@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+)
+
+// VersionController reports the API versions and their usage.
+type VersionController struct {
+	versions []middlewares.APIVersion
+	usage    *middlewares.VersionUsage
+}
+
+// NewVersionController creates a VersionController.
+func NewVersionController(versions []middlewares.APIVersion, usage *middlewares.VersionUsage) *VersionController {
+	return &VersionController{versions: versions, usage: usage}
+}
+
+// GetVersionUsage lists the API versions, oldest first, with their deprecation
+// and sunset dates and the requests they served since the replica started: a
+// retired version no client uses anymore can be removed.
+func (v *VersionController) GetVersionUsage(c *fiber.Ctx) error {
+	response := make([]dto.APIVersionResponse, len(v.versions))
+	for i, version := range v.versions {
+		stats := v.usage.Stats(version.Name)
+		response[i] = dto.APIVersionResponse{
+			Version:       version.Name,
+			DeprecatedAt:  dto.TimeOrNil(version.Deprecated),
+			SunsetAt:      dto.TimeOrNil(version.Sunset),
+			Requests:      stats.Requests,
+			RequestsVia:   stats.RequestsVia,
+			LastRequestAt: dto.TimeOrNil(stats.LastRequestAt),
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
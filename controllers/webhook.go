@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// WebhookController serves the webhooks of the authenticated owner's shops.
+type WebhookController struct {
+	webhooks *services.WebhookService
+}
+
+// NewWebhookController creates a WebhookController.
+func NewWebhookController(webhooks *services.WebhookService) *WebhookController {
+	return &WebhookController{webhooks: webhooks}
+}
+
+// GetWebhooks lists the webhooks of a shop.
+func (h *WebhookController) GetWebhooks(c *fiber.Ctx) error {
+	shopID, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	webhooks, err := h.webhooks.List(c.UserContext(), principal.UserID, shopID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewWebhookResponses(webhooks))
+}
+
+// CreateWebhook registers a webhook for a shop. The response is the only one
+// showing its secret.
+func (h *WebhookController) CreateWebhook(c *fiber.Ctx) error {
+	shopID, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	// Parse and validate the request body.
+	var req dto.CreateWebhookRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	webhook, err := h.webhooks.Create(c.UserContext(), principal.UserID, shopID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.NewCreatedWebhookResponse(webhook))
+}
+
+// GetWebhook returns a webhook of a shop.
+func (h *WebhookController) GetWebhook(c *fiber.Ctx) error {
+	shopID, webhookID, err := webhookParams(c)
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	webhook, err := h.webhooks.Get(c.UserContext(), principal.UserID, shopID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewWebhookResponse(webhook))
+}
+
+// UpdateWebhook changes a webhook; activating it re-enables a webhook disabled
+// after repeated failures.
+func (h *WebhookController) UpdateWebhook(c *fiber.Ctx) error {
+	shopID, webhookID, err := webhookParams(c)
+	if err != nil {
+		return err
+	}
+
+	// Parse and validate the request body.
+	var req dto.UpdateWebhookRequest
+	if err := dto.Bind(c, &req); err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	webhook, err := h.webhooks.Update(c.UserContext(), principal.UserID, shopID, webhookID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewWebhookResponse(webhook))
+}
+
+// DeleteWebhook permanently removes a webhook and its delivery log.
+func (h *WebhookController) DeleteWebhook(c *fiber.Ctx) error {
+	shopID, webhookID, err := webhookParams(c)
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	if err := h.webhooks.Delete(c.UserContext(), principal.UserID, shopID, webhookID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.MessageResponse{Message: "Webhook deleted successfully"})
+}
+
+// PingWebhook sends a test event to a webhook and returns the delivery.
+func (h *WebhookController) PingWebhook(c *fiber.Ctx) error {
+	shopID, webhookID, err := webhookParams(c)
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	delivery, err := h.webhooks.Ping(c.UserContext(), principal.UserID, shopID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewWebhookDeliveryResponse(delivery))
+}
+
+// GetWebhookDeliveries pages through the delivery log of a webhook, newest first.
+func (h *WebhookController) GetWebhookDeliveries(c *fiber.Ctx) error {
+	shopID, webhookID, err := webhookParams(c)
+	if err != nil {
+		return err
+	}
+
+	var query dto.WebhookDeliveryQuery
+	if err := dto.BindQuery(c, &query); err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	deliveries, err := h.webhooks.Deliveries(c.UserContext(), principal.UserID, shopID, webhookID, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NewWebhookDeliveryResponses(deliveries))
+}
+
+// RedeliverWebhookDelivery sends a past delivery again and returns the new one.
+func (h *WebhookController) RedeliverWebhookDelivery(c *fiber.Ctx) error {
+	shopID, webhookID, err := webhookParams(c)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := paramID(c, "deliveryId")
+	if err != nil {
+		return err
+	}
+
+	principal, _ := middlewares.CurrentPrincipal(c)
+	delivery, err := h.webhooks.Redeliver(c.UserContext(), principal.UserID, shopID, webhookID, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.NewWebhookDeliveryResponse(delivery))
+}
+
+// webhookParams parses the :id and :webhookId route parameters.
+func webhookParams(c *fiber.Ctx) (shopID, webhookID uint, err error) {
+	if shopID, err = paramID(c, "id"); err != nil {
+		return 0, 0, err
+	}
+	webhookID, err = paramID(c, "webhookId")
+	return shopID, webhookID, err
+}
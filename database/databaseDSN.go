@@ -1,76 +1,117 @@
 package database
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"os"
+	"net"
+	"net/url"
 
-	"github.com/mohamedhabas11/golang-api/models"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+
+	"github.com/mohamedhabas11/golang-api/config"
 )
 
-// DBinstance represents a database instance with a direct gorm.DB embedding
-type DBinstance struct {
-	*gorm.DB
+// ConnectDB establishes a connection to the configured database and makes sure
+// its schema is up to date.
+func ConnectDB(cfg config.Database) *gorm.DB {
+	db := OpenDB(cfg)
+	EnsureSchema(db, cfg.MigrateOnStart)
+	return db
 }
 
-// Global DB instance
-var DB DBinstance
+// OpenDB connects to the database selected by cfg.URL without touching its
+// schema. Without a URL it connects to the PostgreSQL server described by the
+// other settings. It is used directly by the migrate command.
+func OpenDB(cfg config.Database) *gorm.DB {
+	databaseURL := cfg.URL.Reveal()
+	if databaseURL == "" {
+		databaseURL = postgresURL(cfg)
+	}
+
+	// Keep retrying while the database starts up, and log the statements slower
+	// than the threshold, every one at debug level
+	db, err := connectWithRetry(databaseURL, NewQueryLogger(cfg.SlowQueryThreshold), cfg.ConnectTimeout)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := ConfigurePool(db, cfg.Pool); err != nil {
+		log.Fatalf("Failed to configure the connection pool: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	log.Printf("Connected to %s database (max open connections: %d)", DialectOf(db), sqlDB.Stats().MaxOpenConnections)
 
-// ConnectDB establishes a connection to the PostgreSQL database
-func ConnectDB() {
-	// Get DataBase connection info from environment variables
-	dbHost := os.Getenv("DB_HOST")
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-	dbPort := os.Getenv("DB_PORT")
-	dbSslmode := os.Getenv("DB_SSLMODE")
-	dbTimezone := os.Getenv("DB_TIMEZONE")
+	return db
+}
 
-	// Default values if missing
-	if dbHost == "" {
-		dbHost = "db"
-		log.Println("DB_HOST is not set. Using default value: db")
+// OpenURL connects to the database identified by databaseURL (see Dialector),
+// logging the statements with queryLogger. Tests can use it with
+// "sqlite::memory:" to run against a throwaway database.
+func OpenURL(databaseURL string, queryLogger logger.Interface) (*gorm.DB, error) {
+	dialector, err := Dialector(databaseURL)
+	if err != nil {
+		return nil, err
 	}
-	if dbPort == "" {
-		dbPort = "5432"
-		log.Println("DB_PORT is not set. Using default value: 5432")
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:         queryLogger,
+		TranslateError: true, // Map driver errors (unique/FK violations) to gorm errors
+	})
+	if err != nil {
+		return nil, err
 	}
-	if dbTimezone == "" {
-		dbTimezone = "UTC"
-		log.Println("DB_TIMEZONE is not set. Using default value: UTC")
+
+	// SQLite allows a single writer, and every connection to :memory: opens a
+	// separate empty database, so share one connection.
+	if DialectOf(db) == SQLite {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
 	}
-	if dbSslmode == "" {
-		dbSslmode = "disable"
-		log.Println("DB_SSLMODE is not set. Using default value: disable")
+	return db, nil
+}
+
+// postgresURL builds a postgres:// URL from the DB_* settings.
+func postgresURL(cfg config.Database) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password.Reveal()),
+		Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:     "/" + cfg.Name,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}, "TimeZone": {cfg.TimeZone}}.Encode(),
 	}
+	return u.String()
+}
 
-	// Build the Data Source Name (DSN) for PostgreSQL
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-		dbHost, dbUser, dbPassword, dbName, dbPort, dbSslmode, dbTimezone,
-	)
+// EnsureSchema fails startup when migrations are pending, unless
+// migrateOnStart asks to apply them right away.
+func EnsureSchema(db *gorm.DB, migrateOnStart bool) {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
 
-	// Open a connection to the database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info), // Enable detailed logging of queries
-	})
+	ctx := context.Background()
+	pending, err := migrator.Pending(ctx)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Error checking database schema: %v", err)
+	}
+	if len(pending) == 0 {
+		log.Println("Database schema is up to date.")
+		return
 	}
 
-	// Set the global DB instance
-	DB = DBinstance{DB: db}
+	if !migrateOnStart {
+		log.Fatalf("Database schema is behind by %d migration(s) (next: %d_%s); run the migrate up command or set DB_MIGRATE_ON_START=TRUE",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
 
-	// Run database migrations (for automatic schema generation)
 	log.Println("Running migrations...")
-	if err := db.AutoMigrate(
-		&models.Shop{}, &models.ShopOwner{}, &models.ShopEmployee{}, &models.ShopOwner{},
-		&models.Inventory{}, &models.Item{}, &models.Customer{}); err != nil {
+	applied, err := migrator.Up(ctx)
+	if err != nil {
 		log.Fatalf("Error running migrations: %v", err)
 	}
-	log.Println("Migrations completed.")
+	log.Printf("Migrations completed: %d applied.", applied)
 }
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// QueryLogger logs the SQL statements of GORM through slog: the failing ones
+// as errors, those slower than SlowThreshold as warnings and, at debug level,
+// every other one. Statements are logged with their placeholders, never with
+// the values bound to them, such as emails and password hashes.
+type QueryLogger struct {
+	SlowThreshold time.Duration // Zero logs no statement as slow
+}
+
+// NewQueryLogger creates a QueryLogger warning about the statements slower
+// than slowThreshold.
+func NewQueryLogger(slowThreshold time.Duration) *QueryLogger {
+	return &QueryLogger{SlowThreshold: slowThreshold}
+}
+
+// LogMode implements logger.Interface. The level of slog applies instead.
+func (l *QueryLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+// Info implements logger.Interface.
+func (l *QueryLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+// Warn implements logger.Interface.
+func (l *QueryLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+// Error implements logger.Interface.
+func (l *QueryLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+}
+
+// Trace implements logger.Interface, logging a statement once run.
+func (l *QueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	level, msg := slog.LevelDebug, "SQL statement"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled):
+		level, msg = slog.LevelError, "SQL statement failed"
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		level, msg = slog.LevelWarn, "Slow SQL statement"
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	slog.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter implements gorm.ParamsFilter, leaving the values out of the
+// logged statements.
+func (l *QueryLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,97 @@
+-- Initial schema for MySQL, mirroring the Postgres migration of the same version.
+-- MySQL has no partial indexes, so soft-delete aware email uniqueness uses a
+-- generated column that is NULL for deleted rows; NULLs never collide.
+
+CREATE TABLE IF NOT EXISTS shop_owners (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(6),
+    updated_at   DATETIME(6),
+    deleted_at   DATETIME(6),
+    name         VARCHAR(255),
+    email        VARCHAR(255),
+    password     VARCHAR(255),
+    active_email VARCHAR(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    INDEX idx_shop_owners_deleted_at (deleted_at),
+    UNIQUE INDEX idx_shop_owners_email (active_email)
+);
+
+CREATE TABLE IF NOT EXISTS shops (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(6),
+    updated_at   DATETIME(6),
+    deleted_at   DATETIME(6),
+    name         VARCHAR(255),
+    email        VARCHAR(255),
+    owner_id     BIGINT UNSIGNED,
+    active_email VARCHAR(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    INDEX idx_shops_deleted_at (deleted_at),
+    UNIQUE INDEX idx_shops_email (active_email),
+    CONSTRAINT fk_shops_owner FOREIGN KEY (owner_id) REFERENCES shop_owners (id)
+);
+
+CREATE TABLE IF NOT EXISTS shop_employees (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(6),
+    updated_at   DATETIME(6),
+    deleted_at   DATETIME(6),
+    name         VARCHAR(255),
+    email        VARCHAR(255),
+    password     VARCHAR(255),
+    shop_id      BIGINT UNSIGNED,
+    active_email VARCHAR(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    INDEX idx_shop_employees_deleted_at (deleted_at),
+    UNIQUE INDEX idx_shop_employees_email (active_email),
+    CONSTRAINT fk_shops_employees FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(6),
+    updated_at   DATETIME(6),
+    deleted_at   DATETIME(6),
+    name         VARCHAR(255),
+    email        VARCHAR(255),
+    password     VARCHAR(255),
+    active_email VARCHAR(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    INDEX idx_customers_deleted_at (deleted_at),
+    UNIQUE INDEX idx_customers_email (active_email)
+);
+
+CREATE TABLE IF NOT EXISTS inventories (
+    id             BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at     DATETIME(6),
+    updated_at     DATETIME(6),
+    deleted_at     DATETIME(6),
+    shop_id        BIGINT UNSIGNED,
+    inventory_name VARCHAR(255),
+    INDEX idx_inventories_deleted_at (deleted_at),
+    CONSTRAINT fk_shops_inventories FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(6),
+    updated_at   DATETIME(6),
+    deleted_at   DATETIME(6),
+    inventory_id BIGINT UNSIGNED,
+    name         VARCHAR(255),
+    quantity     BIGINT,
+    INDEX idx_items_deleted_at (deleted_at),
+    CONSTRAINT fk_inventories_items FOREIGN KEY (inventory_id) REFERENCES inventories (id)
+);
+
+CREATE TABLE IF NOT EXISTS shop_transfers (
+    id            BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at    DATETIME(6),
+    updated_at    DATETIME(6),
+    deleted_at    DATETIME(6),
+    shop_id       BIGINT UNSIGNED,
+    from_owner_id BIGINT UNSIGNED,
+    to_owner_id   BIGINT UNSIGNED,
+    status        VARCHAR(32),
+    expires_at    DATETIME(6),
+    responded_at  DATETIME(6),
+    INDEX idx_shop_transfers_deleted_at (deleted_at),
+    INDEX idx_shop_transfers_shop_id (shop_id),
+    INDEX idx_shop_transfers_to_owner_id (to_owner_id)
+);
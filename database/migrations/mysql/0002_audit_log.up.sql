@@ -0,0 +1,35 @@
+-- Append-only audit log, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id          BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    seq         BIGINT UNSIGNED NOT NULL,
+    created_at  DATETIME(6) NOT NULL,
+    actor_type  VARCHAR(32) NOT NULL,
+    actor_id    BIGINT UNSIGNED,
+    shop_id     BIGINT UNSIGNED,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id   BIGINT UNSIGNED NOT NULL,
+    action      VARCHAR(32) NOT NULL,
+    changes     TEXT NOT NULL,
+    request_id  VARCHAR(255) NOT NULL,
+    ip          VARCHAR(64) NOT NULL,
+    prev_hash   CHAR(64) NOT NULL,
+    hash        CHAR(64) NOT NULL,
+    UNIQUE INDEX idx_audit_entries_seq (seq),
+    INDEX idx_audit_entries_created_at (created_at),
+    INDEX idx_audit_entries_actor (actor_type, actor_id),
+    INDEX idx_audit_entries_shop_id (shop_id),
+    INDEX idx_audit_entries_entity (entity_type, entity_id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_chain (
+    id   BIGINT UNSIGNED PRIMARY KEY,
+    seq  BIGINT UNSIGNED NOT NULL,
+    hash CHAR(64) NOT NULL
+);
+INSERT IGNORE INTO audit_chain (id, seq, hash) VALUES (1, 0, '');
+
+CREATE TRIGGER audit_entries_no_update BEFORE UPDATE ON audit_entries
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_entries is append-only';
+CREATE TRIGGER audit_entries_no_delete BEFORE DELETE ON audit_entries
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_entries is append-only';
@@ -0,0 +1,19 @@
+-- Transactional outbox, mirroring the Postgres migration of the same version.
+-- MySQL has no partial indexes, pending events are found by published_at and dead_at.
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at      DATETIME(6) NOT NULL,
+    type            VARCHAR(64) NOT NULL,
+    aggregate_type  VARCHAR(32) NOT NULL,
+    aggregate_id    BIGINT UNSIGNED NOT NULL,
+    shop_id         BIGINT UNSIGNED,
+    payload         TEXT NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(6) NOT NULL,
+    locked_until    DATETIME(6),
+    last_error      TEXT NOT NULL,
+    published_at    DATETIME(6),
+    dead_at         DATETIME(6),
+    INDEX idx_outbox_events_pending (published_at, dead_at, id)
+);
@@ -0,0 +1,44 @@
+-- Webhooks and their deliveries, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id                   BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at           DATETIME(6),
+    updated_at           DATETIME(6),
+    deleted_at           DATETIME(6),
+    shop_id              BIGINT UNSIGNED NOT NULL,
+    url                  VARCHAR(2048) NOT NULL,
+    secret               VARCHAR(255) NOT NULL,
+    event_types          TEXT NOT NULL,
+    active               BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    disabled_at          DATETIME(6),
+    disabled_reason      VARCHAR(255) NOT NULL DEFAULT '',
+    INDEX idx_webhooks_deleted_at (deleted_at),
+    INDEX idx_webhooks_shop_id (shop_id),
+    CONSTRAINT fk_shops_webhooks FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at      DATETIME(6) NOT NULL,
+    webhook_id      BIGINT UNSIGNED NOT NULL,
+    event_id        BIGINT UNSIGNED NOT NULL,
+    event_type      VARCHAR(64) NOT NULL,
+    payload         MEDIUMTEXT NOT NULL,
+    redelivery_of   BIGINT UNSIGNED,
+    status          VARCHAR(16) NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(6) NOT NULL,
+    locked_until    DATETIME(6),
+    last_attempt_at DATETIME(6),
+    response_code   INT NOT NULL DEFAULT 0,
+    response_body   TEXT NOT NULL,
+    error           TEXT NOT NULL,
+    duration_ms     BIGINT NOT NULL DEFAULT 0,
+    finished_at     DATETIME(6),
+    INDEX idx_webhook_deliveries_webhook_id (webhook_id, id),
+    INDEX idx_webhook_deliveries_event_id (event_id),
+    INDEX idx_webhook_deliveries_pending (status, next_attempt_at),
+    INDEX idx_webhook_deliveries_finished_at (finished_at),
+    CONSTRAINT fk_webhooks_deliveries FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
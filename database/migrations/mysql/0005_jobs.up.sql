@@ -0,0 +1,35 @@
+-- Background job queue, mirroring the Postgres migration of the same version.
+-- MySQL has no partial indexes, runnable jobs are found by status and run_at.
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    created_at   DATETIME(6) NOT NULL,
+    updated_at   DATETIME(6) NOT NULL,
+    type         VARCHAR(64) NOT NULL,
+    payload      TEXT NOT NULL,
+    schedule     VARCHAR(64) NOT NULL DEFAULT '',
+    status       VARCHAR(16) NOT NULL,
+    attempts     INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL,
+    run_at       DATETIME(6) NOT NULL,
+    locked_by    VARCHAR(128) NOT NULL DEFAULT '',
+    locked_until DATETIME(6),
+    last_error   TEXT NOT NULL,
+    started_at   DATETIME(6),
+    finished_at  DATETIME(6),
+    INDEX idx_jobs_runnable (status, run_at),
+    INDEX idx_jobs_status (status, id),
+    INDEX idx_jobs_finished_at (finished_at)
+);
+
+CREATE TABLE IF NOT EXISTS job_schedules (
+    name        VARCHAR(64) PRIMARY KEY,
+    next_run_at DATETIME(6) NOT NULL,
+    last_run_at DATETIME(6)
+);
+
+CREATE TABLE IF NOT EXISTS leader_leases (
+    name       VARCHAR(64) PRIMARY KEY,
+    holder     VARCHAR(128) NOT NULL,
+    expires_at DATETIME(6) NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Outbox index by shop, mirroring the Postgres migration of the same version.
+
+CREATE INDEX idx_outbox_events_shop_id ON outbox_events (shop_id, id);
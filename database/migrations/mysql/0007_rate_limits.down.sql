@@ -0,0 +1,3 @@
+ALTER TABLE shops DROP COLUMN monthly_api_quota;
+DROP TABLE IF EXISTS shop_api_usage;
+DROP TABLE IF EXISTS rate_limit_buckets;
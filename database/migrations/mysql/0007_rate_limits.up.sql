@@ -0,0 +1,18 @@
+-- Rate limits and shop quotas, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    `key`      VARCHAR(255) PRIMARY KEY,
+    tokens     DOUBLE NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    INDEX idx_rate_limit_buckets_updated_at (updated_at)
+);
+
+CREATE TABLE IF NOT EXISTS shop_api_usage (
+    shop_id  BIGINT UNSIGNED NOT NULL,
+    period   VARCHAR(7) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (shop_id, period),
+    CONSTRAINT fk_shops_api_usage FOREIGN KEY (shop_id) REFERENCES shops (id) ON DELETE CASCADE
+);
+
+ALTER TABLE shops ADD COLUMN monthly_api_quota BIGINT;
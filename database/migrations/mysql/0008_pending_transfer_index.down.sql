@@ -0,0 +1,3 @@
+ALTER TABLE shop_transfers
+    DROP INDEX idx_shop_transfers_pending,
+    DROP COLUMN pending_shop_id;
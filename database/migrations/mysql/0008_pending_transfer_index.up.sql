@@ -0,0 +1,16 @@
+-- Pending transfer index, mirroring the Postgres migration of the same version.
+-- MySQL has no partial indexes, so the index covers a generated column that is
+-- NULL for the other transfers; NULLs never collide.
+
+UPDATE shop_transfers SET status = 'expired', responded_at = NOW(6)
+WHERE status = 'pending' AND deleted_at IS NULL AND id NOT IN (
+    SELECT latest.id FROM (
+        SELECT MAX(id) AS id FROM shop_transfers
+        WHERE status = 'pending' AND deleted_at IS NULL
+        GROUP BY shop_id
+    ) AS latest
+);
+
+ALTER TABLE shop_transfers
+    ADD COLUMN pending_shop_id BIGINT UNSIGNED AS (IF(status = 'pending' AND deleted_at IS NULL, shop_id, NULL)) STORED,
+    ADD UNIQUE INDEX idx_shop_transfers_pending (pending_shop_id);
@@ -0,0 +1 @@
+DROP INDEX idx_outbox_events_aggregate ON outbox_events;
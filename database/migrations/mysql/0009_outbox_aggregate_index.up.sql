@@ -0,0 +1,4 @@
+-- Outbox index by aggregate, mirroring the Postgres migration of the same version.
+-- MySQL has no partial indexes, the pending events are found by published_at and dead_at.
+
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id, published_at, dead_at, id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS shop_transfers;
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS inventories;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS shop_employees;
+DROP TABLE IF EXISTS shops;
+DROP TABLE IF EXISTS shop_owners;
@@ -0,0 +1,99 @@
+-- Initial schema, equivalent to what AutoMigrate used to create. Tables and
+-- indexes use IF NOT EXISTS so databases created by AutoMigrate can adopt
+-- versioned migrations without being rebuilt.
+
+CREATE TABLE IF NOT EXISTS shop_owners (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    name       TEXT,
+    email      TEXT,
+    password   TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_shop_owners_deleted_at ON shop_owners (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_owners_email ON shop_owners (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS shops (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    name       TEXT,
+    email      TEXT,
+    owner_id   BIGINT,
+    CONSTRAINT fk_shops_owner FOREIGN KEY (owner_id) REFERENCES shop_owners (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shops_deleted_at ON shops (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shops_email ON shops (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS shop_employees (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    name       TEXT,
+    email      TEXT,
+    password   TEXT,
+    shop_id    BIGINT,
+    CONSTRAINT fk_shops_employees FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shop_employees_deleted_at ON shop_employees (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_employees_email ON shop_employees (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS customers (
+    id         BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    name       TEXT,
+    email      TEXT,
+    password   TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS inventories (
+    id             BIGSERIAL PRIMARY KEY,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ,
+    deleted_at     TIMESTAMPTZ,
+    shop_id        BIGINT,
+    inventory_name TEXT,
+    CONSTRAINT fk_shops_inventories FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+CREATE INDEX IF NOT EXISTS idx_inventories_deleted_at ON inventories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS items (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ,
+    deleted_at   TIMESTAMPTZ,
+    inventory_id BIGINT,
+    name         TEXT,
+    quantity     BIGINT,
+    CONSTRAINT fk_inventories_items FOREIGN KEY (inventory_id) REFERENCES inventories (id)
+);
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items (deleted_at);
+
+CREATE TABLE IF NOT EXISTS shop_transfers (
+    id            BIGSERIAL PRIMARY KEY,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ,
+    deleted_at    TIMESTAMPTZ,
+    shop_id       BIGINT,
+    from_owner_id BIGINT,
+    to_owner_id   BIGINT,
+    status        TEXT,
+    expires_at    TIMESTAMPTZ,
+    responded_at  TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_shop_transfers_deleted_at ON shop_transfers (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shop_transfers_shop_id ON shop_transfers (shop_id);
+CREATE INDEX IF NOT EXISTS idx_shop_transfers_to_owner_id ON shop_transfers (to_owner_id);
+
+-- AutoMigrate created plain unique constraints before soft-delete aware indexes existed.
+ALTER TABLE shop_owners DROP CONSTRAINT IF EXISTS uni_shop_owners_email;
+ALTER TABLE shops DROP CONSTRAINT IF EXISTS uni_shops_email;
+ALTER TABLE shop_employees DROP CONSTRAINT IF EXISTS uni_shop_employees_email;
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS uni_customers_email;
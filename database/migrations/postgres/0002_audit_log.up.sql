@@ -0,0 +1,43 @@
+-- Append-only audit log of every mutation, chained by hash. The single
+-- audit_chain row holds the head of the chain and is locked by writers.
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id          BIGSERIAL PRIMARY KEY,
+    seq         BIGINT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    actor_type  TEXT NOT NULL,
+    actor_id    BIGINT,
+    shop_id     BIGINT,
+    entity_type TEXT NOT NULL,
+    entity_id   BIGINT NOT NULL,
+    action      TEXT NOT NULL,
+    changes     TEXT NOT NULL,
+    request_id  TEXT NOT NULL,
+    ip          TEXT NOT NULL,
+    prev_hash   TEXT NOT NULL,
+    hash        TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_entries_seq ON audit_entries (seq);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor ON audit_entries (actor_type, actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_shop_id ON audit_entries (shop_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_entity ON audit_entries (entity_type, entity_id);
+
+CREATE TABLE IF NOT EXISTS audit_chain (
+    id   BIGINT PRIMARY KEY,
+    seq  BIGINT NOT NULL,
+    hash TEXT NOT NULL
+);
+INSERT INTO audit_chain (id, seq, hash) VALUES (1, 0, '') ON CONFLICT (id) DO NOTHING;
+
+-- Reject any change to recorded entries.
+CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_append_only ON audit_entries;
+CREATE TRIGGER audit_entries_append_only
+    BEFORE UPDATE OR DELETE ON audit_entries
+    FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
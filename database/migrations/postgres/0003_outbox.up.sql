@@ -0,0 +1,21 @@
+-- Transactional outbox: domain events are written here in the transaction of
+-- the change and published by the dispatcher afterwards.
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ NOT NULL,
+    type            TEXT NOT NULL,
+    aggregate_type  TEXT NOT NULL,
+    aggregate_id    BIGINT NOT NULL,
+    shop_id         BIGINT,
+    payload         TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    locked_until    TIMESTAMPTZ,
+    last_error      TEXT NOT NULL DEFAULT '',
+    published_at    TIMESTAMPTZ,
+    dead_at         TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (id)
+    WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at);
@@ -0,0 +1,45 @@
+-- Webhook endpoints registered by shop owners and the log of their deliveries.
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id                   BIGSERIAL PRIMARY KEY,
+    created_at           TIMESTAMPTZ,
+    updated_at           TIMESTAMPTZ,
+    deleted_at           TIMESTAMPTZ,
+    shop_id              BIGINT NOT NULL,
+    url                  TEXT NOT NULL,
+    secret               TEXT NOT NULL,
+    event_types          TEXT NOT NULL,
+    active               BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    disabled_at          TIMESTAMPTZ,
+    disabled_reason      TEXT NOT NULL DEFAULT '',
+    CONSTRAINT fk_shops_webhooks FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_webhooks_shop_id ON webhooks (shop_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ NOT NULL,
+    webhook_id      BIGINT NOT NULL,
+    event_id        BIGINT NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    redelivery_of   BIGINT,
+    status          TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    locked_until    TIMESTAMPTZ,
+    last_attempt_at TIMESTAMPTZ,
+    response_code   INTEGER NOT NULL DEFAULT 0,
+    response_body   TEXT NOT NULL DEFAULT '',
+    error           TEXT NOT NULL DEFAULT '',
+    duration_ms     BIGINT NOT NULL DEFAULT 0,
+    finished_at     TIMESTAMPTZ,
+    CONSTRAINT fk_webhooks_deliveries FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_finished_at ON webhook_deliveries (finished_at);
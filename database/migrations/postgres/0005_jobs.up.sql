@@ -0,0 +1,36 @@
+-- Background job queue, cron schedule state and the leases electing the single
+-- scheduler among replicas.
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id           BIGSERIAL PRIMARY KEY,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    type         TEXT NOT NULL,
+    payload      TEXT NOT NULL,
+    schedule     TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at       TIMESTAMPTZ NOT NULL,
+    locked_by    TEXT NOT NULL DEFAULT '',
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT NOT NULL DEFAULT '',
+    started_at   TIMESTAMPTZ,
+    finished_at  TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_runnable ON jobs (run_at)
+    WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON jobs (finished_at);
+
+CREATE TABLE IF NOT EXISTS job_schedules (
+    name        TEXT PRIMARY KEY,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS leader_leases (
+    name       TEXT PRIMARY KEY,
+    holder     TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
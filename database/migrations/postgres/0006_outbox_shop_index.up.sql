@@ -0,0 +1,4 @@
+-- Streaming clients resume from the last event they saw, reading the events of
+-- one shop by ID.
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_shop_id ON outbox_events (shop_id, id);
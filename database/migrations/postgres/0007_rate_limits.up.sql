@@ -0,0 +1,19 @@
+-- Token buckets of the rate limits shared by the replicas, the monthly API
+-- usage of the shops and their quotas, the default quota applying when null.
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
+
+CREATE TABLE IF NOT EXISTS shop_api_usage (
+    shop_id  BIGINT NOT NULL,
+    period   TEXT NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (shop_id, period),
+    CONSTRAINT fk_shops_api_usage FOREIGN KEY (shop_id) REFERENCES shops (id) ON DELETE CASCADE
+);
+
+ALTER TABLE shops ADD COLUMN IF NOT EXISTS monthly_api_quota BIGINT;
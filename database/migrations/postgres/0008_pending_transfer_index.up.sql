@@ -0,0 +1,12 @@
+-- At most one transfer of a shop is pending, even when two owners' requests
+-- race. Duplicates left by such races are expired first, keeping the latest.
+
+UPDATE shop_transfers SET status = 'expired', responded_at = NOW()
+WHERE status = 'pending' AND deleted_at IS NULL AND id NOT IN (
+    SELECT MAX(id) FROM shop_transfers
+    WHERE status = 'pending' AND deleted_at IS NULL
+    GROUP BY shop_id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_transfers_pending ON shop_transfers (shop_id)
+    WHERE status = 'pending' AND deleted_at IS NULL;
@@ -0,0 +1,5 @@
+-- The dispatcher claims the oldest pending event of each aggregate, looking
+-- up the earlier pending events of the aggregate by ID.
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id, id)
+    WHERE published_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,91 @@
+-- Initial schema for SQLite, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS shop_owners (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    name       TEXT,
+    email      TEXT,
+    password   TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_shop_owners_deleted_at ON shop_owners (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_owners_email ON shop_owners (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS shops (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    name       TEXT,
+    email      TEXT,
+    owner_id   INTEGER,
+    CONSTRAINT fk_shops_owner FOREIGN KEY (owner_id) REFERENCES shop_owners (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shops_deleted_at ON shops (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shops_email ON shops (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS shop_employees (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    name       TEXT,
+    email      TEXT,
+    password   TEXT,
+    shop_id    INTEGER,
+    CONSTRAINT fk_shops_employees FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+CREATE INDEX IF NOT EXISTS idx_shop_employees_deleted_at ON shop_employees (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_employees_email ON shop_employees (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS customers (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    name       TEXT,
+    email      TEXT,
+    password   TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers (email) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS inventories (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at     DATETIME,
+    updated_at     DATETIME,
+    deleted_at     DATETIME,
+    shop_id        INTEGER,
+    inventory_name TEXT,
+    CONSTRAINT fk_shops_inventories FOREIGN KEY (shop_id) REFERENCES shops (id)
+);
+CREATE INDEX IF NOT EXISTS idx_inventories_deleted_at ON inventories (deleted_at);
+
+CREATE TABLE IF NOT EXISTS items (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   DATETIME,
+    updated_at   DATETIME,
+    deleted_at   DATETIME,
+    inventory_id INTEGER,
+    name         TEXT,
+    quantity     INTEGER,
+    CONSTRAINT fk_inventories_items FOREIGN KEY (inventory_id) REFERENCES inventories (id)
+);
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items (deleted_at);
+
+CREATE TABLE IF NOT EXISTS shop_transfers (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at    DATETIME,
+    updated_at    DATETIME,
+    deleted_at    DATETIME,
+    shop_id       INTEGER,
+    from_owner_id INTEGER,
+    to_owner_id   INTEGER,
+    status        TEXT,
+    expires_at    DATETIME,
+    responded_at  DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_shop_transfers_deleted_at ON shop_transfers (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shop_transfers_shop_id ON shop_transfers (shop_id);
+CREATE INDEX IF NOT EXISTS idx_shop_transfers_to_owner_id ON shop_transfers (to_owner_id);
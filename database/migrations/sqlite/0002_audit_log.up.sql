@@ -0,0 +1,39 @@
+-- Append-only audit log, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    seq         INTEGER NOT NULL,
+    created_at  DATETIME NOT NULL,
+    actor_type  TEXT NOT NULL,
+    actor_id    INTEGER,
+    shop_id     INTEGER,
+    entity_type TEXT NOT NULL,
+    entity_id   INTEGER NOT NULL,
+    action      TEXT NOT NULL,
+    changes     TEXT NOT NULL,
+    request_id  TEXT NOT NULL,
+    ip          TEXT NOT NULL,
+    prev_hash   TEXT NOT NULL,
+    hash        TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_entries_seq ON audit_entries (seq);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor ON audit_entries (actor_type, actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_shop_id ON audit_entries (shop_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_entity ON audit_entries (entity_type, entity_id);
+
+CREATE TABLE IF NOT EXISTS audit_chain (
+    id   INTEGER PRIMARY KEY,
+    seq  INTEGER NOT NULL,
+    hash TEXT NOT NULL
+);
+INSERT OR IGNORE INTO audit_chain (id, seq, hash) VALUES (1, 0, '');
+
+CREATE TRIGGER IF NOT EXISTS audit_entries_no_update BEFORE UPDATE ON audit_entries
+BEGIN
+    SELECT RAISE(ABORT, 'audit_entries is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_entries_no_delete BEFORE DELETE ON audit_entries
+BEGIN
+    SELECT RAISE(ABORT, 'audit_entries is append-only');
+END;
@@ -0,0 +1,20 @@
+-- Transactional outbox, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at      DATETIME NOT NULL,
+    type            TEXT NOT NULL,
+    aggregate_type  TEXT NOT NULL,
+    aggregate_id    INTEGER NOT NULL,
+    shop_id         INTEGER,
+    payload         TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    locked_until    DATETIME,
+    last_error      TEXT NOT NULL DEFAULT '',
+    published_at    DATETIME,
+    dead_at         DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (id)
+    WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at);
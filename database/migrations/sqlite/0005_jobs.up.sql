@@ -0,0 +1,35 @@
+-- Background job queue, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at   DATETIME NOT NULL,
+    updated_at   DATETIME NOT NULL,
+    type         TEXT NOT NULL,
+    payload      TEXT NOT NULL,
+    schedule     TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at       DATETIME NOT NULL,
+    locked_by    TEXT NOT NULL DEFAULT '',
+    locked_until DATETIME,
+    last_error   TEXT NOT NULL DEFAULT '',
+    started_at   DATETIME,
+    finished_at  DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_runnable ON jobs (run_at)
+    WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON jobs (finished_at);
+
+CREATE TABLE IF NOT EXISTS job_schedules (
+    name        TEXT PRIMARY KEY,
+    next_run_at DATETIME NOT NULL,
+    last_run_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS leader_leases (
+    name       TEXT PRIMARY KEY,
+    holder     TEXT NOT NULL,
+    expires_at DATETIME NOT NULL
+);
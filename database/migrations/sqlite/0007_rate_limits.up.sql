@@ -0,0 +1,18 @@
+-- Rate limits and shop quotas, mirroring the Postgres migration of the same version.
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        TEXT PRIMARY KEY,
+    tokens     REAL NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
+
+CREATE TABLE IF NOT EXISTS shop_api_usage (
+    shop_id  INTEGER NOT NULL,
+    period   TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (shop_id, period),
+    CONSTRAINT fk_shops_api_usage FOREIGN KEY (shop_id) REFERENCES shops (id) ON DELETE CASCADE
+);
+
+ALTER TABLE shops ADD COLUMN monthly_api_quota INTEGER;
@@ -0,0 +1,11 @@
+-- Pending transfer index, mirroring the Postgres migration of the same version.
+
+UPDATE shop_transfers SET status = 'expired', responded_at = CURRENT_TIMESTAMP
+WHERE status = 'pending' AND deleted_at IS NULL AND id NOT IN (
+    SELECT MAX(id) FROM shop_transfers
+    WHERE status = 'pending' AND deleted_at IS NULL
+    GROUP BY shop_id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_transfers_pending ON shop_transfers (shop_id)
+    WHERE status = 'pending' AND deleted_at IS NULL;
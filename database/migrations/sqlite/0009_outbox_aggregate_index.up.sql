@@ -0,0 +1,4 @@
+-- Outbox index by aggregate, mirroring the Postgres migration of the same version.
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id, id)
+    WHERE published_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,454 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds the SQL migrations compiled into the binary, one
+// directory per dialect. Every dialect has the same versions.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock taken while migrating so that only one
+// replica applies migrations at a time.
+const (
+	migrationLockKey  int64 = 7263540913
+	migrationLockName       = "golang_api_migrations"
+)
+
+// migrationFileName matches files like 0002_add_item_sku.up.sql.
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrChecksumMismatch is returned when an applied migration was edited afterwards.
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes the state of a migration in the database.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	// Missing is set for migrations recorded in the database but unknown to this binary.
+	Missing bool
+	// Modified is set when the applied checksum differs from the embedded file.
+	Modified bool
+}
+
+// appliedMigration is a row of the schema_migrations table.
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies the embedded migrations to a database.
+type Migrator struct {
+	db         *gorm.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for db using the migrations embedded in the
+// binary for its dialect.
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := DialectOf(db)
+	sub, err := fs.Sub(migrationFiles, "migrations/"+string(dialect))
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := LoadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations for the %s dialect", dialect)
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// LoadMigrations reads and pairs the up/down SQL files of fsys, ordered by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		pending, err := m.pending(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last n applied migrations and returns how many were rolled back.
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		known := m.byVersion()
+		for i := len(applied) - 1; i >= 0 && rolledBack < n; i-- {
+			migration, ok := known[applied[i].Version]
+			if !ok {
+				return fmt.Errorf("cannot roll back migration %d_%s: it is unknown to this binary", applied[i].Version, applied[i].Name)
+			}
+			if err := m.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status reports every known and applied migration, ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		appliedByVersion := map[int64]appliedMigration{}
+		for _, a := range applied {
+			appliedByVersion[a.Version] = a
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if a, ok := appliedByVersion[migration.Version]; ok {
+				appliedAt := a.AppliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+				status.Modified = a.Checksum != migration.Checksum
+				delete(appliedByVersion, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for _, a := range appliedByVersion {
+			appliedAt := a.AppliedAt
+			statuses = append(statuses, MigrationStatus{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: &appliedAt, Missing: true})
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// Pending returns the migrations that still have to be applied. It fails if an
+// applied migration was modified after being applied.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	var pending []Migration
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		var err error
+		pending, err = m.pending(ctx, conn)
+		return err
+	})
+	return pending, err
+}
+
+// UpToDate reports an error when migrations are pending or an applied one was
+// modified, the schema not being the one this binary expects.
+func (m *Migrator) UpToDate(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("schema is behind by %d migration(s), next: %d_%s", len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+// pending compares the embedded migrations with the applied ones.
+func (m *Migrator) pending(ctx context.Context, conn *sql.Conn) ([]Migration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := map[int64]string{}
+	for _, a := range applied {
+		checksums[a.Version] = a.Checksum
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		checksum, ok := checksums[migration.Version]
+		if !ok {
+			pending = append(pending, migration)
+			continue
+		}
+		if checksum != migration.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s was changed after it was applied", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return pending, nil
+}
+
+// applied reads the schema_migrations table, creating it if needed.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) ([]appliedMigration, error) {
+	if _, err := conn.ExecContext(ctx, m.schemaMigrationsTable()); err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []appliedMigration
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// apply runs an up migration and records it in a single transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ("+
+				m.placeholder(1)+", "+m.placeholder(2)+", "+m.placeholder(3)+", "+m.placeholder(4)+")",
+			migration.Version, migration.Name, migration.Checksum, time.Now())
+		return err
+	})
+}
+
+// revert runs a down migration and removes its record in a single transaction.
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if strings.TrimSpace(migration.Down) == "" {
+		return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return fmt.Errorf("reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = "+m.placeholder(1), migration.Version)
+		return err
+	})
+}
+
+// byVersion indexes the embedded migrations by version.
+func (m *Migrator) byVersion() map[int64]Migration {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	return known
+}
+
+// withConn runs fn on a dedicated connection from the pool.
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(conn)
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock.
+// The lock is session scoped, so it is released even if the process dies.
+// Databases without advisory locks (SQLite) are local to one process, which
+// serializes migrations on its single connection anyway.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		if !m.dialect.Capabilities().AdvisoryLocks {
+			return fn(conn)
+		}
+
+		lock, unlock := "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)"
+		var key interface{} = migrationLockKey
+		if m.dialect == MySQL {
+			lock, unlock = "SELECT GET_LOCK(?, -1)", "SELECT RELEASE_LOCK(?)"
+			key = migrationLockName
+		}
+
+		if _, err := conn.ExecContext(ctx, lock, key); err != nil {
+			return fmt.Errorf("acquiring migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), unlock, key)
+		return fn(conn)
+	})
+}
+
+// schemaMigrationsTable returns the DDL of the table recording applied migrations.
+func (m *Migrator) schemaMigrationsTable() string {
+	switch m.dialect {
+	case MySQL:
+		return `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       VARCHAR(255) NOT NULL,
+		checksum   CHAR(64) NOT NULL,
+		applied_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+	)`
+	case SQLite:
+		return `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   TEXT NOT NULL,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+	}
+	return `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		checksum   TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+}
+
+// placeholder returns the i-th (1-based) bind parameter in the dialect's syntax.
+func (m *Migrator) placeholder(i int) string {
+	if m.dialect == Postgres {
+		return "$" + strconv.Itoa(i)
+	}
+	return "?"
+}
+
+// inTx runs fn in a transaction on conn.
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateMigration writes an empty up/down migration pair named after name into
+// every dialect directory under dir, numbered after the newest existing version.
+func CreateMigration(dir, name string) ([]string, error) {
+	slug := strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return nil, errors.New("migration name must contain letters or digits")
+	}
+
+	dialects := []Dialect{Postgres, SQLite, MySQL}
+	version := int64(1)
+	for _, dialect := range dialects {
+		existing, err := LoadMigrations(os.DirFS(filepath.Join(dir, string(dialect))))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if len(existing) > 0 && existing[len(existing)-1].Version >= version {
+			version = existing[len(existing)-1].Version + 1
+		}
+	}
+
+	var paths []string
+	for _, dialect := range dialects {
+		if err := os.MkdirAll(filepath.Join(dir, string(dialect)), 0o755); err != nil {
+			return paths, err
+		}
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, string(dialect), fmt.Sprintf("%04d_%s.%s.sql", version, slug, direction))
+			content := fmt.Sprintf("-- %s migration %04d for %s: %s\n", direction, version, dialect, name)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
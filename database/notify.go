@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// Notifier relays messages between the replicas sharing a Postgres database
+// over one LISTEN/NOTIFY channel.
+type Notifier struct {
+	db      *gorm.DB
+	channel string
+}
+
+// NewNotifier creates a Notifier on channel. The database must support
+// LISTEN/NOTIFY, see Capabilities.
+func NewNotifier(db *gorm.DB, channel string) (*Notifier, error) {
+	if !CapabilitiesOf(db).ListenNotify {
+		return nil, fmt.Errorf("%s has no LISTEN/NOTIFY", DialectOf(db))
+	}
+	return &Notifier{db: db, channel: channel}, nil
+}
+
+// Notify sends payload, at most 8000 bytes, to the listeners of the channel.
+func (n *Notifier) Notify(ctx context.Context, payload string) error {
+	return n.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", n.channel, payload).Error
+}
+
+// Listen calls handle with the payload of every notification on the channel
+// until ctx is cancelled or the connection fails. It holds a connection of the
+// pool meanwhile, and closes it afterwards rather than hand a listening
+// connection back to the pool.
+func (n *Notifier) Listen(ctx context.Context, handle func(payload string)) error {
+	sqlDB, err := n.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("LISTEN needs the pgx driver, got %T", driverConn)
+		}
+		if _, err := pgxConn.Conn().Exec(ctx, "LISTEN "+pgx.Identifier{n.channel}.Sanitize()); err != nil {
+			return errors.Join(err, driver.ErrBadConn)
+		}
+		for {
+			notification, err := pgxConn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				return errors.Join(err, driver.ErrBadConn)
+			}
+			handle(notification.Payload)
+		}
+	})
+}
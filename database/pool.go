@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mohamedhabas11/golang-api/config"
+)
+
+// ConfigurePool applies cfg to the pool of db, keeping at most as many idle
+// connections as open ones. SQLite keeps its single connection for good (see
+// OpenURL): closing the connection to :memory: would lose the database.
+func ConfigurePool(db *gorm.DB, cfg config.Pool) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if DialectOf(db) != SQLite {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		maxIdle := cfg.MaxIdleConns
+		if cfg.MaxOpenConns > 0 {
+			maxIdle = min(maxIdle, cfg.MaxOpenConns)
+		}
+		sqlDB.SetMaxIdleConns(maxIdle)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+	return nil
+}
+
+// connectWithRetry opens databaseURL, retrying with exponential backoff until
+// the database accepts connections or timeout elapses. This lets the API start
+// before the database container is ready.
+func connectWithRetry(databaseURL string, queryLogger logger.Interface, timeout time.Duration) (*gorm.DB, error) {
+	// A malformed URL won't get better by waiting.
+	if _, err := Dialector(databaseURL); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		db, err := OpenURL(databaseURL, queryLogger)
+		if err == nil {
+			return db, nil
+		}
+		slog.Warn("Database not reachable, retrying", "attempt", attempt, "retry_in", backoff.String(), "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("giving up after %d attempts in %s: %w", attempt, timeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 10*time.Second)
+	}
+}
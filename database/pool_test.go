@@ -0,0 +1,33 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm/logger"
+
+	"github.com/mohamedhabas11/golang-api/config"
+	"github.com/mohamedhabas11/golang-api/database"
+)
+
+// TestConfigurePoolKeepsSQLiteConnection checks that the lifetimes of the pool
+// don't close the connection to an in-memory SQLite database, which would
+// lose it.
+func TestConfigurePoolKeepsSQLiteConnection(t *testing.T) {
+	db, err := database.OpenURL("sqlite::memory:", logger.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.ConfigurePool(db, config.Pool{MaxOpenConns: 25, MaxIdleConns: 10, ConnMaxLifetime: time.Millisecond, ConnMaxIdleTime: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE kept (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM kept").Scan(&count).Error; err != nil {
+		t.Fatalf("the database was lost: %v", err)
+	}
+}
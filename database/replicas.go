@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mohamedhabas11/golang-api/config"
+)
+
+// replicaReadsKey marks a context whose queries may be served by a replica.
+type replicaReadsKey struct{}
+
+// WithReplicaReads allows the SELECTs run with ctx to go to a read replica.
+// Queries inside transactions always stay on the primary.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+// WithPrimaryReads keeps the queries run with ctx on the primary, even when
+// a parent context allowed replica reads.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, false)
+}
+
+// ReplicaReadsAllowed reports whether ctx was marked by WithReplicaReads
+// rather than WithPrimaryReads.
+func ReplicaReadsAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(replicaReadsKey{}).(bool)
+	return allowed
+}
+
+// replica is one read replica and its last known health.
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// ReplicaSet routes reads marked with WithReplicaReads to healthy replicas,
+// round robin, and falls back to the primary when none is healthy.
+type ReplicaSet struct {
+	dialect  Dialect
+	replicas []*replica
+	next     atomic.Uint64
+	// maxLag marks Postgres replicas replaying further behind as unhealthy.
+	maxLag time.Duration
+}
+
+// OpenReplicas opens every replica of cfg.Replicas. It returns nil when no
+// replica is configured. Replicas must use the dialect of the primary and
+// share its pool settings; one that is down at startup is only marked
+// unhealthy.
+func OpenReplicas(primary *gorm.DB, cfg config.Database) *ReplicaSet {
+	if len(cfg.Replicas.URLs) == 0 {
+		return nil
+	}
+
+	set := &ReplicaSet{dialect: DialectOf(primary), maxLag: cfg.Replicas.MaxLag}
+	for i, databaseURL := range cfg.Replicas.URLs {
+		name := fmt.Sprintf("%d", i+1)
+		sqlDB, err := openReplica(databaseURL.Reveal(), set.dialect, cfg.Pool)
+		if err != nil {
+			slog.Warn("Skipping read replica", "replica", name, "error", err)
+			continue
+		}
+		r := &replica{name: name, db: sqlDB}
+		r.healthy.Store(true) // Only log replicas found unhealthy by the first check
+		set.replicas = append(set.replicas, r)
+	}
+	if len(set.replicas) == 0 {
+		slog.Warn("No usable read replica, reading from the primary")
+		return nil
+	}
+
+	set.checkAll(context.Background())
+	slog.Info("Routing reads to the read replicas", "replicas", len(set.replicas))
+	return set
+}
+
+// openReplica opens the pool of one replica without waiting for it to be up;
+// the health checks take care of that.
+func openReplica(databaseURL string, dialect Dialect, pool config.Pool) (*sql.DB, error) {
+	dialector, err := Dialector(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:               logger.Default.LogMode(logger.Silent),
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if DialectOf(db) != dialect {
+		return nil, fmt.Errorf("it is a %s database, the primary is %s", DialectOf(db), dialect)
+	}
+	if err := ConfigurePool(db, pool); err != nil {
+		return nil, err
+	}
+	return db.DB()
+}
+
+// Register installs the routing callbacks on the primary connection.
+func (s *ReplicaSet) Register(primary *gorm.DB) error {
+	if err := primary.Callback().Query().Before("gorm:query").Register("replicas:route", s.route); err != nil {
+		return err
+	}
+	return primary.Callback().Row().Before("gorm:row").Register("replicas:route", s.route)
+}
+
+// route switches the connection of a read to a replica when its context
+// allows it and it doesn't run inside a transaction.
+func (s *ReplicaSet) route(db *gorm.DB) {
+	if !ReplicaReadsAllowed(db.Statement.Context) {
+		return
+	}
+	if _, inTx := db.Statement.ConnPool.(*sql.Tx); inTx {
+		return
+	}
+	if r := s.pick(); r != nil {
+		db.Statement.ConnPool = r.db
+	}
+}
+
+// pick returns the next healthy replica, or nil if there is none.
+func (s *ReplicaSet) pick() *replica {
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := s.replicas[(start+i)%n]; r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// Run checks the health of every replica each interval until ctx is cancelled.
+func (s *ReplicaSet) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkAll(ctx)
+		}
+	}
+}
+
+// Healthy reports an error naming the replicas found unhealthy by the last
+// check, reads going to the primary meanwhile.
+func (s *ReplicaSet) Healthy(context.Context) error {
+	var unhealthy []string
+	for _, r := range s.replicas {
+		if !r.healthy.Load() {
+			unhealthy = append(unhealthy, r.name)
+		}
+	}
+	if len(unhealthy) > 0 {
+		return fmt.Errorf("%d of %d read replicas unhealthy: %s", len(unhealthy), len(s.replicas), strings.Join(unhealthy, ", "))
+	}
+	return nil
+}
+
+// checkAll updates the health of every replica and logs the changes.
+func (s *ReplicaSet) checkAll(ctx context.Context) {
+	for _, r := range s.replicas {
+		err := s.check(ctx, r)
+		if healthy := err == nil; r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				slog.Info("Read replica is healthy", "replica", r.name)
+			} else {
+				slog.Warn("Read replica is unhealthy, its reads go to the primary", "replica", r.name, "error", err)
+			}
+		}
+	}
+}
+
+// check pings a replica and, on Postgres, verifies its replication lag.
+func (s *ReplicaSet) check(ctx context.Context, r *replica) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	if err := r.db.PingContext(ctx); err != nil {
+		return err
+	}
+	if s.dialect != Postgres || s.maxLag == 0 {
+		return nil
+	}
+
+	// A replica that replayed everything it received is not lagging, however
+	// old its last transaction is. NULL means the server isn't replaying WAL.
+	var lag sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, `SELECT CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+	END`).Scan(&lag)
+	if err != nil {
+		return err
+	}
+	if lag.Valid && time.Duration(lag.Float64*float64(time.Second)) > s.maxLag {
+		return fmt.Errorf("replication lag of %.1fs exceeds %s", lag.Float64, s.maxLag)
+	}
+	return nil
+}
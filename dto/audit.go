@@ -0,0 +1,105 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+)
+
+// Audit log page sizes.
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+// AuditQuery holds the filters of GET /api/audit. Entries are returned newest
+// first; pass the seq of the last entry as before_seq to get the next page.
+type AuditQuery struct {
+	ShopID     uint   `query:"shop_id" json:"shop_id"`
+	EntityType string `query:"entity_type" json:"entity_type" validate:"omitempty,oneof=shop shop_owner shop_employee customer inventory item shop_transfer webhook"`
+	EntityID   uint   `query:"entity_id" json:"entity_id"`
+	ActorType  string `query:"actor_type" json:"actor_type" validate:"omitempty,max=32"`
+	ActorID    uint   `query:"actor_id" json:"actor_id"`
+	From       string `query:"from" json:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `query:"to" json:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	BeforeSeq  uint64 `query:"before_seq" json:"before_seq"`
+	Limit      int    `query:"limit" json:"limit" validate:"omitempty,min=1,max=200"`
+}
+
+// ToFilter maps the query onto a repository filter. The query must be valid.
+func (q AuditQuery) ToFilter() repositories.AuditFilter {
+	filter := repositories.AuditFilter{
+		EntityType: q.EntityType,
+		EntityID:   q.EntityID,
+		ActorType:  q.ActorType,
+		ActorID:    q.ActorID,
+		BeforeSeq:  q.BeforeSeq,
+		Limit:      min(q.Limit, maxAuditLimit),
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if q.ShopID != 0 {
+		filter.ShopIDs = []uint{q.ShopID}
+	}
+	filter.From, _ = time.Parse(time.RFC3339, q.From)
+	filter.To, _ = time.Parse(time.RFC3339, q.To)
+	return filter
+}
+
+// AuditEntryResponse is the public representation of an audit log entry.
+type AuditEntryResponse struct {
+	ID         uint            `json:"id"`
+	Seq        uint64          `json:"seq"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorType  string          `json:"actor_type"`
+	ActorID    *uint           `json:"actor_id"`
+	ShopID     *uint           `json:"shop_id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   uint            `json:"entity_id"`
+	Action     string          `json:"action"`
+	Changes    json.RawMessage `json:"changes"`
+	RequestID  string          `json:"request_id"`
+	IP         string          `json:"ip"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// NewAuditEntryResponse maps an audit entry onto its public representation.
+func NewAuditEntryResponse(e models.AuditEntry) AuditEntryResponse {
+	changes := json.RawMessage(e.Changes)
+	if !json.Valid(changes) {
+		changes = json.RawMessage("{}")
+	}
+	return AuditEntryResponse{
+		ID:         e.ID,
+		Seq:        e.Seq,
+		CreatedAt:  e.CreatedAt,
+		ActorType:  e.ActorType,
+		ActorID:    e.ActorID,
+		ShopID:     e.ShopID,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     e.Action,
+		Changes:    changes,
+		RequestID:  e.RequestID,
+		IP:         e.IP,
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+	}
+}
+
+// NewAuditEntryResponses maps a list of audit entries.
+func NewAuditEntryResponses(entries []models.AuditEntry) []AuditEntryResponse {
+	return mapSlice(entries, NewAuditEntryResponse)
+}
+
+// AuditVerificationResponse is the body of GET /api/audit/verify.
+type AuditVerificationResponse struct {
+	Valid           bool   `json:"valid"`
+	Checked         uint64 `json:"checked"`
+	FirstInvalidSeq uint64 `json:"first_invalid_seq,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
@@ -0,0 +1,222 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/events"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// MinPasswordLength is the minimum length enforced by the "password" rule.
+const MinPasswordLength = 8
+
+// validate is the shared validator instance; it caches struct metadata so it must be reused.
+var validate = newValidator()
+
+// newValidator creates a validator that reports fields by their JSON names and
+// knows the custom rules used by the request DTOs.
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report errors using the JSON field names clients actually send.
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	// password checks a plaintext password against the shared password policy.
+	_ = v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return utils.ValidatePassword(fl.Field().String(), utils.NewPasswordValidationConfig(MinPasswordLength)) == nil
+	})
+
+	// notblank rejects strings made only of whitespace.
+	_ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	// eventtype accepts the domain event types webhooks can subscribe to.
+	_ = v.RegisterValidation("eventtype", func(fl validator.FieldLevel) bool {
+		eventType := fl.Field().String()
+		return eventType == models.WebhookAllEvents || slices.Contains(events.Types, eventType)
+	})
+
+	return v
+}
+
+// Bind decodes the JSON request body into out and validates it. Unknown fields
+// are rejected and every rule violation is reported together, keyed by JSON path.
+func Bind(c *fiber.Ctx, out interface{}) error {
+	if !strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEApplicationJSON) {
+		return utils.NewAPIError(fiber.StatusUnsupportedMediaType, utils.CodeUnsupportedMediaType, "Content-Type must be application/json")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(c.Body()))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(out); err != nil {
+		return decodeError(err)
+	}
+	// Only a single JSON document is allowed in the body.
+	if decoder.More() {
+		return utils.ErrBadRequest("Request body must contain a single JSON object")
+	}
+
+	return Validate(out)
+}
+
+// BindQuery decodes the query string into out using its `query` tags and validates it.
+func BindQuery(c *fiber.Ctx, out interface{}) error {
+	if err := c.QueryParser(out); err != nil {
+		return utils.ErrBadRequest("Invalid query parameters")
+	}
+	return Validate(out)
+}
+
+// Validate runs the declarative rules of a DTO and returns a validation APIError
+// listing every violation, or nil if the value is valid.
+func Validate(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]utils.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, utils.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Message: fieldMessage(fe),
+		})
+	}
+	return utils.ErrValidation(fields)
+}
+
+// decodeError converts a JSON decoding failure into an APIError.
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return utils.ErrBadRequest("Request body is required")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return utils.ErrBadRequest("Request body is not valid JSON")
+	case errors.As(err, &typeErr):
+		return utils.ErrValidation([]utils.FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be of type %s", jsonType(typeErr.Type)),
+		}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return utils.ErrValidation([]utils.FieldError{{
+			Field:   field,
+			Message: "is not a recognized field",
+		}})
+	default:
+		return utils.ErrBadRequest("Invalid request body")
+	}
+}
+
+// fieldPath strips the top-level struct name from a validator namespace,
+// e.g. "CreateShopRequest.owner.email" becomes "owner.email".
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// fieldMessage returns a human readable description of a failed rule.
+func fieldMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required", "required_with", "required_without", "notblank":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "password":
+		return fmt.Sprintf("must be at least %d characters long", MinPasswordLength)
+	case "min", "gte":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "max", "lte":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(fe.Param()), ", "))
+	case "eqfield":
+		return fmt.Sprintf("must match %s", jsonName(fe.Param()))
+	case "nefield":
+		return fmt.Sprintf("must differ from %s", jsonName(fe.Param()))
+	case "datetime":
+		return "must be an RFC 3339 timestamp"
+	case "http_url":
+		return "must be an http or https URL"
+	case "eventtype":
+		return fmt.Sprintf("must be a known event type or %s", models.WebhookAllEvents)
+	default:
+		return "is invalid"
+	}
+}
+
+// jsonName converts a Go field name used as a rule parameter into snake_case.
+func jsonName(goName string) string {
+	var b strings.Builder
+	for i, r := range goName {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// jsonType names the JSON type expected for a Go type.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
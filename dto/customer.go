@@ -0,0 +1,55 @@
+package dto
+
+import "github.com/mohamedhabas11/golang-api/models"
+
+// CreateCustomerRequest is the payload of POST /api/customer/signup.
+type CreateCustomerRequest struct {
+	Name     string `json:"name" validate:"required,notblank,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password,max=72"`
+}
+
+// ToModel maps the request onto a new Customer. The password is left empty;
+// the caller stores its hash.
+func (r CreateCustomerRequest) ToModel() models.Customer {
+	return models.Customer{
+		Name:  r.Name,
+		Email: r.Email,
+	}
+}
+
+// LoginRequest is the payload of the customer and shop owner login endpoints.
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// CustomerResponse is the public representation of a Customer.
+type CustomerResponse struct {
+	Resource
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// NewCustomerResponse builds the public representation of a customer.
+func NewCustomerResponse(customer models.Customer) CustomerResponse {
+	return CustomerResponse{
+		Resource: newResource(customer.Model),
+		Name:     customer.Name,
+		Email:    customer.Email,
+	}
+}
+
+// NewCustomerResponses builds the public representation of a list of customers.
+func NewCustomerResponses(customers []models.Customer) []CustomerResponse {
+	return mapSlice(customers, NewCustomerResponse)
+}
+
+// TokenResponse is returned by the login endpoints.
+type TokenResponse struct {
+	Message string `json:"message"`
+	Token   string `json:"token"`
+	// CSRFToken must be sent in the X-CSRF-Token header of the mutations
+	// authenticated by the cookie of the token.
+	CSRFToken string `json:"csrf_token"`
+}
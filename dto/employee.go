@@ -0,0 +1,68 @@
+package dto
+
+import "github.com/mohamedhabas11/golang-api/models"
+
+// CreateEmployeeRequest is the payload of POST /api/employees.
+type CreateEmployeeRequest struct {
+	ShopID   uint   `json:"shop_id" validate:"required"`
+	Name     string `json:"name" validate:"required,notblank,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password,max=72"`
+}
+
+// ToModel maps the request onto a new ShopEmployee. The password is left empty;
+// the caller stores its hash.
+func (r CreateEmployeeRequest) ToModel() models.ShopEmployee {
+	return models.ShopEmployee{
+		ShopID: r.ShopID,
+		Name:   r.Name,
+		Email:  r.Email,
+	}
+}
+
+// UpdateEmployeeRequest is the payload of PUT /api/employees/:id. Omitted fields
+// are left unchanged; a new password must be repeated in password_confirmation.
+type UpdateEmployeeRequest struct {
+	ShopID               *uint   `json:"shop_id" validate:"omitempty,gt=0"`
+	Name                 *string `json:"name" validate:"omitempty,notblank,max=100"`
+	Email                *string `json:"email" validate:"omitempty,email,max=255"`
+	Password             *string `json:"password" validate:"omitempty,password,max=72"`
+	PasswordConfirmation *string `json:"password_confirmation" validate:"required_with=Password,omitempty,eqfield=Password"`
+}
+
+// ApplyTo copies the provided non-secret fields onto an existing employee.
+// Password changes are handled by the caller because they need hashing.
+func (r UpdateEmployeeRequest) ApplyTo(employee *models.ShopEmployee) {
+	if r.ShopID != nil {
+		employee.ShopID = *r.ShopID
+	}
+	if r.Name != nil {
+		employee.Name = *r.Name
+	}
+	if r.Email != nil {
+		employee.Email = *r.Email
+	}
+}
+
+// EmployeeResponse is the public representation of a ShopEmployee.
+type EmployeeResponse struct {
+	Resource
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	ShopID uint   `json:"shop_id"`
+}
+
+// NewEmployeeResponse builds the public representation of an employee.
+func NewEmployeeResponse(employee models.ShopEmployee) EmployeeResponse {
+	return EmployeeResponse{
+		Resource: newResource(employee.Model),
+		Name:     employee.Name,
+		Email:    employee.Email,
+		ShopID:   employee.ShopID,
+	}
+}
+
+// NewEmployeeResponses builds the public representation of a list of employees.
+func NewEmployeeResponses(employees []models.ShopEmployee) []EmployeeResponse {
+	return mapSlice(employees, NewEmployeeResponse)
+}
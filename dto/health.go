@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"database/sql"
+)
+
+// DatabaseHealthResponse is the body of GET /health/db.
+type DatabaseHealthResponse struct {
+	Status string             `json:"status"`
+	Pool   *PoolStatsResponse `json:"pool,omitempty"`
+}
+
+// PoolStatsResponse exposes the database/sql connection pool statistics.
+type PoolStatsResponse struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMs     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// NewDatabaseHealthResponse reports a reachable database with the statistics
+// of its connection pool, if it has one.
+func NewDatabaseHealthResponse(s *sql.DBStats) DatabaseHealthResponse {
+	resp := DatabaseHealthResponse{Status: "ok"}
+	if s == nil {
+		return resp
+	}
+
+	resp.Pool = &PoolStatsResponse{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMs:     s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+	return resp
+}
+
+// ReadinessResponse is the body of GET /readyz.
+type ReadinessResponse struct {
+	// Status is starting, ready, not_ready or draining.
+	Status string          `json:"status"`
+	Checks []CheckResponse `json:"checks"`
+}
+
+// CheckResponse is the outcome of one readiness check.
+type CheckResponse struct {
+	Name string `json:"name"`
+	// Status is ok or failed; a failed optional check leaves the API ready.
+	Status     string  `json:"status"`
+	Optional   bool    `json:"optional"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// StatusResponse is the body of the liveness and startup probes.
+type StatusResponse struct {
+	Status string `json:"status"`
+}
@@ -0,0 +1,52 @@
+package dto
+
+import "github.com/mohamedhabas11/golang-api/models"
+
+// CreateInventoryRequest is the payload of POST /api/inventories.
+type CreateInventoryRequest struct {
+	ShopID        uint   `json:"shop_id" validate:"required"`
+	InventoryName string `json:"inventory_name" validate:"required,notblank,max=100"`
+}
+
+// ToModel maps the request onto a new Inventory.
+func (r CreateInventoryRequest) ToModel() models.Inventory {
+	return models.Inventory{
+		ShopID:        r.ShopID,
+		InventoryName: r.InventoryName,
+	}
+}
+
+// UpdateInventoryRequest is the payload of PUT /api/inventories/:id. Omitted fields are left unchanged.
+type UpdateInventoryRequest struct {
+	InventoryName *string `json:"inventory_name" validate:"omitempty,notblank,max=100"`
+}
+
+// ApplyTo copies the provided fields onto an existing inventory.
+func (r UpdateInventoryRequest) ApplyTo(inventory *models.Inventory) {
+	if r.InventoryName != nil {
+		inventory.InventoryName = *r.InventoryName
+	}
+}
+
+// InventoryResponse is the public representation of an Inventory and its items.
+type InventoryResponse struct {
+	Resource
+	ShopID        uint           `json:"shop_id"`
+	InventoryName string         `json:"inventory_name"`
+	Items         []ItemResponse `json:"items"`
+}
+
+// NewInventoryResponse builds the public representation of an inventory.
+func NewInventoryResponse(inventory models.Inventory) InventoryResponse {
+	return InventoryResponse{
+		Resource:      newResource(inventory.Model),
+		ShopID:        inventory.ShopID,
+		InventoryName: inventory.InventoryName,
+		Items:         NewItemResponses(inventory.Items),
+	}
+}
+
+// NewInventoryResponses builds the public representation of a list of inventories.
+func NewInventoryResponses(inventories []models.Inventory) []InventoryResponse {
+	return mapSlice(inventories, NewInventoryResponse)
+}
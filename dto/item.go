@@ -0,0 +1,63 @@
+package dto
+
+import "github.com/mohamedhabas11/golang-api/models"
+
+// CreateItemRequest is the payload of POST /api/items.
+type CreateItemRequest struct {
+	InventoryID uint   `json:"inventory_id" validate:"required"`
+	Name        string `json:"name" validate:"required,notblank,max=100"`
+	Quantity    *int   `json:"quantity" validate:"required,gte=0,lte=1000000"`
+}
+
+// ToModel maps the request onto a new Item.
+func (r CreateItemRequest) ToModel() models.Item {
+	return models.Item{
+		InventoryID: r.InventoryID,
+		Name:        r.Name,
+		Quantity:    *r.Quantity,
+	}
+}
+
+// UpdateItemRequest is the payload of PUT /api/items/:id. An omitted (nil)
+// field leaves the item unchanged; any value, including 0, replaces it.
+type UpdateItemRequest struct {
+	InventoryID *uint   `json:"inventory_id" validate:"omitempty,gt=0"`
+	Name        *string `json:"name" validate:"omitempty,notblank,max=100"`
+	Quantity    *int    `json:"quantity" validate:"omitempty,gte=0,lte=1000000"`
+}
+
+// ApplyTo copies the provided fields onto an existing item.
+func (r UpdateItemRequest) ApplyTo(item *models.Item) {
+	if r.InventoryID != nil {
+		item.InventoryID = *r.InventoryID
+	}
+	if r.Name != nil {
+		item.Name = *r.Name
+	}
+	if r.Quantity != nil {
+		item.Quantity = *r.Quantity
+	}
+}
+
+// ItemResponse is the public representation of an Item.
+type ItemResponse struct {
+	Resource
+	InventoryID uint   `json:"inventory_id"`
+	Name        string `json:"name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// NewItemResponse builds the public representation of an item.
+func NewItemResponse(item models.Item) ItemResponse {
+	return ItemResponse{
+		Resource:    newResource(item.Model),
+		InventoryID: item.InventoryID,
+		Name:        item.Name,
+		Quantity:    item.Quantity,
+	}
+}
+
+// NewItemResponses builds the public representation of a list of items.
+func NewItemResponses(items []models.Item) []ItemResponse {
+	return mapSlice(items, NewItemResponse)
+}
@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+)
+
+// Job list page sizes.
+const (
+	defaultJobLimit = 50
+	maxJobLimit     = 200
+)
+
+// JobQuery holds the filters of GET /api/admin/jobs. Jobs are returned newest
+// first; pass the ID of the last job as before_id to get the next page.
+type JobQuery struct {
+	Status   string `query:"status" json:"status" validate:"omitempty,oneof=queued running succeeded dead cancelled"`
+	Type     string `query:"type" json:"type" validate:"omitempty,max=100"`
+	BeforeID uint   `query:"before_id" json:"before_id"`
+	Limit    int    `query:"limit" json:"limit" validate:"omitempty,min=1,max=200"`
+}
+
+// ToFilter maps the query onto a repository filter. The query must be valid.
+func (q JobQuery) ToFilter() repositories.JobFilter {
+	filter := repositories.JobFilter{
+		Status:   q.Status,
+		Type:     q.Type,
+		BeforeID: q.BeforeID,
+		Limit:    min(q.Limit, maxJobLimit),
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultJobLimit
+	}
+	return filter
+}
+
+// JobResponse is the public representation of a background job.
+type JobResponse struct {
+	ID          uint            `json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Schedule    string          `json:"schedule,omitempty"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LockedUntil *time.Time      `json:"locked_until"`
+	LastError   string          `json:"last_error"`
+	StartedAt   *time.Time      `json:"started_at"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+}
+
+// NewJobResponse builds the public representation of a job.
+func NewJobResponse(j models.Job) JobResponse {
+	payload := json.RawMessage(j.Payload)
+	if !json.Valid(payload) {
+		payload = json.RawMessage("null")
+	}
+	return JobResponse{
+		ID:          j.ID,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+		Type:        j.Type,
+		Payload:     payload,
+		Schedule:    j.Schedule,
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		LockedBy:    j.LockedBy,
+		LockedUntil: j.LockedUntil,
+		LastError:   j.LastError,
+		StartedAt:   j.StartedAt,
+		FinishedAt:  j.FinishedAt,
+	}
+}
+
+// NewJobResponses maps a list of jobs.
+func NewJobResponses(jobs []models.Job) []JobResponse {
+	return mapSlice(jobs, NewJobResponse)
+}
+
+// JobStatsResponse is the body of GET /api/admin/jobs/stats.
+type JobStatsResponse struct {
+	Counts    map[string]int64      `json:"counts"` // Jobs per status
+	Types     []string              `json:"types"`  // Job types handled by this replica
+	Schedules []JobScheduleResponse `json:"schedules"`
+	Leader    *JobLeaderResponse    `json:"leader"` // Null when no replica runs the scheduler
+}
+
+// JobScheduleResponse describes a cron schedule.
+type JobScheduleResponse struct {
+	Name      string     `json:"name"`
+	Spec      string     `json:"spec"`
+	JobType   string     `json:"job_type"`
+	NextRunAt *time.Time `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+}
+
+// JobLeaderResponse describes the replica enqueuing the scheduled jobs.
+type JobLeaderResponse struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// LogLevelRequest changes the minimum level of the logs, in the body of PUT
+// /api/admin/log-level.
+type LogLevelRequest struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn error"`
+}
+
+// LogLevelResponse is the minimum level of the logs.
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// ShopQuotaRequest sets the monthly API quota of a shop, in the body of PUT
+// /api/admin/shops/:id/quota. Null restores the default quota, 0 lifts the
+// limit.
+type ShopQuotaRequest struct {
+	MonthlyAPIQuota *int64 `json:"monthly_api_quota" validate:"omitempty,gte=0"`
+}
+
+// ShopUsageResponse is the API usage of a shop in the current month.
+type ShopUsageResponse struct {
+	ShopID   uint   `json:"shop_id"`
+	Period   string `json:"period"` // 2006-01, in UTC
+	Requests int64  `json:"requests"`
+	// Quota and Remaining are null when the shop is unlimited.
+	Quota     *int64    `json:"quota"`
+	Remaining *int64    `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Resource holds the identity and timestamps shared by every public representation.
+// DeletedAt is only set for rows listed from the trash.
+type Resource struct {
+	ID        uint       `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// MessageResponse is the body of the endpoints answering with a message only.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// newResource copies the public part of an embedded gorm.Model.
+func newResource(m gorm.Model) Resource {
+	r := Resource{
+		ID:        m.ID,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	if m.DeletedAt.Valid {
+		deletedAt := m.DeletedAt.Time
+		r.DeletedAt = &deletedAt
+	}
+	return r
+}
+
+// mapSlice converts a slice of models into their public representations. It
+// never returns nil so empty lists serialize as [] instead of null.
+func mapSlice[M any, R any](models []M, fn func(M) R) []R {
+	out := make([]R, 0, len(models))
+	for _, m := range models {
+		out = append(out, fn(m))
+	}
+	return out
+}
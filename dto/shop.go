@@ -0,0 +1,159 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// CreateShopRequest is the payload of POST /api/shop/signup: a shop and its owner.
+type CreateShopRequest struct {
+	Name  string             `json:"name" validate:"required,notblank,max=100"`
+	Email string             `json:"email" validate:"required,email,max=255"`
+	Owner CreateOwnerRequest `json:"owner" validate:"required"`
+}
+
+// CreateOwnerRequest describes the owner registered together with a shop.
+type CreateOwnerRequest struct {
+	Name     string `json:"name" validate:"required,notblank,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password,max=72"`
+}
+
+// ToModel maps the request onto a new Shop without an owner ID.
+func (r CreateShopRequest) ToModel() models.Shop {
+	return models.Shop{
+		Name:  r.Name,
+		Email: r.Email,
+	}
+}
+
+// ToModel maps the request onto a new ShopOwner. The password is left empty;
+// the caller stores its hash.
+func (r CreateOwnerRequest) ToModel() models.ShopOwner {
+	return models.ShopOwner{
+		Name:  r.Name,
+		Email: r.Email,
+	}
+}
+
+// UpdateShopRequest is the payload of PUT /api/shops/:id. Omitted fields are left unchanged.
+type UpdateShopRequest struct {
+	Name  *string `json:"name" validate:"omitempty,notblank,max=100"`
+	Email *string `json:"email" validate:"omitempty,email,max=255"`
+}
+
+// ApplyTo copies the provided fields onto an existing shop.
+func (r UpdateShopRequest) ApplyTo(shop *models.Shop) {
+	if r.Name != nil {
+		shop.Name = *r.Name
+	}
+	if r.Email != nil {
+		shop.Email = *r.Email
+	}
+}
+
+// OwnerResponse is the public representation of a ShopOwner.
+type OwnerResponse struct {
+	Resource
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// NewOwnerResponse builds the public representation of a shop owner.
+func NewOwnerResponse(owner models.ShopOwner) OwnerResponse {
+	return OwnerResponse{
+		Resource: newResource(owner.Model),
+		Name:     owner.Name,
+		Email:    owner.Email,
+	}
+}
+
+// ShopResponse is the public representation of a Shop. Relations are only
+// included when they were loaded.
+type ShopResponse struct {
+	Resource
+	Name        string              `json:"name"`
+	Email       string              `json:"email"`
+	OwnerID     uint                `json:"owner_id"`
+	Owner       *OwnerResponse      `json:"owner,omitempty"`
+	Employees   []EmployeeResponse  `json:"employees,omitempty"`
+	Inventories []InventoryResponse `json:"inventories,omitempty"`
+}
+
+// NewShopResponse builds the public representation of a shop.
+func NewShopResponse(shop models.Shop) ShopResponse {
+	resp := ShopResponse{
+		Resource: newResource(shop.Model),
+		Name:     shop.Name,
+		Email:    shop.Email,
+		OwnerID:  shop.OwnerID,
+	}
+	if shop.Owner.ID != 0 {
+		owner := NewOwnerResponse(shop.Owner)
+		resp.Owner = &owner
+	}
+	if len(shop.Employees) > 0 {
+		resp.Employees = NewEmployeeResponses(shop.Employees)
+	}
+	if len(shop.Inventories) > 0 {
+		resp.Inventories = NewInventoryResponses(shop.Inventories)
+	}
+	return resp
+}
+
+// NewShopResponses builds the public representation of a list of shops.
+func NewShopResponses(shops []models.Shop) []ShopResponse {
+	return mapSlice(shops, NewShopResponse)
+}
+
+// CreateOwnedShopRequest is the payload of POST /api/owners/me/shops, used by an
+// authenticated owner to open another shop.
+type CreateOwnedShopRequest struct {
+	Name  string `json:"name" validate:"required,notblank,max=100"`
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// ToModel maps the request onto a new Shop without an owner ID.
+func (r CreateOwnedShopRequest) ToModel() models.Shop {
+	return models.Shop{
+		Name:  r.Name,
+		Email: r.Email,
+	}
+}
+
+// TransferShopRequest is the payload of POST /api/shops/:id/transfers. The current
+// owner confirms the transfer by re-entering their password.
+type TransferShopRequest struct {
+	NewOwnerEmail string `json:"new_owner_email" validate:"required,email"`
+	Password      string `json:"password" validate:"required"`
+}
+
+// TransferResponse is the public representation of a ShopTransfer.
+type TransferResponse struct {
+	Resource
+	ShopID      uint       `json:"shop_id"`
+	FromOwnerID uint       `json:"from_owner_id"`
+	ToOwnerID   uint       `json:"to_owner_id"`
+	Status      string     `json:"status"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// NewTransferResponse builds the public representation of a shop transfer.
+func NewTransferResponse(transfer models.ShopTransfer) TransferResponse {
+	return TransferResponse{
+		Resource:    newResource(transfer.Model),
+		ShopID:      transfer.ShopID,
+		FromOwnerID: transfer.FromOwnerID,
+		ToOwnerID:   transfer.ToOwnerID,
+		Status:      transfer.Status,
+		ExpiresAt:   transfer.ExpiresAt,
+		RespondedAt: transfer.RespondedAt,
+	}
+}
+
+// NewTransferResponses builds the public representation of a list of shop transfers.
+func NewTransferResponses(transfers []models.ShopTransfer) []TransferResponse {
+	return mapSlice(transfers, NewTransferResponse)
+}
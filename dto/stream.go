@@ -0,0 +1,11 @@
+package dto
+
+// StreamQuery holds the parameters of GET /api/stream: the shop, or the
+// inventory, whose changes are streamed, and the ID of the last event received
+// to resume from. EventSource clients send the latter as the Last-Event-ID
+// header instead.
+type StreamQuery struct {
+	ShopID      uint `query:"shop_id" json:"shop_id" validate:"required_without=InventoryID"`
+	InventoryID uint `query:"inventory_id" json:"inventory_id" validate:"required_without=ShopID"`
+	LastEventID uint `query:"last_event_id" json:"last_event_id"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "time"
+
+// APIVersionResponse is an API version with the usage it got on the replica
+// answering, in the body of GET /api/admin/versions.
+type APIVersionResponse struct {
+	Version       string           `json:"version"`
+	DeprecatedAt  *time.Time       `json:"deprecated_at"`
+	SunsetAt      *time.Time       `json:"sunset_at"`
+	Requests      int64            `json:"requests"`
+	RequestsVia   map[string]int64 `json:"requests_via"` // By selection: path, header or default
+	LastRequestAt *time.Time       `json:"last_request_at"`
+}
+
+// TimeOrNil returns nil for the zero time, the null of the optional times of
+// the responses.
+func TimeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,179 @@
+package dto
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// Delivery log page sizes.
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// CreateWebhookRequest is the payload of POST /api/shops/:id/webhooks. A
+// secret is generated when none is given.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,eventtype"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	Active     *bool    `json:"active"` // Defaults to true
+}
+
+// ToModel maps the request onto a new Webhook. The secret is left to the caller.
+func (r CreateWebhookRequest) ToModel() models.Webhook {
+	return models.Webhook{
+		URL:        r.URL,
+		EventTypes: joinEventTypes(r.EventTypes),
+		Active:     r.Active == nil || *r.Active,
+	}
+}
+
+// UpdateWebhookRequest is the payload of PUT /api/shops/:id/webhooks/:webhookId.
+// Omitted fields are left unchanged; setting active to true re-enables a
+// webhook disabled after repeated failures.
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url" validate:"omitempty,http_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"omitempty,min=1,dive,eventtype"`
+	Secret     *string  `json:"secret" validate:"omitempty,min=16,max=255"`
+	Active     *bool    `json:"active"`
+}
+
+// ApplyTo copies the provided fields onto an existing webhook.
+func (r UpdateWebhookRequest) ApplyTo(webhook *models.Webhook) {
+	if r.URL != nil {
+		webhook.URL = *r.URL
+	}
+	if len(r.EventTypes) > 0 {
+		webhook.EventTypes = joinEventTypes(r.EventTypes)
+	}
+	if r.Secret != nil {
+		webhook.Secret = models.Secret(*r.Secret)
+	}
+	if r.Active != nil {
+		webhook.Active = *r.Active
+	}
+}
+
+// joinEventTypes stores event types sorted and without duplicates.
+func joinEventTypes(types []string) string {
+	types = slices.Clone(types)
+	slices.Sort(types)
+	return strings.Join(slices.Compact(types), ",")
+}
+
+// WebhookResponse is the public representation of a Webhook. The secret is
+// only shown once, when it is generated.
+type WebhookResponse struct {
+	Resource
+	ShopID              uint       `json:"shop_id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"event_types"`
+	Secret              string     `json:"secret,omitempty"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+}
+
+// NewWebhookResponse builds the public representation of a webhook.
+func NewWebhookResponse(webhook models.Webhook) WebhookResponse {
+	return WebhookResponse{
+		Resource:            newResource(webhook.Model),
+		ShopID:              webhook.ShopID,
+		URL:                 webhook.URL,
+		EventTypes:          webhook.EventTypeList(),
+		Active:              webhook.Active,
+		ConsecutiveFailures: webhook.ConsecutiveFailures,
+		DisabledAt:          webhook.DisabledAt,
+		DisabledReason:      webhook.DisabledReason,
+	}
+}
+
+// NewCreatedWebhookResponse builds the representation of a new webhook,
+// revealing its secret.
+func NewCreatedWebhookResponse(webhook models.Webhook) WebhookResponse {
+	response := NewWebhookResponse(webhook)
+	response.Secret = webhook.Secret.Reveal()
+	return response
+}
+
+// NewWebhookResponses builds the public representation of a list of webhooks.
+func NewWebhookResponses(webhooks []models.Webhook) []WebhookResponse {
+	return mapSlice(webhooks, NewWebhookResponse)
+}
+
+// WebhookDeliveryQuery pages through GET /api/shops/:id/webhooks/:webhookId/deliveries.
+// Deliveries are returned newest first; pass the id of the last one as
+// before_id to get the next page.
+type WebhookDeliveryQuery struct {
+	BeforeID uint `query:"before_id" json:"before_id"`
+	Limit    int  `query:"limit" json:"limit" validate:"omitempty,min=1,max=200"`
+}
+
+// PageLimit returns the page size, defaulting when none was given.
+func (q WebhookDeliveryQuery) PageLimit() int {
+	if q.Limit == 0 {
+		return defaultDeliveryLimit
+	}
+	return min(q.Limit, maxDeliveryLimit)
+}
+
+// WebhookDeliveryResponse is the public representation of a webhook delivery.
+type WebhookDeliveryResponse struct {
+	ID            uint            `json:"id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	WebhookID     uint            `json:"webhook_id"`
+	EventID       uint            `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	RedeliveryOf  *uint           `json:"redelivery_of"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at"` // Only while pending
+	LastAttemptAt *time.Time      `json:"last_attempt_at"`
+	ResponseCode  int             `json:"response_code"`
+	ResponseBody  string          `json:"response_body"`
+	Error         string          `json:"error"`
+	DurationMS    int64           `json:"duration_ms"`
+	FinishedAt    *time.Time      `json:"finished_at"`
+}
+
+// NewWebhookDeliveryResponse builds the public representation of a delivery.
+func NewWebhookDeliveryResponse(d models.WebhookDelivery) WebhookDeliveryResponse {
+	payload := json.RawMessage(d.Payload)
+	if !json.Valid(payload) {
+		payload = json.RawMessage("null")
+	}
+	response := WebhookDeliveryResponse{
+		ID:            d.ID,
+		CreatedAt:     d.CreatedAt,
+		WebhookID:     d.WebhookID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Payload:       payload,
+		RedeliveryOf:  d.RedeliveryOf,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		LastAttemptAt: d.LastAttemptAt,
+		ResponseCode:  d.ResponseCode,
+		ResponseBody:  d.ResponseBody,
+		Error:         d.Error,
+		DurationMS:    d.DurationMS,
+		FinishedAt:    d.FinishedAt,
+	}
+	if d.Status == models.DeliveryPending {
+		nextAttemptAt := d.NextAttemptAt
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	return response
+}
+
+// NewWebhookDeliveryResponses builds the public representation of a list of deliveries.
+func NewWebhookDeliveryResponses(deliveries []models.WebhookDelivery) []WebhookDeliveryResponse {
+	return mapSlice(deliveries, NewWebhookDeliveryResponse)
+}
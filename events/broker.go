@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Message is a record produced to a message broker topic.
+type Message struct {
+	Topic string `json:"topic"`
+	// Key routes the message to a partition; all events of an aggregate share
+	// it so partitioned brokers keep them in order.
+	Key     string            `json:"key"`
+	Headers map[string]string `json:"headers"`
+	Value   json.RawMessage   `json:"value"`
+}
+
+// Producer writes messages to a broker such as NATS JetStream or Kafka.
+// Produce returns once the broker acknowledged the message.
+type Producer interface {
+	Produce(ctx context.Context, msg Message) error
+}
+
+// BrokerSink publishes events to a broker, one topic per aggregate type.
+type BrokerSink struct {
+	producer    Producer
+	topicPrefix string
+}
+
+// NewBrokerSink creates a BrokerSink producing to "<topicPrefix><aggregate type>".
+func NewBrokerSink(producer Producer, topicPrefix string) *BrokerSink {
+	return &BrokerSink{producer: producer, topicPrefix: topicPrefix}
+}
+
+// Name identifies the sink in logs.
+func (s *BrokerSink) Name() string {
+	return "broker"
+}
+
+// Publish produces the event keyed by its aggregate.
+func (s *BrokerSink) Publish(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.producer.Produce(ctx, Message{
+		Topic: s.topicPrefix + event.AggregateType,
+		Key:   event.AggregateType + ":" + strconv.FormatUint(uint64(event.AggregateID), 10),
+		Headers: map[string]string{
+			HeaderEventID:   strconv.FormatUint(uint64(event.ID), 10),
+			HeaderEventType: event.Type,
+		},
+		Value: value,
+	})
+}
+
+// FileProducer is a local stand-in for a broker: it appends every message as a
+// line of JSON to a file, or to stdout.
+type FileProducer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFileProducer creates a FileProducer appending to path, or writing to
+// stdout when path is "-".
+func NewFileProducer(path string) (*FileProducer, error) {
+	if path == "-" {
+		return &FileProducer{w: os.Stdout}, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening the broker file: %w", err)
+	}
+	return &FileProducer{w: file}, nil
+}
+
+// Produce writes msg as one line.
+func (p *FileProducer) Produce(_ context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// AllTypes subscribes a handler to every event type.
+const AllTypes = "*"
+
+// Handler processes an event published on the Bus.
+type Handler func(ctx context.Context, event Event) error
+
+// Bus is the in-process sink: it hands every event to the handlers subscribed
+// to its type, one after the other.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[int]subscription
+	nextID int
+}
+
+type subscription struct {
+	eventType string
+	handler   Handler
+}
+
+// NewBus creates a Bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: map[int]subscription{}}
+}
+
+// Subscribe calls handler for every event of eventType, or of every type with
+// AllTypes, until the returned function is called.
+func (b *Bus) Subscribe(eventType string, handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = subscription{eventType: eventType, handler: handler}
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// Name identifies the bus in logs.
+func (b *Bus) Name() string {
+	return "bus"
+}
+
+// Publish calls the handlers subscribed to the event. A failing handler fails
+// the publication, so every handler must be idempotent.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	var handlers []Handler
+	for _, sub := range b.subs {
+		if sub.eventType == AllTypes || sub.eventType == event.Type {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+// Package events defines the domain events published by the API and the sinks
+// they are delivered to. Events are written to the transactional outbox by the
+// services and handed to a Sink by the outbox dispatcher, at least once and in
+// order per aggregate: sinks must tolerate duplicates, using the event ID.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Domain event types, named <aggregate>.<what happened>.
+const (
+	ShopCreated          = "shop.created"
+	ShopUpdated          = "shop.updated"
+	ShopOwnershipChanged = "shop.ownership_changed"
+	ShopDeleted          = "shop.deleted"
+	ShopRestored         = "shop.restored"
+	ShopPurged           = "shop.purged"
+	ShopOwnerRegistered  = "shop_owner.registered"
+	TransferRequested    = "shop_transfer.requested"
+	TransferAccepted     = "shop_transfer.accepted"
+	TransferDeclined     = "shop_transfer.declined"
+	TransferCancelled    = "shop_transfer.cancelled"
+	TransferExpired      = "shop_transfer.expired"
+	EmployeeCreated      = "shop_employee.created"
+	EmployeeUpdated      = "shop_employee.updated"
+	EmployeeDeleted      = "shop_employee.deleted"
+	EmployeeRestored     = "shop_employee.restored"
+	EmployeePurged       = "shop_employee.purged"
+	CustomerRegistered   = "customer.registered"
+	InventoryCreated     = "inventory.created"
+	InventoryUpdated     = "inventory.updated"
+	InventoryDeleted     = "inventory.deleted"
+	InventoryRestored    = "inventory.restored"
+	InventoryPurged      = "inventory.purged"
+	ItemCreated          = "item.created"
+	ItemUpdated          = "item.updated"
+	ItemQuantityChanged  = "item.quantity_changed"
+	ItemOutOfStock       = "item.out_of_stock"
+	ItemDeleted          = "item.deleted"
+	ItemRestored         = "item.restored"
+	ItemPurged           = "item.purged"
+)
+
+// Types lists every domain event type.
+var Types = []string{
+	ShopCreated, ShopUpdated, ShopOwnershipChanged, ShopDeleted, ShopRestored, ShopPurged,
+	ShopOwnerRegistered,
+	TransferRequested, TransferAccepted, TransferDeclined, TransferCancelled, TransferExpired,
+	EmployeeCreated, EmployeeUpdated, EmployeeDeleted, EmployeeRestored, EmployeePurged,
+	CustomerRegistered,
+	InventoryCreated, InventoryUpdated, InventoryDeleted, InventoryRestored, InventoryPurged,
+	ItemCreated, ItemUpdated, ItemQuantityChanged, ItemOutOfStock, ItemDeleted, ItemRestored, ItemPurged,
+}
+
+// Event is the envelope of a domain event as delivered to sinks.
+type Event struct {
+	ID            uint      `json:"id"` // Unique and increasing, for deduplication
+	Type          string    `json:"type"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateID   uint      `json:"aggregate_id"`
+	ShopID        *uint     `json:"shop_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	// Data holds the changed fields ("changes") and the state of the aggregate
+	// after the change ("state", null once deleted).
+	Data json.RawMessage `json:"data"`
+}
+
+// Sink receives published events.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Publish delivers an event. An error makes the dispatcher retry it later,
+	// holding back the following events of the same aggregate.
+	Publish(ctx context.Context, event Event) error
+}
+
+// Fanout publishes every event to each of its sinks. An event failing on one
+// sink is retried on all of them, so each sink sees it at least once.
+type Fanout []Sink
+
+// Name lists the names of the sinks.
+func (f Fanout) Name() string {
+	names := make([]string, len(f))
+	for i, sink := range f {
+		names[i] = sink.Name()
+	}
+	return "fanout(" + strings.Join(names, ", ") + ")"
+}
+
+// Publish delivers event to every sink, even if an earlier one fails.
+func (f Fanout) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, sink := range f {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,14 @@
+package events
+
+import "context"
+
+// Relay carries short messages between the replicas of the API, such as
+// Postgres LISTEN/NOTIFY. Messages sent while a replica isn't listening are
+// lost to it.
+type Relay interface {
+	// Notify sends payload to every listening replica, this one included.
+	Notify(ctx context.Context, payload string) error
+	// Listen calls handle with every payload notified by any replica until ctx
+	// is cancelled or the relay fails.
+	Listen(ctx context.Context, handle func(payload string)) error
+}
@@ -5,13 +5,14 @@ import (
 	"log"
 	"os"
 
-	"github.com/mohamedhabas11/golang-api/database"
 	"github.com/mohamedhabas11/golang-api/models"
 	"github.com/mohamedhabas11/golang-api/utils"
 	"gorm.io/gorm"
 )
 
-func SeedDatabase(seedFile string) {
+// SeedDatabase upserts the shops, owners, employees, inventories, items and
+// customers described in a JSON seed file.
+func SeedDatabase(db *gorm.DB, seedFile string) {
 	// Read seed data from JSON file.
 	file, err := os.ReadFile(seedFile)
 	if err != nil {
@@ -41,19 +42,19 @@ func SeedDatabase(seedFile string) {
 	for _, shopData := range seedData.Shops {
 		// --- Seed Shop Owner ---
 		// Always hash the owner's password.
-		hashedOwnerPass, err := utils.HashPassword(shopData.Owner.Password)
+		hashedOwnerPass, err := utils.HashPassword(shopData.Owner.Password.Reveal())
 		if err != nil {
 			log.Printf("Error hashing password for shop owner %s: %v", shopData.Owner.Email, err)
 			continue
 		}
-		shopData.Owner.Password = hashedOwnerPass
+		shopData.Owner.Password = models.Secret(hashedOwnerPass)
 
 		// Use FirstOrCreate to ensure no duplicates
 		var existingOwner models.ShopOwner
-		result := database.DB.Where("email = ?", shopData.Owner.Email).First(&existingOwner)
+		result := db.Where("email = ?", shopData.Owner.Email).First(&existingOwner)
 		if result.Error == gorm.ErrRecordNotFound {
 			// If owner doesn't exist, create
-			if err := database.DB.Create(&shopData.Owner).Error; err != nil {
+			if err := db.Create(&shopData.Owner).Error; err != nil {
 				log.Printf("Error seeding shop owner %s: %v", shopData.Owner.Email, err)
 				continue
 			}
@@ -63,7 +64,7 @@ func SeedDatabase(seedFile string) {
 		} else {
 			// If owner exists, update
 			shopData.Owner.ID = existingOwner.ID
-			if err := database.DB.Save(&shopData.Owner).Error; err != nil {
+			if err := db.Save(&shopData.Owner).Error; err != nil {
 				log.Printf("Error updating shop owner %s: %v", shopData.Owner.Email, err)
 				continue
 			}
@@ -77,10 +78,10 @@ func SeedDatabase(seedFile string) {
 			OwnerID: owner.ID,
 		}
 		var existingShop models.Shop
-		result = database.DB.Where("email = ?", shopData.Email).First(&existingShop)
+		result = db.Where("email = ?", shopData.Email).First(&existingShop)
 		if result.Error == gorm.ErrRecordNotFound {
 			// If shop doesn't exist, create
-			if err := database.DB.Create(&shop).Error; err != nil {
+			if err := db.Create(&shop).Error; err != nil {
 				log.Printf("Error seeding shop %s: %v", shopData.Name, err)
 				continue
 			}
@@ -90,7 +91,7 @@ func SeedDatabase(seedFile string) {
 		} else {
 			// If shop exists, update
 			shop.ID = existingShop.ID
-			if err := database.DB.Save(&shop).Error; err != nil {
+			if err := db.Save(&shop).Error; err != nil {
 				log.Printf("Error updating shop %s: %v", shopData.Name, err)
 				continue
 			}
@@ -99,19 +100,19 @@ func SeedDatabase(seedFile string) {
 		// --- Seed Shop Employees ---
 		for _, empData := range shopData.Employees {
 			// Always hash the employee's password.
-			hashedEmpPass, err := utils.HashPassword(empData.Password)
+			hashedEmpPass, err := utils.HashPassword(empData.Password.Reveal())
 			if err != nil {
 				log.Printf("Error hashing password for employee %s: %v", empData.Email, err)
 				continue
 			}
-			empData.Password = hashedEmpPass
+			empData.Password = models.Secret(hashedEmpPass)
 			empData.ShopID = shop.ID
 
 			var existingEmployee models.ShopEmployee
-			result := database.DB.Where("email = ? AND shop_id = ?", empData.Email, shop.ID).First(&existingEmployee)
+			result := db.Where("email = ? AND shop_id = ?", empData.Email, shop.ID).First(&existingEmployee)
 			if result.Error == gorm.ErrRecordNotFound {
 				// If employee doesn't exist, create
-				if err := database.DB.Create(&empData).Error; err != nil {
+				if err := db.Create(&empData).Error; err != nil {
 					log.Printf("Error seeding employee %s: %v", empData.Email, err)
 					continue
 				}
@@ -121,7 +122,7 @@ func SeedDatabase(seedFile string) {
 			} else {
 				// If employee exists, update
 				empData.ID = existingEmployee.ID
-				if err := database.DB.Save(&empData).Error; err != nil {
+				if err := db.Save(&empData).Error; err != nil {
 					log.Printf("Error updating employee %s: %v", empData.Email, err)
 					continue
 				}
@@ -136,10 +137,10 @@ func SeedDatabase(seedFile string) {
 				ShopID:        shop.ID,
 			}
 			var existingInventory models.Inventory
-			result := database.DB.Where("inventory_name = ? AND shop_id = ?", invData.InventoryName, shop.ID).First(&existingInventory)
+			result := db.Where("inventory_name = ? AND shop_id = ?", invData.InventoryName, shop.ID).First(&existingInventory)
 			if result.Error == gorm.ErrRecordNotFound {
 				// If inventory doesn't exist, create
-				if err := database.DB.Create(&inv).Error; err != nil {
+				if err := db.Create(&inv).Error; err != nil {
 					log.Printf("Error seeding inventory %s: %v", invData.InventoryName, err)
 					continue
 				}
@@ -149,7 +150,7 @@ func SeedDatabase(seedFile string) {
 			} else {
 				// If inventory exists, update
 				inv.ID = existingInventory.ID
-				if err := database.DB.Save(&inv).Error; err != nil {
+				if err := db.Save(&inv).Error; err != nil {
 					log.Printf("Error updating inventory %s: %v", invData.InventoryName, err)
 					continue
 				}
@@ -159,10 +160,10 @@ func SeedDatabase(seedFile string) {
 			for _, itemData := range invData.Items {
 				itemData.InventoryID = inv.ID
 				var existingItem models.Item
-				result := database.DB.Where("name = ? AND inventory_id = ?", itemData.Name, inv.ID).First(&existingItem)
+				result := db.Where("name = ? AND inventory_id = ?", itemData.Name, inv.ID).First(&existingItem)
 				if result.Error == gorm.ErrRecordNotFound {
 					// If item doesn't exist, create
-					if err := database.DB.Create(&itemData).Error; err != nil {
+					if err := db.Create(&itemData).Error; err != nil {
 						log.Printf("Error seeding item %s: %v", itemData.Name, err)
 						continue
 					}
@@ -172,7 +173,7 @@ func SeedDatabase(seedFile string) {
 				} else {
 					// If item exists, update
 					itemData.ID = existingItem.ID
-					if err := database.DB.Save(&itemData).Error; err != nil {
+					if err := db.Save(&itemData).Error; err != nil {
 						log.Printf("Error updating item %s: %v", itemData.Name, err)
 						continue
 					}
@@ -184,18 +185,18 @@ func SeedDatabase(seedFile string) {
 	// --- Seed Standalone Customers ---
 	for _, custData := range seedData.Customers {
 		// Always hash the customer's password.
-		hashedCustPass, err := utils.HashPassword(custData.Password)
+		hashedCustPass, err := utils.HashPassword(custData.Password.Reveal())
 		if err != nil {
 			log.Printf("Error hashing password for customer %s: %v", custData.Email, err)
 			continue
 		}
-		custData.Password = hashedCustPass
+		custData.Password = models.Secret(hashedCustPass)
 
 		var existingCustomer models.Customer
-		result := database.DB.Where("email = ?", custData.Email).First(&existingCustomer)
+		result := db.Where("email = ?", custData.Email).First(&existingCustomer)
 		if result.Error == gorm.ErrRecordNotFound {
 			// If customer doesn't exist, create
-			if err := database.DB.Create(&custData).Error; err != nil {
+			if err := db.Create(&custData).Error; err != nil {
 				log.Printf("Error seeding customer %s: %v", custData.Email, err)
 				continue
 			}
@@ -205,7 +206,7 @@ func SeedDatabase(seedFile string) {
 		} else {
 			// If customer exists, update
 			custData.ID = existingCustomer.ID
-			if err := database.DB.Save(&custData).Error; err != nil {
+			if err := db.Save(&custData).Error; err != nil {
 				log.Printf("Error updating customer %s: %v", custData.Email, err)
 				continue
 			}
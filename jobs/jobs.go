@@ -0,0 +1,75 @@
+// Package jobs defines the building blocks of the background job queue: typed
+// handlers looked up by job type, errors that must not be retried, and the cron
+// schedules enqueuing jobs periodically. Jobs run at least once, since a worker
+// dying mid-job gets it run again, so handlers must be idempotent.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Handler runs a job from its JSON payload.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Registry maps job types to their handler. Handlers are registered before the
+// workers start and never changed afterwards.
+type Registry struct {
+	handlers map[string]Handler
+}
+
+// NewRegistry creates a Registry without handlers.
+func NewRegistry() *Registry {
+	return &Registry{handlers: map[string]Handler{}}
+}
+
+// Register makes handle run the jobs of jobType, with their payload decoded
+// into a T. A payload that can't be decoded fails the job permanently. It
+// panics if jobType already has a handler.
+func Register[T any](r *Registry, jobType string, handle func(ctx context.Context, payload T) error) {
+	if _, ok := r.handlers[jobType]; ok {
+		panic(fmt.Sprintf("jobs: handler of %s registered twice", jobType))
+	}
+	r.handlers[jobType] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return Permanent(fmt.Errorf("decoding the payload of %s: %w", jobType, err))
+		}
+		return handle(ctx, payload)
+	}
+}
+
+// Handler returns the handler of jobType.
+func (r *Registry) Handler(jobType string) (Handler, bool) {
+	handler, ok := r.handlers[jobType]
+	return handler, ok
+}
+
+// Types returns the registered job types, sorted.
+func (r *Registry) Types() []string {
+	return slices.Sorted(maps.Keys(r.handlers))
+}
+
+// permanentError marks a failure that retrying won't fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the job goes to the dead letters right away
+// instead of being retried.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped by Permanent.
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
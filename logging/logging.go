@@ -0,0 +1,90 @@
+// Package logging sets up the structured logs of the API: records written by
+// log/slog, at a level adjustable while running, carrying the request ID and
+// trace of their context, with the sensitive fields redacted.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// level is the minimum level of the records, shared by every logger.
+var level = new(slog.LevelVar)
+
+// Setup makes slog write records to w in format, "json" or "text", from the
+// level named levelName, "debug", "info", "warn" or "error". The log package
+// writes through it too, at info level.
+func Setup(w io.Writer, format, levelName string) error {
+	if _, err := SetLevel(levelName); err != nil {
+		return err
+	}
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format %q, expected json or text", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// Level returns the minimum level of the records.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes the minimum level of the records to the one named name,
+// info for "".
+func SetLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if name != "" {
+		if err := l.UnmarshalText([]byte(name)); err != nil {
+			return l, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+		}
+	}
+	level.Set(l)
+	return l, nil
+}
+
+// attrsKey is the context key of the attributes added by WithAttrs.
+type attrsKey struct{}
+
+// WithAttrs returns a copy of ctx whose records carry attrs, after those
+// already added.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return context.WithValue(ctx, attrsKey{}, append(existing[:len(existing):len(existing)], attrs...))
+}
+
+// contextHandler adds to the records the attributes of their context and
+// the IDs of its trace.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted replaces the values of the sensitive fields.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the parts of the attribute keys whose values never reach
+// the logs.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie", "signature", "api_key"}
+
+// redact hides the values of the sensitive attributes and masks the email
+// addresses, keeping their domain.
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+	if key == "email" || strings.HasSuffix(key, "_email") {
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	}
+	return a
+}
+
+// MaskEmail keeps the first letter and the domain of an email address, such
+// as j***@example.com.
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return Redacted
+	}
+	first, _ := utf8.DecodeRuneInString(local)
+	return string(first) + "***@" + domain
+}
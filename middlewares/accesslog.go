@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// quietPaths are polled by probes and scrapers: their successful requests
+// are only logged at debug level, and the 503 of a replica not ready, an
+// expected answer, at info level.
+var quietPaths = []string{"/health", "/livez", "/readyz", "/startupz", "/metrics"}
+
+// AccessLog logs every request once answered, with its status, duration and
+// size, streams counting until their headers are sent: server errors as
+// errors, the rest at info level. The path is logged
+// without its query string, which may hold tokens. It must run after
+// RequestIDMiddleware.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		method, path := strings.Clone(c.Method()), strings.Clone(c.Path()) // Before any rewrite
+
+		// Render errors first, to log the status of the problem details.
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		switch {
+		case status == fiber.StatusServiceUnavailable && quiet(path):
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status < fiber.StatusBadRequest && quiet(path):
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+			slog.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+		}
+		// Reading the body of a stream would consume it.
+		if !c.Response().IsBodyStream() {
+			attrs = append(attrs, slog.Int("bytes", len(c.Response().Body())))
+		}
+		slog.LogAttrs(c.UserContext(), level, "Request", attrs...)
+		return nil
+	}
+}
+
+// quiet reports whether path is one of quietPaths or under it.
+func quiet(path string) bool {
+	for _, quietPath := range quietPaths {
+		if path == quietPath || strings.HasPrefix(path, quietPath+"/") {
+			return true
+		}
+	}
+	return false
+}
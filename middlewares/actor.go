@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/services"
+)
+
+// ActorContext attributes the mutations of a request to its caller in the
+// audit log: the principal of its token, on the routes that don't require one
+// too, or anonymous without a valid token. It must run after
+// RequestIDMiddleware.
+func ActorContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := services.Actor{
+			Type:      models.ActorAnonymous,
+			RequestID: RequestID(c),
+			IP:        c.IP(),
+		}
+		if principal, err := parseToken(c); err == nil {
+			actor.Type, actor.ID = principal.Role, principal.UserID
+		}
+		c.SetUserContext(services.WithActor(c.UserContext(), actor))
+		return c.Next()
+	}
+}
+
+// setActorPrincipal attributes the rest of the request to principal.
+func setActorPrincipal(c *fiber.Ctx, principal Principal) {
+	actor := services.ActorFrom(c.UserContext())
+	if actor.Type == models.ActorSystem {
+		// ActorContext didn't run, keep what we know.
+		actor = services.Actor{RequestID: RequestID(c), IP: c.IP()}
+	}
+	actor.Type, actor.ID = principal.Role, principal.UserID
+	c.SetUserContext(services.WithActor(c.UserContext(), actor))
+}
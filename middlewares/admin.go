@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// HeaderAdminToken carries the operator token of the admin endpoints.
+const HeaderAdminToken = "X-Admin-Token"
+
+// adminToken is the operator token, set by SetAdminToken at startup.
+var adminToken string
+
+// SetAdminToken sets the operator token of the admin endpoints.
+func SetAdminToken(token string) {
+	adminToken = token
+}
+
+// RequireAdmin lets through the requests carrying the operator token set by
+// SetAdminToken. Without a token the admin endpoints don't exist.
+func RequireAdmin(c *fiber.Ctx) error {
+	token := adminToken
+	if token == "" {
+		return utils.ErrNotFound("The requested resource does not exist.")
+	}
+
+	given := c.Get(HeaderAdminToken)
+	if given == "" {
+		return utils.ErrUnauthorized("Unauthorized: No admin token provided")
+	}
+	if !isAdmin(c) {
+		return utils.ErrUnauthorized("Unauthorized: Invalid admin token")
+	}
+	return c.Next()
+}
+
+// isAdmin reports whether the request carries the operator token.
+func isAdmin(c *fiber.Ctx) bool {
+	given := c.Get(HeaderAdminToken)
+	return adminToken != "" && subtle.ConstantTimeCompare([]byte(given), []byte(adminToken)) == 1
+}
@@ -1,35 +1,114 @@
 package middlewares
 
 import (
-	"os"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Roles carried in the "role" claim of issued tokens.
+const (
+	RoleCustomer  = "customer"
+	RoleShopOwner = "shop_owner"
 )
 
+// principalKey is the fiber.Ctx locals key holding the authenticated Principal.
+const principalKey = "principal"
+
+// Principal identifies the authenticated caller of a request.
+type Principal struct {
+	UserID uint
+	Email  string
+	Role   string
+}
+
+// CurrentPrincipal returns the principal set by RequireAuth, if any.
+func CurrentPrincipal(c *fiber.Ctx) (Principal, bool) {
+	principal, ok := c.Locals(principalKey).(Principal)
+	return principal, ok
+}
+
 // RequireAuth checks for a valid JWT token
 func RequireAuth(c *fiber.Ctx) error {
-	tokenString := c.Cookies("jwt_token") // Try to get token from cookies
+	principal, err := parseToken(c)
+	if err != nil {
+		return err
+	}
+
+	// Expose the caller to the following handlers
+	c.Locals(principalKey, principal)
+	setActorPrincipal(c, principal)
+
+	// Token is valid, allow the request to proceed
+	return c.Next()
+}
+
+// parseToken returns the principal of the JWT token of the request.
+func parseToken(c *fiber.Ctx) (Principal, error) {
+	tokenString := c.Cookies(AuthCookie) // Try to get token from cookies
 	if tokenString == "" {
-		tokenString = c.Get("Authorization") // Or from Authorization header
+		// Or from the Authorization header, with or without the Bearer scheme
+		tokenString = strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
 		if tokenString == "" {
-			return c.Status(fiber.StatusUnauthorized).SendString("Unauthorized: No token provided")
+			return Principal{}, utils.ErrUnauthorized("Unauthorized: No token provided")
 		}
 	}
 
 	// Parse and validate the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		// ensure the signing method is valid
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(jwtSecret) == 0 {
 			return nil, fiber.ErrUnauthorized
 		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		return jwtSecret, nil
 	})
 
 	if err != nil || !token.Valid {
-		return c.Status(fiber.StatusUnauthorized).SendString("Unauthorized: Invalid token")
+		return Principal{}, utils.ErrUnauthorized("Unauthorized: Invalid token")
 	}
 
-	// Token is valid, allow the request to proceed
-	return c.Next()
+	userID, _ := claims["user_id"].(float64)
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+	return Principal{UserID: uint(userID), Email: email, Role: role}, nil
+}
+
+// RequireAuthWhen runs RequireAuth on the requests when reports true for,
+// only letting through the principals with one of roles; the other requests
+// go through unauthenticated.
+func RequireAuthWhen(when func(c *fiber.Ctx) bool, roles ...string) fiber.Handler {
+	requireRole := RequireRole(roles...)
+	return func(c *fiber.Ctx) error {
+		if !when(c) {
+			return c.Next()
+		}
+		principal, err := parseToken(c)
+		if err != nil {
+			return err
+		}
+		c.Locals(principalKey, principal)
+		setActorPrincipal(c, principal)
+		return requireRole(c)
+	}
+}
+
+// RequireRole only lets authenticated principals with one of the given roles
+// through. It must run after RequireAuth.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := CurrentPrincipal(c)
+		if !ok {
+			return utils.ErrUnauthorized("Unauthorized: No token provided")
+		}
+		for _, role := range roles {
+			if principal.Role == role {
+				return c.Next()
+			}
+		}
+		return utils.ErrForbidden("You are not allowed to access this resource")
+	}
 }
@@ -0,0 +1,135 @@
+package middlewares
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/database"
+)
+
+// HeaderCache tells whether a response came from the server cache, HIT, or
+// was rendered for the request, MISS.
+const HeaderCache = "X-Cache"
+
+// cacheTagsKey is the Locals key holding the tags of a cacheable response.
+const cacheTagsKey = "cacheTags"
+
+// responseCache is the server cache of the responses, set by SetResponseCache
+// at startup; nil disables it.
+var (
+	responseCache    cache.Backend
+	responseCacheTTL time.Duration
+)
+
+// SetResponseCache stores the cacheable responses in backend for ttl, nil
+// disabling the server cache.
+func SetResponseCache(backend cache.Backend, ttl time.Duration) {
+	responseCache, responseCacheTTL = backend, ttl
+}
+
+// CacheControl sets the Cache-Control header of the responses to policy;
+// handlers may replace it.
+func CacheControl(policy string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, policy)
+		return c.Next()
+	}
+}
+
+// NotModified reports whether the client's copy of the response is current:
+// whether the If-None-Match header lists etag, or * for any representation.
+// Entity tags are compared weakly, as RFC 9110 requires for If-None-Match.
+func NotModified(c *fiber.Ctx, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(c.Get(fiber.HeaderIfNoneMatch), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheResponse marks the 200 response of the request cacheable by
+// CacheResponses until one of tags is invalidated, see cache.Tag.
+func CacheResponse(c *fiber.Ctx, tags ...string) {
+	c.Locals(cacheTagsKey, tags)
+}
+
+// CacheResponses serves the GET requests from the server cache, keyed by path
+// and query, answering 304 when the client's copy is current. On a miss the
+// response is stored when its handler marked it with CacheResponse. It is
+// meant for the responses that are the same for every client.
+//
+// Misses read from the primary: a replica lagging behind a write already
+// invalidated would have its stale rows cached for every client.
+func CacheResponses(c *fiber.Ctx) error {
+	if responseCache == nil || (c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead) {
+		return c.Next()
+	}
+
+	key := c.Path() + "?" + string(c.Request().URI().QueryString())
+	entry, found, err := responseCache.Get(c.UserContext(), key)
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "Response cache unavailable", "error", err)
+	}
+	if found {
+		c.Set(HeaderCache, "HIT")
+		c.Set(fiber.HeaderETag, entry.ETag)
+		if NotModified(c, entry.ETag) {
+			return c.Status(fiber.StatusNotModified).Send(nil)
+		}
+		c.Set(fiber.HeaderContentType, entry.ContentType)
+		return c.Status(fiber.StatusOK).Send(entry.Body)
+	}
+
+	c.Set(HeaderCache, "MISS")
+	c.SetUserContext(database.WithPrimaryReads(c.UserContext()))
+	created := time.Now()
+	if err := c.Next(); err != nil {
+		return err
+	}
+	tags, ok := c.Locals(cacheTagsKey).([]string)
+	if !ok || c.Method() != fiber.MethodGet || c.Response().StatusCode() != fiber.StatusOK {
+		return nil
+	}
+	entry = cache.Entry{
+		Body:        bytes.Clone(c.Response().Body()),
+		ContentType: string(c.Response().Header.ContentType()),
+		ETag:        string(c.Response().Header.Peek(fiber.HeaderETag)),
+		Tags:        tags,
+		Created:     created,
+		Expires:     created.Add(responseCacheTTL),
+	}
+	if err := responseCache.Set(c.UserContext(), key, entry); err != nil {
+		slog.WarnContext(c.UserContext(), "Failed to cache the response", "error", err)
+	}
+	return nil
+}
+
+// InvalidateCache drops the cached responses showing the data changed by the
+// mutations of the request, as noted by the services with cache.Changed, once
+// it is answered and its transactions committed.
+func InvalidateCache(c *fiber.Ctx) error {
+	switch {
+	case responseCache == nil:
+		return c.Next()
+	case c.Method() == fiber.MethodGet, c.Method() == fiber.MethodHead, c.Method() == fiber.MethodOptions:
+		return c.Next()
+	}
+
+	ctx, changes := cache.Track(c.UserContext())
+	c.SetUserContext(ctx)
+	err := c.Next()
+	if tags := changes.Tags(); len(tags) > 0 {
+		if err := responseCache.Invalidate(ctx, tags...); err != nil {
+			slog.ErrorContext(ctx, "Failed to invalidate the cached responses", "tags", tags, "error", err)
+		}
+	}
+	return err
+}
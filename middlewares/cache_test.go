@@ -0,0 +1,43 @@
+package middlewares_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/database"
+	"github.com/mohamedhabas11/golang-api/middlewares"
+)
+
+// TestCachedMissesReadFromPrimary checks that the reads of a response the
+// server may cache stay on the primary, while the other reads may go to a
+// replica.
+func TestCachedMissesReadFromPrimary(t *testing.T) {
+	middlewares.SetResponseCache(cache.NewMemory(10), time.Hour)
+	t.Cleanup(func() { middlewares.SetResponseCache(nil, 0) })
+
+	replicaReads := map[string]bool{}
+	handler := func(c *fiber.Ctx) error {
+		replicaReads[c.Path()] = database.ReplicaReadsAllowed(c.UserContext())
+		middlewares.CacheResponse(c, cache.ListTag("thing"))
+		return c.SendString("things")
+	}
+	app := fiber.New()
+	app.Use(middlewares.ReadRouting(time.Minute))
+	app.Get("/cached", middlewares.CacheResponses, handler)
+	app.Get("/uncached", handler)
+
+	for path, want := range map[string]bool{"/cached": false, "/uncached": true} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, ok := replicaReads[path]; !ok || got != want {
+			t.Errorf("GET %s: got replica reads %v, want %v", path, got, want)
+		}
+	}
+}
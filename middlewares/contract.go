@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/openapi"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// ValidateResponses checks every response against the OpenAPI document of
+// the API version that served it, returned by doc, the latest for "": the
+// unversioned routes are part of every version. A response diverging from
+// the contract is logged and replaced by a 500, so that end-to-end runs fail
+// on it. Streamed bodies and the routes outside the document, such as the
+// catch-all, aren't checked. It is meant for development and CI, not
+// production.
+func ValidateResponses(doc func(version string) *openapi.Document) fiber.Handler {
+	isRoute := routeChecker()
+
+	return func(c *fiber.Ctx) error {
+		// Render errors first: the problem details are part of the contract.
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		route := c.Route()
+		response := c.Response()
+		if !isRoute(c) || c.Method() == fiber.MethodHead || response.IsBodyStream() ||
+			response.StatusCode() == fiber.StatusSwitchingProtocols {
+			return nil
+		}
+		path := openapi.PathTemplate(route.Path)
+		contentType := string(response.Header.ContentType())
+		err := doc(APIVersionOf(c).Name).ValidateResponse(route.Method, path, response.StatusCode(), contentType, response.Body())
+		if err == nil {
+			return nil
+		}
+
+		slog.ErrorContext(c.UserContext(), "Response broke the API contract", "method", c.Method(), "path", c.Path(),
+			"operation", route.Method+" "+path, "status", response.StatusCode(), "error", err)
+		response.ResetBody()
+		response.Header.Del(fiber.HeaderContentType)
+		return ErrorHandler(c, utils.ErrInternal("The response does not match the API contract"))
+	}
+}
+
+// routeChecker returns a function reporting whether the request was served by
+// a route rather than by a middleware of Use, which Fiber reports with the
+// methods it applies to. The routes are listed on the first request, once
+// every route is registered.
+func routeChecker() func(c *fiber.Ctx) bool {
+	var routes map[uintptr]bool
+	var once sync.Once
+	return func(c *fiber.Ctx) bool {
+		once.Do(func() {
+			routes = map[uintptr]bool{}
+			for _, route := range c.App().GetRoutes(true) {
+				routes[reflect.ValueOf(route.Handlers).Pointer()] = true
+			}
+		})
+		return routes[reflect.ValueOf(c.Route().Handlers).Pointer()]
+	}
+}
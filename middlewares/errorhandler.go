@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// MIMEProblemJSON is the media type of RFC 7807 problem details responses.
+const MIMEProblemJSON = "application/problem+json"
+
+// ErrorHandler is the central Fiber error handler. It turns every error returned
+// by a handler into an RFC 7807 problem+json response and makes sure internal
+// (e.g. database) error messages never reach the client.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	apiErr := toAPIError(err)
+
+	// Attach request metadata so clients can reference the failing call.
+	apiErr.Instance = c.OriginalURL()
+	apiErr.RequestID = RequestID(c)
+
+	if apiErr.Status >= fiber.StatusInternalServerError {
+		slog.ErrorContext(c.UserContext(), "Request failed", "method", c.Method(), "path", c.Path(), "status", apiErr.Status, "error", err)
+	}
+
+	return c.Status(apiErr.Status).JSON(apiErr, MIMEProblemJSON)
+}
+
+// toAPIError maps known error types onto an APIError.
+func toAPIError(err error) *utils.APIError {
+	var apiErr *utils.APIError
+	if errors.As(err, &apiErr) {
+		// Copy so request metadata doesn't leak between requests sharing an error value.
+		copied := *apiErr
+		return &copied
+	}
+
+	var fiberErr *fiber.Error
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		return utils.ErrNotFound("The requested resource does not exist")
+	case errors.Is(err, repositories.ErrDuplicate):
+		return utils.ErrConflict("A resource with the same unique value already exists")
+	case errors.Is(err, repositories.ErrForeignKey):
+		return utils.ErrConflict("The operation references a resource that does not exist or is still in use")
+	case errors.Is(err, context.DeadlineExceeded):
+		return utils.ErrUnavailable("The request took too long to complete, please retry")
+	case errors.As(err, &fiberErr):
+		return utils.NewAPIError(fiberErr.Code, codeForStatus(fiberErr.Code), fiberErr.Message)
+	default:
+		return utils.ErrInternal("An unexpected error occurred")
+	}
+}
+
+// codeForStatus returns the generic error code for an HTTP status.
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return utils.CodeBadRequest
+	case fiber.StatusUnauthorized:
+		return utils.CodeUnauthorized
+	case fiber.StatusForbidden:
+		return utils.CodeForbidden
+	case fiber.StatusNotFound:
+		return utils.CodeNotFound
+	case fiber.StatusMethodNotAllowed:
+		return utils.CodeMethodNotAllowed
+	case fiber.StatusRequestTimeout:
+		return utils.CodeRequestTimeout
+	case fiber.StatusConflict:
+		return utils.CodeConflict
+	case fiber.StatusUnsupportedMediaType:
+		return utils.CodeUnsupportedMediaType
+	case fiber.StatusRequestEntityTooLarge:
+		return utils.CodePayloadTooLarge
+	case fiber.StatusUnprocessableEntity:
+		return utils.CodeValidationFailed
+	case fiber.StatusTooManyRequests:
+		return utils.CodeRateLimited
+	case fiber.StatusServiceUnavailable:
+		return utils.CodeUnavailable
+	}
+	if status >= fiber.StatusInternalServerError {
+		return utils.CodeInternal
+	}
+	return utils.CodeBadRequest
+}
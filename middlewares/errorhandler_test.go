@@ -0,0 +1,51 @@
+package middlewares_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/middlewares"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// TestErrorHandlerCodesFiberErrors checks the problem code of the errors of
+// Fiber, answered with their status.
+func TestErrorHandlerCodesFiberErrors(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: middlewares.ErrorHandler})
+	app.Get("/status/:status", func(c *fiber.Ctx) error {
+		status, _ := strconv.Atoi(c.Params("status"))
+		return fiber.NewError(status)
+	})
+
+	for _, test := range []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{fiber.MethodGet, "/status/400", fiber.StatusBadRequest, utils.CodeBadRequest},
+		{fiber.MethodPost, "/status/400", fiber.StatusMethodNotAllowed, utils.CodeMethodNotAllowed},
+		{fiber.MethodGet, "/status/405", fiber.StatusMethodNotAllowed, utils.CodeMethodNotAllowed},
+		{fiber.MethodGet, "/status/408", fiber.StatusRequestTimeout, utils.CodeRequestTimeout},
+		{fiber.MethodGet, "/status/429", fiber.StatusTooManyRequests, utils.CodeRateLimited},
+		{fiber.MethodGet, "/status/418", fiber.StatusTeapot, utils.CodeBadRequest},
+		{fiber.MethodGet, "/status/502", fiber.StatusBadGateway, utils.CodeInternal},
+	} {
+		resp, err := app.Test(httptest.NewRequest(test.method, test.path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var problem utils.APIError
+		err = json.NewDecoder(resp.Body).Decode(&problem)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s %s: %v", test.method, test.path, err)
+		}
+		if resp.StatusCode != test.status || problem.Code != test.code {
+			t.Errorf("%s %s: got %d %s, want %d %s", test.method, test.path, resp.StatusCode, problem.Code, test.status, test.code)
+		}
+	}
+}
@@ -3,9 +3,9 @@ package middlewares
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -14,7 +14,10 @@ import (
 
 const minSecretKeyLength = 32
 
-// GenerteJWTSecret generates a secure random secret key and sets it as an environment variable.
+// jwtSecret signs and verifies the tokens, set by SetJWTSecret at startup.
+var jwtSecret []byte
+
+// GenerteJWTSecret generates a secure random secret key.
 func GenerteJWTSecret() (string, error) {
 	// Generate a secure random byte slice of the required length
 	secretBytes := make([]byte, minSecretKeyLength)
@@ -24,46 +27,43 @@ func GenerteJWTSecret() (string, error) {
 	}
 
 	// Encode the byte slice to a base64 string
-	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
-
-	// Set the secret key as the expected enviorment variable
-	err = os.Setenv("JWT_SECRET", secret)
-	if err != nil {
-		return "", fmt.Errorf("failed to set JWT_SECRET environment variable: %v", err)
-	}
-
-	// Log that a new JWT secret was created
-	log.Printf("%v A new JWT_SECRET was generated and set.", time.Now().Format(time.RFC3339))
-	return secret, nil
+	return base64.RawURLEncoding.EncodeToString(secretBytes), nil
 }
 
-// GenerateJWT generates a JWT token with a given expiration or defaults to 24 hours
-func GenerateJWT(userID uint, email string, expiration time.Duration) (string, error) {
-
-	// Retrieve the secret key from environment variables
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
+// SetJWTSecret sets the secret signing the tokens. Without one a random
+// secret is generated, the tokens not surviving a restart.
+func SetJWTSecret(secret string) error {
+	if secret == "" {
 		// Log a warning that the secret is not set
-		log.Printf("%v Warning: JWT_SECRET env variable is not set. Generating a new secret. ", time.Now().Format(time.RFC3339))
+		slog.Warn("JWT_SECRET is not set, generating a new secret")
 
-		// Generate and set new secret
 		var err error
-		jwtSecret, err = GenerteJWTSecret()
+		secret, err = GenerteJWTSecret()
 		if err != nil {
-			return "", fmt.Errorf("failed to generate a new JWT secret: %v", err)
+			return fmt.Errorf("failed to generate a new JWT secret: %v", err)
 		}
 	}
 
 	// Validate the strength of the JWT secret key using ValidatePassword
 	jwtSecretConfig := utils.NewPasswordValidationConfig(minSecretKeyLength)
-	if err := utils.ValidatePassword(jwtSecret, jwtSecretConfig); err != nil {
-		return "", fmt.Errorf("JWT_SECRET is too weak; must be at least %d characters long", minSecretKeyLength)
+	if err := utils.ValidatePassword(secret, jwtSecretConfig); err != nil {
+		return fmt.Errorf("JWT_SECRET is too weak; must be at least %d characters long", minSecretKeyLength)
+	}
+	jwtSecret = []byte(secret)
+	return nil
+}
+
+// GenerateJWT generates a JWT token for a user of the given role with a given expiration
+func GenerateJWT(userID uint, email, role string, expiration time.Duration) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errors.New("the JWT secret is not set")
 	}
 
 	// Generate JWT token with claims
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
+		"role":    role,
 		"exp":     time.Now().Add(expiration).Unix(), // Set the expiration time
 	}
 
@@ -71,7 +71,7 @@ func GenerateJWT(userID uint, email string, expiration time.Duration) (string, e
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign the token with the secret and return it
-	signedToken, err := token.SignedString([]byte(jwtSecret))
+	signedToken, err := token.SignedString(jwtSecret)
 	if err != nil {
 		return "", fmt.Errorf("error signing the token: %v", err)
 	}
@@ -0,0 +1,141 @@
+package middlewares
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/services"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Headers of the rate limits, after the IETF RateLimit header fields draft,
+// and of the monthly quotas of the shops.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset" // Seconds until the bucket is full
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+	HeaderQuotaLimit         = "X-Quota-Limit"
+	HeaderQuotaRemaining     = "X-Quota-Remaining"
+	HeaderQuotaReset         = "X-Quota-Reset" // Seconds until the next month
+)
+
+// KeyFunc names the client of a request; the requests of a client share their
+// buckets.
+type KeyFunc func(c *fiber.Ctx) string
+
+// KeyByIP tells the clients apart by IP address.
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByClient tells the clients apart by their verified principal, then by the
+// operator token, then by IP address. Invalid credentials count against the
+// IP, so that sending random tokens doesn't get a client fresh buckets.
+func KeyByClient(c *fiber.Ctx) string {
+	if principal, err := parseToken(c); err == nil {
+		return "user:" + principal.Role + ":" + strconv.FormatUint(uint64(principal.UserID), 10)
+	}
+	if isAdmin(c) {
+		return "admin"
+	}
+	return KeyByIP(c)
+}
+
+// RateLimit limits the requests of each client, as named by key, to policy,
+// answering 429 with a Retry-After header past the limit. The responses tell
+// the state of the client's bucket in the RateLimit-* headers, those of the
+// most exhausted bucket when several limits apply. When the buckets can't be
+// reached the requests are let through.
+func RateLimit(limits *services.RateLimitService, policy services.RatePolicy, key KeyFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !policy.Enabled() {
+			return c.Next()
+		}
+
+		limit, err := limits.Take(c.UserContext(), policy, key(c))
+		if err != nil {
+			slog.WarnContext(c.UserContext(), "Rate limit unavailable, letting the request through", "policy", policy.Name, "error", err)
+			return c.Next()
+		}
+
+		setRateLimitHeaders(c, limit)
+		if !limit.Allowed {
+			retryAfter := seconds(limit.RetryAfter)
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+			return utils.ErrRateLimited("Too many requests, retry in " + strconv.FormatInt(retryAfter, 10) + " seconds")
+		}
+		return c.Next()
+	}
+}
+
+// RateLimitByMethod limits the GET, HEAD and OPTIONS requests to read and the
+// other requests to write, see RateLimit.
+func RateLimitByMethod(limits *services.RateLimitService, read, write services.RatePolicy, key KeyFunc) fiber.Handler {
+	readLimit, writeLimit := RateLimit(limits, read, key), RateLimit(limits, write, key)
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return readLimit(c)
+		}
+		return writeLimit(c)
+	}
+}
+
+// setRateLimitHeaders describes limit in the response headers, unless they
+// already describe a bucket with fewer tokens left.
+func setRateLimitHeaders(c *fiber.Ctx, limit services.RateLimit) {
+	if previous, err := strconv.Atoi(c.GetRespHeader(HeaderRateLimitRemaining)); err == nil && previous <= limit.Remaining {
+		return
+	}
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(limit.Policy.Limit))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(limit.Remaining))
+	c.Set(HeaderRateLimitReset, strconv.FormatInt(seconds(limit.Reset), 10))
+	c.Set(HeaderRateLimitPolicy, strconv.Itoa(limit.Policy.Limit)+";w="+strconv.FormatInt(seconds(limit.Policy.Window), 10))
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// ShopQuota counts the requests for the shop of the :id parameter against its
+// monthly API quota, answering 429 once it is used up, and tells the state of
+// the quota in the X-Quota-* headers. It must run after RequireAuth. Only the
+// requests of the shop's owner are counted; the others are left to the
+// handler to refuse. When the usage can't be counted the requests are let
+// through.
+func ShopQuota(quotas *services.QuotaService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		shopID, err := strconv.ParseUint(c.Params("id"), 10, 0)
+		principal, ok := CurrentPrincipal(c)
+		if err != nil || !ok {
+			return c.Next()
+		}
+
+		usage, err := quotas.Consume(c.UserContext(), principal.UserID, uint(shopID))
+		var apiErr *utils.APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Code != utils.CodeQuotaExceeded:
+			return c.Next() // Not the owner's shop
+		case err != nil && apiErr == nil:
+			slog.WarnContext(c.UserContext(), "Shop quota unavailable, letting the request through", "shop_id", shopID, "error", err)
+			return c.Next()
+		}
+
+		if usage.Quota > 0 {
+			c.Set(HeaderQuotaLimit, strconv.FormatInt(usage.Quota, 10))
+			c.Set(HeaderQuotaRemaining, strconv.FormatInt(usage.Remaining(), 10))
+			c.Set(HeaderQuotaReset, strconv.FormatInt(seconds(time.Until(usage.Resets)), 10))
+		}
+		if err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/database"
+)
+
+// HeaderReadConsistency set to "strong" forces a request to read from the primary.
+const HeaderReadConsistency = "X-Read-Consistency"
+
+// ReadRouting lets the queries of GET and HEAD requests go to the read
+// replicas. A client that just wrote is kept on the primary for window so it
+// reads its own writes despite replication lag, and a client can ask for the
+// primary with "X-Read-Consistency: strong".
+//
+// Clients are told apart by their Authorization header, or their IP when
+// anonymous. The window is tracked per instance, which is enough behind a load
+// balancer with session affinity.
+func ReadRouting(window time.Duration) fiber.Handler {
+	writers := newRecentWriters(window)
+
+	return func(c *fiber.Ctx) error {
+		client := clientKey(c)
+
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			// The window starts once the write is done.
+			err := c.Next()
+			writers.mark(client)
+			return err
+		}
+
+		if !strings.EqualFold(c.Get(HeaderReadConsistency), "strong") && !writers.recent(client) {
+			c.SetUserContext(database.WithReplicaReads(c.UserContext()))
+		}
+		return c.Next()
+	}
+}
+
+// clientKey identifies the client of a request without keeping its token around.
+func clientKey(c *fiber.Ctx) [sha256.Size]byte {
+	if auth := c.Get(fiber.HeaderAuthorization); auth != "" {
+		return sha256.Sum256([]byte(auth))
+	}
+	return sha256.Sum256([]byte(c.IP()))
+}
+
+// recentWriters remembers which clients wrote within the last window.
+type recentWriters struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	until     map[[sha256.Size]byte]time.Time
+	lastSweep time.Time
+}
+
+func newRecentWriters(window time.Duration) *recentWriters {
+	return &recentWriters{window: window, until: map[[sha256.Size]byte]time.Time{}}
+}
+
+// mark records a write by client.
+func (w *recentWriters) mark(client [sha256.Size]byte) {
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.until[client] = now.Add(w.window)
+
+	// Drop expired entries from time to time so the map doesn't grow forever.
+	if now.Sub(w.lastSweep) > w.window {
+		for key, until := range w.until {
+			if now.After(until) {
+				delete(w.until, key)
+			}
+		}
+		w.lastSweep = now
+	}
+}
+
+// recent reports whether client wrote within the window.
+func (w *recentWriters) recent(client [sha256.Size]byte) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	until, ok := w.until[client]
+	return ok && time.Now().Before(until)
+}
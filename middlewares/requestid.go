@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/mohamedhabas11/golang-api/logging"
+)
+
+// requestIDKey is the fiber.Ctx locals key holding the current request ID.
+const requestIDKey = "requestid"
+
+// validRequestID matches the caller's request IDs worth keeping: short and
+// without characters that could forge log lines.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware assigns every request an ID (or reuses the caller's
+// X-Request-ID header) and echoes it back in the response. The logs of the
+// request carry it too, the ones of its SQL statements included.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if !validRequestID.MatchString(id) {
+			id = utils.UUIDv4()
+		}
+		id = strings.Clone(id) // Outlives the request buffer in the logs
+		c.Set(fiber.HeaderXRequestID, id)
+		c.Locals(requestIDKey, id)
+		c.SetUserContext(logging.WithAttrs(c.UserContext(), slog.String("request_id", id)))
+		return c.Next()
+	}
+}
+
+// RequestID returns the ID assigned to the current request, if any.
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// Headers the browsers let the API clients send, and read from the responses,
+// on cross-origin requests.
+var (
+	corsAllowHeaders = []string{
+		fiber.HeaderAuthorization, fiber.HeaderContentType, fiber.HeaderXRequestID, HeaderAPIVersion,
+		HeaderCSRFToken, HeaderReadConsistency, "Last-Event-ID", fiber.HeaderIfNoneMatch,
+	}
+	corsExposeHeaders = []string{
+		fiber.HeaderXRequestID, HeaderAPIVersion, "Deprecation", "Sunset", fiber.HeaderLink, fiber.HeaderLocation,
+		HeaderRateLimitLimit, HeaderRateLimitRemaining, HeaderRateLimitReset, HeaderRateLimitPolicy, fiber.HeaderRetryAfter,
+		HeaderQuotaLimit, HeaderQuotaRemaining, HeaderQuotaReset, fiber.HeaderETag, HeaderCache,
+	}
+)
+
+// CORS lets the browser pages of origins call the API, with the auth cookies
+// when credentials is set; * allows every origin, without credentials.
+// Without origins the browsers keep the API to its own origin.
+func CORS(origins []string, credentials bool, maxAge time.Duration) fiber.Handler {
+	if len(origins) == 0 {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(origins, ","),
+		AllowMethods:     strings.Join([]string{fiber.MethodGet, fiber.MethodHead, fiber.MethodPost, fiber.MethodPut, fiber.MethodDelete}, ","),
+		AllowHeaders:     strings.Join(corsAllowHeaders, ","),
+		AllowCredentials: credentials,
+		ExposeHeaders:    strings.Join(corsExposeHeaders, ","),
+		MaxAge:           int(maxAge.Seconds()),
+	})
+}
+
+// SecurityHeaders sets the headers hardening the responses in browsers: no
+// MIME sniffing, framing or referrers, the content security policy csp, and
+// Strict-Transport-Security for hstsMaxAge on HTTPS requests, unless zero.
+// Handlers serving pages may replace the policy.
+func SecurityHeaders(hstsMaxAge time.Duration, csp string) fiber.Handler {
+	hsts := "max-age=" + strconv.FormatInt(int64(hstsMaxAge.Seconds()), 10) + "; includeSubDomains"
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Set(fiber.HeaderXFrameOptions, "DENY")
+		c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+		c.Set("Cross-Origin-Opener-Policy", "same-origin")
+		c.Set(fiber.HeaderCrossOriginResourcePolicy, "same-origin")
+		if csp != "" {
+			c.Set(fiber.HeaderContentSecurityPolicy, csp)
+		}
+		if hstsMaxAge > 0 && c.Protocol() == "https" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		return c.Next()
+	}
+}
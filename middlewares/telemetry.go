@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mohamedhabas11/golang-api/telemetry"
+)
+
+// Telemetry traces every request in a server span, continuing the trace of
+// its W3C traceparent header, and counts it with its latency by route
+// template and status. The span is in c.UserContext(), so the database
+// queries of the request are its children. It must run after
+// RequestIDMiddleware and before the middlewares deriving the user context.
+func Telemetry() fiber.Handler {
+	isRoute := routeChecker()
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		// Fiber's strings point into buffers reused by the next requests, the
+		// metrics and spans outliving the request keep copies.
+		method := strings.Clone(c.Method())
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaders{c})
+		ctx, span := telemetry.Tracer().Start(ctx, method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(strings.Clone(c.Path())),
+				semconv.ClientAddress(strings.Clone(c.IP())),
+				semconv.UserAgentOriginal(strings.Clone(c.Get(fiber.HeaderUserAgent))),
+				attribute.String("request.id", RequestID(c)),
+			))
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		// Render errors first, to record the status of the problem details.
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				span.RecordError(err)
+				return err
+			}
+		}
+
+		// Middlewares of Use serve every path under theirs.
+		route := strings.Clone(c.Route().Path)
+		if !isRoute(c) {
+			route = strings.TrimSuffix(route, "/") + "/*"
+		}
+		status := c.Response().StatusCode()
+		telemetry.ObserveRequest(method, route, status, time.Since(start))
+
+		span.SetName(method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
+
+// requestHeaders reads the headers of a request for the propagators.
+type requestHeaders struct {
+	c *fiber.Ctx
+}
+
+var _ propagation.TextMapCarrier = requestHeaders{}
+
+func (h requestHeaders) Get(key string) string {
+	return strings.Clone(h.c.Get(key))
+}
+
+func (h requestHeaders) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h requestHeaders) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// QueryTimeout bounds the database work of a request. Handlers pass
+// c.UserContext() down to the repositories, so every query of the request is
+// cancelled once timeout elapses and the client gets a 503 instead of waiting
+// on a stuck database. A zero timeout disables the limit.
+func QueryTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
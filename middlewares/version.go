@@ -0,0 +1,162 @@
+package middlewares
+
+import (
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// HeaderAPIVersion selects the version of the API of a request to an
+// unversioned path, and tells the version that served a response.
+const HeaderAPIVersion = "API-Version"
+
+// Locals keys of the API version of the request and of how it was selected.
+const (
+	apiVersionKey = "apiVersion"
+	versionViaKey = "apiVersionVia"
+)
+
+// Ways the API version of a request is selected.
+const (
+	VersionViaPath    = "path"    // /api/v2/...
+	VersionViaHeader  = "header"  // API-Version: v2
+	VersionViaDefault = "default" // Neither: the oldest version
+)
+
+// APIVersion is a version of the API, served under /api/<Name>.
+type APIVersion struct {
+	Name       string    // v1, v2...
+	Deprecated time.Time // When the version was or will be deprecated; zero while supported
+	Sunset     time.Time // When the version stops being served; zero until planned
+}
+
+// APIVersionOf returns the version of the API serving the request, the zero
+// APIVersion outside of the versioned routes.
+func APIVersionOf(c *fiber.Ctx) APIVersion {
+	version, _ := c.Locals(apiVersionKey).(APIVersion)
+	return version
+}
+
+// versionSegment matches the path segments naming a version.
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// NegotiateVersion routes the requests to unversioned paths under prefix to a
+// version of the API: the one of the API-Version header, "v2" or "2", or the
+// oldest of versions without it, for the clients predating versioning. The
+// path is rewritten, so the versioned routes registered after it serve them.
+func NegotiateVersion(prefix string, versions []APIVersion) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rest := strings.TrimPrefix(c.Path(), prefix)
+		segment, _, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+		if versionSegment.MatchString(segment) {
+			if !slices.ContainsFunc(versions, func(v APIVersion) bool { return v.Name == segment }) {
+				return utils.ErrNotFound("API version " + segment + " does not exist")
+			}
+			c.Locals(versionViaKey, VersionViaPath)
+			return c.Next()
+		}
+
+		version, via := versions[0], VersionViaDefault
+		if requested := c.Get(HeaderAPIVersion); requested != "" {
+			name := "v" + strings.TrimPrefix(strings.ToLower(requested), "v")
+			i := slices.IndexFunc(versions, func(v APIVersion) bool { return v.Name == name })
+			if i < 0 {
+				return utils.ErrBadRequest("Unsupported " + HeaderAPIVersion + " " + strconv.Quote(requested) + ", supported versions: " + versionNames(versions))
+			}
+			version, via = versions[i], VersionViaHeader
+		}
+		c.Vary(HeaderAPIVersion)
+		c.Locals(versionViaKey, via)
+		c.Path(prefix + "/" + version.Name + rest)
+		return c.Next()
+	}
+}
+
+// versionNames lists the names of versions.
+func versionNames(versions []APIVersion) string {
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		names[i] = version.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// Versioned marks the requests of a version group: it records their version
+// and its usage, and answers the version in the API-Version header. A
+// deprecated version also gets the Deprecation and Sunset headers (RFC 9745,
+// RFC 8594) with a link to successor, the path of the latest version.
+func Versioned(version APIVersion, successor string, usage *VersionUsage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(apiVersionKey, version)
+		via, _ := c.Locals(versionViaKey).(string)
+		if via == "" {
+			via = VersionViaPath
+		}
+		usage.record(version.Name, via)
+
+		c.Set(HeaderAPIVersion, version.Name)
+		if !version.Deprecated.IsZero() {
+			c.Set("Deprecation", "@"+strconv.FormatInt(version.Deprecated.Unix(), 10))
+			if successor != "" {
+				c.Append(fiber.HeaderLink, "<"+successor+`>; rel="successor-version"`)
+			}
+		}
+		if !version.Sunset.IsZero() {
+			c.Set("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+		}
+		return c.Next()
+	}
+}
+
+// VersionUsage counts the requests served by each API version on this
+// replica, telling when a retired version stopped being used.
+type VersionUsage struct {
+	mu     sync.Mutex
+	usages map[string]*VersionStats
+}
+
+// VersionStats is the usage of an API version since the replica started.
+type VersionStats struct {
+	Requests      int64            // Every request
+	RequestsVia   map[string]int64 // Requests by how the version was selected
+	LastRequestAt time.Time
+}
+
+// NewVersionUsage creates a VersionUsage without requests.
+func NewVersionUsage() *VersionUsage {
+	return &VersionUsage{usages: map[string]*VersionStats{}}
+}
+
+func (u *VersionUsage) record(version, via string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	stats, ok := u.usages[version]
+	if !ok {
+		stats = &VersionStats{RequestsVia: map[string]int64{}}
+		u.usages[version] = stats
+	}
+	stats.Requests++
+	stats.RequestsVia[via]++
+	stats.LastRequestAt = time.Now()
+}
+
+// Stats returns a copy of the usage of version.
+func (u *VersionUsage) Stats(version string) VersionStats {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	stats := VersionStats{RequestsVia: map[string]int64{}}
+	if usage, ok := u.usages[version]; ok {
+		stats = *usage
+		stats.RequestsVia = maps.Clone(usage.RequestsVia)
+	}
+	return stats
+}
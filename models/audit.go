@@ -0,0 +1,96 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audited entity types.
+const (
+	AuditShop      = "shop"
+	AuditShopOwner = "shop_owner"
+	AuditEmployee  = "shop_employee"
+	AuditCustomer  = "customer"
+	AuditInventory = "inventory"
+	AuditItem      = "item"
+	AuditTransfer  = "shop_transfer"
+	AuditWebhook   = "webhook"
+)
+
+// Audited actions.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// Actor types that are not a principal role.
+const (
+	ActorAnonymous = "anonymous" // Unauthenticated API request
+	ActorSystem    = "system"    // Background job
+)
+
+// AuditEntry records one mutation. Entries are append-only and chained: each
+// Hash covers the entry and the Hash of the previous one, so altering or
+// removing an entry breaks every following hash.
+type AuditEntry struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	Seq        uint64    `json:"seq" gorm:"uniqueIndex"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	ActorType  string    `json:"actor_type" gorm:"index:idx_audit_entries_actor"`
+	ActorID    *uint     `json:"actor_id" gorm:"index:idx_audit_entries_actor"`
+	ShopID     *uint     `json:"shop_id" gorm:"index"`
+	EntityType string    `json:"entity_type" gorm:"index:idx_audit_entries_entity"`
+	EntityID   uint      `json:"entity_id" gorm:"index:idx_audit_entries_entity"`
+	Action     string    `json:"action"`
+	Changes    string    `json:"changes"` // JSON object of field name to {"old", "new"}
+	RequestID  string    `json:"request_id"`
+	IP         string    `json:"ip"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+// ComputeHash returns the SHA-256 of the entry's content and PrevHash.
+func (e AuditEntry) ComputeHash() string {
+	optional := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+
+	content := strings.Join([]string{
+		strconv.FormatUint(e.Seq, 10),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.ActorType,
+		optional(e.ActorID),
+		optional(e.ShopID),
+		e.EntityType,
+		strconv.FormatUint(uint64(e.EntityID), 10),
+		e.Action,
+		e.Changes,
+		e.RequestID,
+		e.IP,
+		e.PrevHash,
+	}, "\n")
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditChain is the single row holding the head of the audit hash chain. Writers
+// lock it to append in order, and it reveals entries removed from the tail.
+type AuditChain struct {
+	ID   uint   `gorm:"primarykey"`
+	Seq  uint64 // Seq of the last entry
+	Hash string // Hash of the last entry
+}
+
+// TableName keeps the table name singular, there is only one chain.
+func (AuditChain) TableName() string {
+	return "audit_chain"
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Job statuses.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running" // Claimed by the worker in LockedBy until LockedUntil
+	JobSucceeded = "succeeded"
+	JobDead      = "dead" // Dead letter: failed permanently or too often
+	JobCancelled = "cancelled"
+)
+
+// Job is a unit of background work waiting in, or done by, the job queue.
+type Job struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Type      string    `json:"type"`     // Selects the handler
+	Payload   string    `json:"payload"`  // JSON document given to the handler
+	Schedule  string    `json:"schedule"` // Name of the schedule that enqueued it, if any
+
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	RunAt       time.Time  `json:"run_at"` // Not run before then
+	LockedBy    string     `json:"locked_by"`
+	LockedUntil *time.Time `json:"locked_until"`
+	LastError   string     `json:"last_error"`
+	StartedAt   *time.Time `json:"started_at"` // Start of the last attempt
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// Finished reports whether the job will not run again unless retried by hand.
+func (j Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobDead || j.Status == JobCancelled
+}
+
+// JobSchedule is the state of a cron schedule shared by all replicas.
+type JobSchedule struct {
+	Name      string     `json:"name" gorm:"primarykey"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+}
+
+// LeaderLease elects one replica to perform a task. The holder must renew it
+// before it expires, after which any replica may take it over.
+type LeaderLease struct {
+	Name      string    `json:"name" gorm:"primarykey"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -1,24 +1,34 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Unique columns use partial unique indexes (WHERE deleted_at IS NULL) so that
+// soft-deleted rows don't block re-registering the same email.
 
 // Shop represents the business entity with its inventories and staff.
 type Shop struct {
 	gorm.Model
 	Name        string         `json:"name"`
-	Email       string         `json:"email" gorm:"unique"`
+	Email       string         `json:"email" gorm:"uniqueIndex:idx_shops_email,where:deleted_at IS NULL"`
 	OwnerID     uint           `json:"owner_id"`    // Reference to the shop owner.
 	Owner       ShopOwner      `json:"owner"`       // One-to-one relation.
 	Employees   []ShopEmployee `json:"employees"`   // One-to-many relation.
 	Inventories []Inventory    `json:"inventories"` // One-to-many relation.
+	// MonthlyAPIQuota caps the API requests made for the shop each month;
+	// nil applies the default quota of the API.
+	MonthlyAPIQuota *int64 `json:"monthly_api_quota"`
 }
 
 // ShopOwner represents a user who can manage the shop (and its employees/inventories).
 type ShopOwner struct {
 	gorm.Model
 	Name     string `json:"name"`
-	Email    string `json:"email" gorm:"unique"`
-	Password string `json:"password"`
+	Email    string `json:"email" gorm:"uniqueIndex:idx_shop_owners_email,where:deleted_at IS NULL"`
+	Password Secret `json:"password"`
 	// Additional owner-specific fields can be added here.
 }
 
@@ -26,8 +36,8 @@ type ShopOwner struct {
 type ShopEmployee struct {
 	gorm.Model
 	Name     string `json:"name"`
-	Email    string `json:"email" gorm:"unique"`
-	Password string `json:"password"`
+	Email    string `json:"email" gorm:"uniqueIndex:idx_shop_employees_email,where:deleted_at IS NULL"`
+	Password Secret `json:"password"`
 	ShopID   uint   `json:"shop_id"` // Foreign key to the Shop.
 }
 
@@ -35,8 +45,8 @@ type ShopEmployee struct {
 type Customer struct {
 	gorm.Model
 	Name     string `json:"name"`
-	Email    string `json:"email" gorm:"unique"`
-	Password string `json:"password"`
+	Email    string `json:"email" gorm:"uniqueIndex:idx_customers_email,where:deleted_at IS NULL"`
+	Password Secret `json:"password"`
 	// Customer-specific fields, like shipping address, can be added.
 }
 
@@ -55,3 +65,25 @@ type Item struct {
 	Name        string `json:"name"`
 	Quantity    int    `json:"quantity"`
 }
+
+// Shop transfer statuses.
+const (
+	TransferPending   = "pending"
+	TransferAccepted  = "accepted"
+	TransferDeclined  = "declined"
+	TransferCancelled = "cancelled"
+	TransferExpired   = "expired"
+)
+
+// ShopTransfer is a request to hand a shop over to another ShopOwner. It is
+// initiated by the current owner and only takes effect once the receiving
+// owner accepts it.
+type ShopTransfer struct {
+	gorm.Model
+	ShopID      uint       `json:"shop_id" gorm:"index"`
+	FromOwnerID uint       `json:"from_owner_id"`
+	ToOwnerID   uint       `json:"to_owner_id" gorm:"index"`
+	Status      string     `json:"status"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event waiting in the transactional outbox. It is
+// written in the transaction of the change it describes and published to the
+// event sinks afterwards, at least once and in order per aggregate.
+type OutboxEvent struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"created_at"`
+	Type          string    `json:"type"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateID   uint      `json:"aggregate_id"`
+	ShopID        *uint     `json:"shop_id"`
+	Payload       string    `json:"payload"` // JSON document of the event data
+
+	// Delivery state, maintained by the dispatcher.
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LockedUntil   *time.Time `json:"locked_until"` // Claimed by a dispatcher until then
+	LastError     string     `json:"last_error"`
+	PublishedAt   *time.Time `json:"published_at"`
+	DeadAt        *time.Time `json:"dead_at"` // Given up on after too many failed attempts
+}
+
+// Pending reports whether the event still has to be published.
+func (e OutboxEvent) Pending() bool {
+	return e.PublishedAt == nil && e.DeadAt == nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// RateLimitBucket is the token bucket of a client under a rate limit policy.
+// It refills continuously up to the limit of the policy.
+type RateLimitBucket struct {
+	Key       string    `json:"key" gorm:"primarykey"` // Policy and client, such as read:ip:203.0.113.7
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"` // Last refill
+}
+
+// ShopAPIUsage counts the API requests made for a shop in a calendar month.
+type ShopAPIUsage struct {
+	ShopID   uint   `json:"shop_id" gorm:"primarykey;autoIncrement:false"`
+	Period   string `json:"period" gorm:"primarykey"` // Month in UTC, 2006-01
+	Requests int64  `json:"requests"`
+}
+
+// TableName keeps the table name uncountable, like usage.
+func (ShopAPIUsage) TableName() string {
+	return "shop_api_usage"
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+)
+
+// redacted is what a Secret renders as anywhere outside the database.
+const redacted = "[REDACTED]"
+
+// Secret holds sensitive values such as password hashes. It is stored as a
+// plain string column but can never be serialized to JSON or printed in logs;
+// use Reveal to access the underlying value explicitly.
+type Secret string
+
+// Reveal returns the underlying value.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// MarshalJSON always emits null so secrets never reach API responses.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// String redacts the value when printed with %s or %v.
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString redacts the value when printed with %#v.
+func (s Secret) GoString() string {
+	return redacted
+}
+
+// Value implements driver.Valuer so the secret is stored as text.
+func (s Secret) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// Scan implements sql.Scanner.
+func (s *Secret) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = Secret(v)
+	case []byte:
+		*s = Secret(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Secret", value)
+	}
+	return nil
+}
+
+// LogValue redacts the value in structured logs.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
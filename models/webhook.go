@@ -0,0 +1,71 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookAllEvents subscribes a webhook to every event type.
+const WebhookAllEvents = "*"
+
+// Webhook is an endpoint registered by a shop owner to be notified of the
+// domain events of their shop.
+type Webhook struct {
+	gorm.Model
+	ShopID uint   `json:"shop_id"` // Foreign key to the Shop.
+	URL    string `json:"url"`
+	Secret Secret `json:"secret"` // Key of the HMAC-SHA256 signature of deliveries
+	// EventTypes is the comma-separated list of subscribed event types, or
+	// WebhookAllEvents.
+	EventTypes string `json:"event_types"`
+	Active     bool   `json:"active"`
+	// ConsecutiveFailures counts the failed delivery attempts since the last
+	// success; too many of them disable the webhook.
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      string     `json:"disabled_reason"`
+}
+
+// EventTypeList returns the subscribed event types.
+func (w Webhook) EventTypeList() []string {
+	return strings.Split(w.EventTypes, ",")
+}
+
+// Subscribes reports whether the webhook wants events of eventType.
+func (w Webhook) Subscribes(eventType string) bool {
+	types := w.EventTypeList()
+	return slices.Contains(types, WebhookAllEvents) || slices.Contains(types, eventType)
+}
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook, together
+// with the outcome of its last attempt.
+type WebhookDelivery struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time `json:"created_at"`
+	WebhookID    uint      `json:"webhook_id"`
+	EventID      uint      `json:"event_id"` // Zero for pings
+	EventType    string    `json:"event_type"`
+	Payload      string    `json:"payload"`       // Body POSTed to the webhook
+	RedeliveryOf *uint     `json:"redelivery_of"` // Delivery manually sent again
+
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LockedUntil   *time.Time `json:"locked_until"` // Claimed by a worker until then
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	ResponseCode  int        `json:"response_code"` // Zero when no response was received
+	ResponseBody  string     `json:"response_body"` // Truncated
+	Error         string     `json:"error"`
+	DurationMS    int64      `json:"duration_ms" gorm:"column:duration_ms"`
+	FinishedAt    *time.Time `json:"finished_at"` // Succeeded or given up on
+}
@@ -0,0 +1,280 @@
+// Package openapi builds the OpenAPI 3.1 document of the API from declarations
+// of its endpoints, deriving the JSON schemas of their payloads from the Go
+// types and validation rules of the DTOs, and checks actual responses against
+// it. It knows nothing about the router: callers add one operation per route.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the documents.
+const Version = "3.1.0"
+
+// MIMEProblemJSON is the media type of the error responses.
+const MIMEProblemJSON = "application/problem+json"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	schemas *generator
+	problem *Schema // Body of the error responses
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path by lower-case HTTP method.
+type PathItem map[string]*Operation
+
+// Operation is one HTTP method on one path.
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query or header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body accepted by an operation.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one possible answer of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body of a given media type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas and the security schemes.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way of authenticating requests.
+type SecurityScheme struct {
+	Type         string `json:"type"` // http or apiKey
+	Description  string `json:"description,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`       // http: bearer
+	BearerFormat string `json:"bearerFormat,omitempty"` // http: JWT
+	Name         string `json:"name,omitempty"`         // apiKey: the header name
+	In           string `json:"in,omitempty"`           // apiKey: header
+}
+
+// Endpoint declares what an operation takes and answers. Its schemas are
+// derived from the Go values given, see Document.Schema.
+type Endpoint struct {
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+	Query       any         // Struct whose `query` tagged fields are the query parameters
+	Params      []Parameter // Parameters not described by Query
+	Body        any         // JSON request body
+	// Responses maps the success statuses to their JSON body, nil for none, or
+	// to a Content for another media type.
+	Responses map[int]any
+	// Security lists the security schemes accepted, any one of them granting
+	// access. None makes the operation public.
+	Security []string
+	// Problems lists the error statuses the operation answers besides the
+	// ones implied by its parameters, body and security. Every other error is
+	// covered by the default response.
+	Problems []int
+	// Deprecated marks an operation clients should move away from.
+	Deprecated bool
+}
+
+// Content is a body of another media type than JSON.
+type Content struct {
+	MediaType string
+	Body      any // Schema of the body, or nil
+}
+
+// New creates a Document without operations, answering errors with problem,
+// the Go value of the problem details.
+func New(info Info, problem any) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		schemas: newGenerator(),
+	}
+	d.Components.Schemas = d.schemas.components
+	d.problem = d.Schema(problem)
+	return d
+}
+
+// Rule makes the custom validation tag name add its constraints to the
+// schemas, apply being called with the schema of the field and the parameter
+// of the tag.
+func (d *Document) Rule(name string, apply func(s *Schema, param string)) {
+	d.schemas.rules[name] = apply
+}
+
+// AddSecurityScheme declares a security scheme under name.
+func (d *Document) AddSecurityScheme(name string, scheme SecurityScheme) {
+	if d.Components.SecuritySchemes == nil {
+		d.Components.SecuritySchemes = map[string]SecurityScheme{}
+	}
+	d.Components.SecuritySchemes[name] = scheme
+}
+
+// Schema returns the JSON schema of the Go value v, a reference for named
+// struct types whose schema is added to the components.
+func (d *Document) Schema(v any) *Schema {
+	return d.schemas.schemaOf(typeOf(v))
+}
+
+// Add adds the operation of method on path, a path template such as
+// /shops/{id}.
+func (d *Document) Add(method, path string, e Endpoint) {
+	op := &Operation{
+		OperationID: e.OperationID,
+		Summary:     e.Summary,
+		Description: e.Description,
+		Responses:   map[string]*Response{},
+		Deprecated:  e.Deprecated,
+	}
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+	}
+	problems := slices.Clone(e.Problems)
+
+	for _, name := range pathParams(path) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: []string{"integer"}, Minimum: ptr(1.0)},
+		})
+		problems = append(problems, http.StatusNotFound)
+	}
+	if e.Query != nil {
+		op.Parameters = append(op.Parameters, d.schemas.parameters(typeOf(e.Query))...)
+		problems = append(problems, http.StatusBadRequest, http.StatusUnprocessableEntity)
+	}
+	op.Parameters = append(op.Parameters, e.Params...)
+
+	if e.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: d.Schema(e.Body)}},
+		}
+		problems = append(problems, http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)
+	}
+
+	for _, name := range e.Security {
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+	if len(e.Security) > 0 {
+		problems = append(problems, http.StatusUnauthorized)
+	}
+
+	for status, body := range e.Responses {
+		response := &Response{Description: http.StatusText(status)}
+		switch body := body.(type) {
+		case nil:
+		case Content:
+			response.Content = map[string]MediaType{body.MediaType: {}}
+			if body.Body != nil {
+				response.Content[body.MediaType] = MediaType{Schema: d.Schema(body.Body)}
+			}
+		default:
+			response.Content = map[string]MediaType{"application/json": {Schema: d.Schema(body)}}
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+	slices.Sort(problems)
+	for _, status := range slices.Compact(problems) {
+		op.Responses[strconv.Itoa(status)] = d.problemResponse(http.StatusText(status))
+	}
+	op.Responses["default"] = d.problemResponse("Unexpected error")
+
+	item := d.Paths[path]
+	if item == nil {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+// problemResponse is an error response with the problem details body.
+func (d *Document) problemResponse(description string) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]MediaType{MIMEProblemJSON: {Schema: d.problem}},
+	}
+}
+
+// Operation returns the operation of method on path, a path template.
+func (d *Document) Operation(method, path string) (*Operation, bool) {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil, false
+	}
+	op, ok := (*item)[strings.ToLower(method)]
+	return op, ok
+}
+
+// routeParam matches the :name parameters of a route path.
+var routeParam = regexp.MustCompile(`:([A-Za-z0-9_]+)\??`)
+
+// PathTemplate turns a route path with :name parameters into an OpenAPI path
+// template with {name} ones, without the trailing slash.
+func PathTemplate(route string) string {
+	path := routeParam.ReplaceAllString(route, "{$1}")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// pathTemplateParam matches the {name} parameters of a path template.
+var pathTemplateParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// pathParams returns the names of the parameters of a path template.
+func pathParams(path string) []string {
+	var names []string
+	for _, match := range pathTemplateParam.FindAllStringSubmatch(path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,339 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema is a JSON Schema, the subset of the 2020-12 dialect used by
+// OpenAPI 3.1 that the generated documents need.
+type Schema struct {
+	Ref              string             `json:"$ref,omitempty"`
+	Type             []string           `json:"-"` // Several for nullable values
+	Format           string             `json:"format,omitempty"`
+	Description      string             `json:"description,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	AnyOf            []*Schema          `json:"anyOf,omitempty"`
+	Enum             []any              `json:"enum,omitempty"`
+	Pattern          string             `json:"pattern,omitempty"`
+	MinLength        *int               `json:"minLength,omitempty"`
+	MaxLength        *int               `json:"maxLength,omitempty"`
+	MinItems         *int               `json:"minItems,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64           `json:"exclusiveMinimum,omitempty"`
+	// AdditionalProperties is the schema of the properties not listed, or
+	// with Closed none are allowed.
+	AdditionalProperties *Schema `json:"-"`
+	Closed               bool    `json:"-"`
+}
+
+// MarshalJSON writes a single type as a string and a closed object as
+// additionalProperties false.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema // Without this method
+	out := struct {
+		Type                 any `json:"type,omitempty"`
+		AdditionalProperties any `json:"additionalProperties,omitempty"`
+		*schema
+	}{schema: (*schema)(s)}
+
+	switch len(s.Type) {
+	case 0:
+	case 1:
+		out.Type = s.Type[0]
+	default:
+		out.Type = s.Type
+	}
+	if s.Closed {
+		out.AdditionalProperties = false
+	} else if s.AdditionalProperties != nil {
+		out.AdditionalProperties = s.AdditionalProperties
+	}
+	return json.Marshal(out)
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator derives the schemas of Go types, collecting the named ones.
+type generator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	rules      map[string]func(s *Schema, param string)
+}
+
+func newGenerator() *generator {
+	return &generator{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+		rules:      map[string]func(s *Schema, param string){},
+	}
+}
+
+// typeOf returns the type of v, a pointer standing for its element.
+func typeOf(v any) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// schemaOf returns the schema of t, as JSON encodes it.
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: []string{"string"}, Format: "date-time"}
+	case rawMessageType:
+		return &Schema{} // Any JSON value
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaOf(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: []string{"integer"}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: []string{"integer"}, Minimum: ptr(0.0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: []string{"number"}}
+	case reflect.String:
+		return &Schema{Type: []string{"string"}}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: []string{"array"}, Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object"}, AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.ref(t)
+	}
+	return &Schema{}
+}
+
+// ref returns a reference to the component schema of the struct type t,
+// adding it first. Anonymous structs are inlined.
+func (g *generator) ref(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = g.componentName(t)
+		g.names[t] = name
+		g.components[name] = &Schema{} // Placeholder for recursive types
+		g.components[name] = g.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName names the component of t after its Go type, prefixed with
+// its package when another package already took the name.
+func (g *generator) componentName(t reflect.Type) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, t.Name())
+	if _, taken := g.components[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+// structSchema returns the closed object schema of a struct. A struct with
+// validation rules describes input: its required properties are the ones
+// the rules require. Other structs describe output, every property without
+// omitempty always being present.
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: []string{"object"}, Properties: map[string]*Schema{}, Closed: true}
+	input := hasRules(t)
+	for _, field := range jsonFields(t) {
+		name, omitempty := jsonName(field)
+		rules := field.Tag.Get("validate")
+
+		property := g.schemaOf(field.Type)
+		g.applyRules(property, field.Type, rules)
+		if field.Type.Kind() == reflect.Pointer && !omitempty {
+			property = nullable(property)
+		}
+		s.Properties[name] = property
+
+		if input && hasRule(rules, "required") || !input && !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// parameters returns the query parameters of the `query` tagged fields of
+// the struct type t.
+func (g *generator) parameters(t reflect.Type) []Parameter {
+	var params []Parameter
+	for _, field := range jsonFields(t) {
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" {
+			continue
+		}
+		rules := field.Tag.Get("validate")
+		schema := g.schemaOf(field.Type)
+		g.applyRules(schema, field.Type, rules)
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: hasRule(rules, "required"),
+			Schema:   schema,
+		})
+	}
+	return params
+}
+
+// applyRules adds the constraints of the validation rules to the schema of a
+// field of type t. The rules after dive apply to the items.
+func (g *generator) applyRules(s *Schema, t reflect.Type, rules string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i, rule := range splitRules(rules) {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			if s.Items != nil {
+				g.applyRules(s.Items, t.Elem(), strings.Join(splitRules(rules)[i+1:], ","))
+			}
+			return
+		case "min", "max", "gte", "lte", "gt":
+			limit(s, t, name, param)
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				s.Enum = append(s.Enum, value)
+			}
+		case "email":
+			s.Format = "email"
+		case "http_url", "url":
+			s.Format = "uri"
+		case "datetime":
+			if param == time.RFC3339 {
+				s.Format = "date-time"
+			}
+		default:
+			if apply, ok := g.rules[name]; ok {
+				apply(s, param)
+			}
+		}
+	}
+}
+
+// limit adds a min, max, gte, lte or gt rule to s: a length for strings, a
+// count for slices and a bound for numbers.
+func limit(s *Schema, t reflect.Type, rule, param string) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch t.Kind() {
+	case reflect.String:
+		switch rule {
+		case "min", "gte":
+			s.MinLength = ptr(int(value))
+		case "max", "lte":
+			s.MaxLength = ptr(int(value))
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if rule == "min" || rule == "gte" {
+			s.MinItems = ptr(int(value))
+		}
+	default:
+		switch rule {
+		case "min", "gte":
+			s.Minimum = ptr(value)
+		case "max", "lte":
+			s.Maximum = ptr(value)
+		case "gt":
+			s.Minimum, s.ExclusiveMinimum = nil, ptr(value)
+		}
+	}
+}
+
+// nullable lets s also be null.
+func nullable(s *Schema) *Schema {
+	if len(s.Type) == 0 {
+		if s.Ref == "" {
+			return s // Any value already
+		}
+		return &Schema{AnyOf: []*Schema{s, {Type: []string{"null"}}}}
+	}
+	s.Type = append(s.Type, "null")
+	return s
+}
+
+// jsonFields returns the fields of the struct type t encoded by JSON, the
+// fields of untagged embedded structs being promoted.
+func jsonFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// jsonName returns the JSON name of a field and whether it has omitempty.
+func jsonName(field reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+options+",", ",omitempty,")
+}
+
+// hasRules reports whether a field of the struct type t has validation rules.
+func hasRules(t reflect.Type) bool {
+	for _, field := range jsonFields(t) {
+		if field.Tag.Get("validate") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRule reports whether the validation rules include name before any dive.
+func hasRule(rules, name string) bool {
+	for _, rule := range splitRules(rules) {
+		if rule == "dive" {
+			return false
+		}
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+func splitRules(rules string) []string {
+	if rules == "" {
+		return nil
+	}
+	return strings.Split(rules, ",")
+}
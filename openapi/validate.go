@@ -0,0 +1,228 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidateResponse checks a response of the operation of method on path, a
+// path template, against the document: its status must be documented, with
+// errors falling back on the default response, and a JSON body must match
+// the schema of its media type. Bodies of other media types aren't checked.
+func (d *Document) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op, ok := d.Operation(method, path)
+	if !ok {
+		return fmt.Errorf("%s %s is not documented", method, path)
+	}
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok && status >= 400 {
+		response, ok = op.Responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+
+	if len(response.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("status %d has no body but got %d bytes", status, len(body))
+		}
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type %q", contentType)
+	}
+	content, ok := response.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("status %d is not documented as %s", status, mediaType)
+	}
+	if content.Schema == nil || !isJSON(mediaType) {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	var problems []string
+	d.validate(content.Schema, value, "", &problems)
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// isJSON reports whether mediaType is JSON, such as application/problem+json.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// validate appends to problems the ways value, at the JSON pointer at,
+// violates the schema s.
+func (d *Document) validate(s *Schema, value any, at string, problems *[]string) {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, fmt.Sprintf("%s: %s", pointer(at), fmt.Sprintf(format, args...)))
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			report("unknown schema %s", s.Ref)
+			return
+		}
+		d.validate(target, value, at, problems)
+		return
+	}
+	if len(s.AnyOf) > 0 {
+		for _, alternative := range s.AnyOf {
+			var ignored []string
+			if d.validate(alternative, value, at, &ignored); len(ignored) == 0 {
+				return
+			}
+		}
+		report("matches none of the alternatives")
+		return
+	}
+
+	if kind := jsonType(value); len(s.Type) > 0 && !slices.Contains(s.Type, kind) &&
+		!(kind == "integer" && slices.Contains(s.Type, "number")) {
+		report("expected %s, got %s", strings.Join(s.Type, " or "), kind)
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		report("%v is not one of %v", value, s.Enum)
+	}
+
+	switch value := value.(type) {
+	case string:
+		d.validateString(s, value, report)
+	case json.Number:
+		number, _ := value.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			report("%v is below the minimum %v", value, *s.Minimum)
+		}
+		if s.ExclusiveMinimum != nil && number <= *s.ExclusiveMinimum {
+			report("%v is not above %v", value, *s.ExclusiveMinimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			report("%v is above the maximum %v", value, *s.Maximum)
+		}
+	case []any:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			report("has %d items, fewer than %d", len(value), *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				d.validate(s.Items, item, at+"/"+strconv.Itoa(i), problems)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+		for name, property := range value {
+			switch schema, ok := s.Properties[name]; {
+			case ok:
+				d.validate(schema, property, at+"/"+escapePointer(name), problems)
+			case s.Closed:
+				report("unexpected property %q", name)
+			case s.AdditionalProperties != nil:
+				d.validate(s.AdditionalProperties, property, at+"/"+escapePointer(name), problems)
+			}
+		}
+	}
+}
+
+// validateString checks the length, format and pattern of a string.
+func (d *Document) validateString(s *Schema, value string, report func(format string, args ...any)) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		report("is shorter than %d characters", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		report("is longer than %d characters", *s.MaxLength)
+	}
+	if s.Pattern != "" && !compilePattern(s.Pattern).MatchString(value) {
+		report("%q doesn't match %s", value, s.Pattern)
+	}
+
+	var err error
+	switch s.Format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "email":
+		_, err = mail.ParseAddress(value)
+	case "uri":
+		var u *url.URL
+		if u, err = url.Parse(value); err == nil && !u.IsAbs() {
+			err = errors.New("not absolute")
+		}
+	}
+	if err != nil {
+		report("%q is not a valid %s", value, s.Format)
+	}
+}
+
+// jsonType returns the JSON Schema type of a decoded value.
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		if _, err := strconv.ParseUint(value.String(), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// patterns caches the compiled schema patterns.
+var patterns sync.Map
+
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+	return re
+}
+
+func pointer(at string) string {
+	if at == "" {
+		return "/"
+	}
+	return at
+}
+
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
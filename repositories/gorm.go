@@ -0,0 +1,998 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mohamedhabas11/golang-api/database"
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// GormStore is the Store backed by a GORM database.
+type GormStore struct {
+	db   *gorm.DB
+	inTx bool
+}
+
+// NewGormStore creates a Store on top of db.
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// maxTxAttempts bounds how often a transaction is run when the database
+// aborts it with a serialization failure or deadlock.
+const maxTxAttempts = 3
+
+// Shops returns the shop repository.
+func (s *GormStore) Shops() ShopRepository {
+	return gormShopRepository{newGormRepository[models.Shop](s.db, "Owner")}
+}
+
+// Owners returns the shop owner repository.
+func (s *GormStore) Owners() ShopOwnerRepository {
+	return gormOwnerRepository{newGormRepository[models.ShopOwner](s.db)}
+}
+
+// Employees returns the shop employee repository.
+func (s *GormStore) Employees() EmployeeRepository {
+	return gormEmployeeRepository{newGormRepository[models.ShopEmployee](s.db)}
+}
+
+// Customers returns the customer repository.
+func (s *GormStore) Customers() CustomerRepository {
+	return gormCustomerRepository{newGormRepository[models.Customer](s.db)}
+}
+
+// Inventories returns the inventory repository.
+func (s *GormStore) Inventories() InventoryRepository {
+	return gormInventoryRepository{newGormRepository[models.Inventory](s.db, "Items")}
+}
+
+// Items returns the item repository.
+func (s *GormStore) Items() ItemRepository {
+	return gormItemRepository{newGormRepository[models.Item](s.db)}
+}
+
+// Transfers returns the shop transfer repository.
+func (s *GormStore) Transfers() TransferRepository {
+	return gormTransferRepository{db: s.db}
+}
+
+// Audit returns the audit log repository.
+func (s *GormStore) Audit() AuditRepository {
+	return gormAuditRepository{db: s.db}
+}
+
+// Outbox returns the domain event outbox repository.
+func (s *GormStore) Outbox() OutboxRepository {
+	return gormOutboxRepository{db: s.db}
+}
+
+// Webhooks returns the webhook repository.
+func (s *GormStore) Webhooks() WebhookRepository {
+	return gormWebhookRepository{db: s.db}
+}
+
+// WebhookDeliveries returns the webhook delivery repository.
+func (s *GormStore) WebhookDeliveries() WebhookDeliveryRepository {
+	return gormWebhookDeliveryRepository{db: s.db}
+}
+
+// Jobs returns the background job repository.
+func (s *GormStore) Jobs() JobRepository {
+	return gormJobRepository{db: s.db}
+}
+
+// JobSchedules returns the cron schedule repository.
+func (s *GormStore) JobSchedules() JobScheduleRepository {
+	return gormJobScheduleRepository{db: s.db}
+}
+
+// Leases returns the leader lease repository.
+func (s *GormStore) Leases() LeaseRepository {
+	return gormLeaseRepository{db: s.db}
+}
+
+// RateLimits returns the rate limit bucket repository.
+func (s *GormStore) RateLimits() RateLimitRepository {
+	return gormRateLimitRepository{db: s.db}
+}
+
+// Quotas returns the shop API usage repository.
+func (s *GormStore) Quotas() QuotaRepository {
+	return gormQuotaRepository{db: s.db}
+}
+
+// Transaction runs fn inside a database transaction. When the database aborts
+// it because of a serialization failure or deadlock, the whole transaction is
+// retried with a short backoff, so fn must not have side effects outside tx.
+// Nested transactions are savepoints of the outer one and are never retried
+// on their own.
+func (s *GormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	run := func() error {
+		return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(&GormStore{db: tx, inTx: true})
+		})
+	}
+	if s.inTx {
+		return run()
+	}
+
+	backoff := 10 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if attempt == maxTxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		// Jitter so that the conflicting transactions don't collide again.
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff + time.Duration(rand.Int63n(int64(backoff)))):
+		}
+		backoff *= 2
+	}
+}
+
+// Ping checks that the database is reachable.
+func (s *GormStore) Ping(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// PoolStats returns the connection pool statistics.
+func (s *GormStore) PoolStats() sql.DBStats {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+// isRetryable reports whether err aborted a transaction that may succeed when
+// run again: Postgres serialization failures (40001) and deadlocks (40P01), and
+// MySQL deadlocks (1213).
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1213
+	}
+	return false
+}
+
+// translateError maps GORM errors onto the repository errors.
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %v", ErrDuplicate, err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return fmt.Errorf("%w: %v", ErrForeignKey, err)
+	}
+	return err
+}
+
+// gormRepository implements Repository for any model embedding gorm.Model.
+type gormRepository[T any] struct {
+	db *gorm.DB
+	// preloads are the relations loaded with every row returned.
+	preloads []string
+}
+
+func newGormRepository[T any](db *gorm.DB, preloads ...string) gormRepository[T] {
+	return gormRepository[T]{db: db, preloads: preloads}
+}
+
+// query starts a read with the repository's relations preloaded.
+func (r gormRepository[T]) query(ctx context.Context) *gorm.DB {
+	query := r.db.WithContext(ctx)
+	for _, relation := range r.preloads {
+		query = query.Preload(relation)
+	}
+	return query
+}
+
+func (r gormRepository[T]) first(query *gorm.DB, id uint) (T, error) {
+	var entity T
+	err := query.First(&entity, id).Error
+	return entity, translateError(err)
+}
+
+func (r gormRepository[T]) find(query *gorm.DB) ([]T, error) {
+	var entities []T
+	err := query.Order("id").Find(&entities).Error
+	return entities, translateError(err)
+}
+
+func (r gormRepository[T]) Create(ctx context.Context, entity *T) error {
+	return translateError(r.db.WithContext(ctx).Omit(clause.Associations).Create(entity).Error)
+}
+
+func (r gormRepository[T]) Get(ctx context.Context, id uint) (T, error) {
+	return r.first(r.query(ctx), id)
+}
+
+func (r gormRepository[T]) GetDeleted(ctx context.Context, id uint) (T, error) {
+	return r.first(r.query(ctx).Unscoped().Where("deleted_at IS NOT NULL"), id)
+}
+
+func (r gormRepository[T]) GetAny(ctx context.Context, id uint) (T, error) {
+	return r.first(r.query(ctx).Unscoped(), id)
+}
+
+func (r gormRepository[T]) List(ctx context.Context, opts ListOptions) ([]T, error) {
+	query := r.query(ctx)
+	if opts.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	return r.find(query)
+}
+
+func (r gormRepository[T]) Update(ctx context.Context, entity *T) error {
+	return translateError(r.db.WithContext(ctx).Omit(clause.Associations).Save(entity).Error)
+}
+
+func (r gormRepository[T]) SoftDelete(ctx context.Context, id uint, at time.Time) error {
+	return translateError(r.db.WithContext(ctx).Model(new(T)).Where("id = ?", id).Update("deleted_at", at).Error)
+}
+
+func (r gormRepository[T]) Restore(ctx context.Context, id uint) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Model(new(T)).Where("id = ?", id).Update("deleted_at", nil).Error)
+}
+
+func (r gormRepository[T]) Purge(ctx context.Context, id uint) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Delete(new(T), id).Error)
+}
+
+func (r gormRepository[T]) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(new(T))
+	return result.RowsAffected, translateError(result.Error)
+}
+
+// findByEmail returns the active row with the given email.
+func (r gormRepository[T]) findByEmail(ctx context.Context, email string) (T, error) {
+	var entity T
+	err := r.query(ctx).Where("email = ?", email).First(&entity).Error
+	return entity, translateError(err)
+}
+
+// softDeleteByShop moves the rows of a shop-owned table to the trash.
+func (r gormRepository[T]) softDeleteByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return translateError(r.db.WithContext(ctx).Model(new(T)).Where("shop_id = ?", shopID).Update("deleted_at", at).Error)
+}
+
+func (r gormRepository[T]) restoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Model(new(T)).
+		Where("shop_id = ? AND deleted_at = ?", shopID, deletedAt).
+		Update("deleted_at", nil).Error)
+}
+
+func (r gormRepository[T]) purgeByShop(ctx context.Context, shopID uint) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Where("shop_id = ?", shopID).Delete(new(T)).Error)
+}
+
+type gormShopRepository struct {
+	gormRepository[models.Shop]
+}
+
+func (r gormShopRepository) GetWithRelations(ctx context.Context, id uint, relations ShopRelations) (models.Shop, error) {
+	query := r.query(ctx)
+	if relations.Employees {
+		query = query.Preload("Employees")
+	}
+	if relations.Items {
+		query = query.Preload("Inventories.Items")
+	} else if relations.Inventories {
+		query = query.Preload("Inventories")
+	}
+	return r.first(query, id)
+}
+
+func (r gormShopRepository) FindByEmail(ctx context.Context, email string) (models.Shop, error) {
+	return r.findByEmail(ctx, email)
+}
+
+func (r gormShopRepository) ListByOwner(ctx context.Context, ownerID uint) ([]models.Shop, error) {
+	return r.find(r.query(ctx).Where("owner_id = ?", ownerID))
+}
+
+func (r gormShopRepository) ChangeOwner(ctx context.Context, shopID, fromOwnerID, toOwnerID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Shop{}).
+		Where("id = ? AND owner_id = ?", shopID, fromOwnerID).
+		Update("owner_id", toOwnerID)
+	return result.RowsAffected > 0, translateError(result.Error)
+}
+
+type gormOwnerRepository struct {
+	gormRepository[models.ShopOwner]
+}
+
+func (r gormOwnerRepository) FindByEmail(ctx context.Context, email string) (models.ShopOwner, error) {
+	return r.findByEmail(ctx, email)
+}
+
+type gormEmployeeRepository struct {
+	gormRepository[models.ShopEmployee]
+}
+
+func (r gormEmployeeRepository) FindByEmail(ctx context.Context, email string) (models.ShopEmployee, error) {
+	return r.findByEmail(ctx, email)
+}
+
+func (r gormEmployeeRepository) SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return r.softDeleteByShop(ctx, shopID, at)
+}
+
+func (r gormEmployeeRepository) RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error {
+	return r.restoreByShop(ctx, shopID, deletedAt)
+}
+
+func (r gormEmployeeRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return r.purgeByShop(ctx, shopID)
+}
+
+type gormCustomerRepository struct {
+	gormRepository[models.Customer]
+}
+
+func (r gormCustomerRepository) FindByEmail(ctx context.Context, email string) (models.Customer, error) {
+	return r.findByEmail(ctx, email)
+}
+
+type gormInventoryRepository struct {
+	gormRepository[models.Inventory]
+}
+
+func (r gormInventoryRepository) SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return r.softDeleteByShop(ctx, shopID, at)
+}
+
+func (r gormInventoryRepository) RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error {
+	return r.restoreByShop(ctx, shopID, deletedAt)
+}
+
+func (r gormInventoryRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return r.purgeByShop(ctx, shopID)
+}
+
+type gormItemRepository struct {
+	gormRepository[models.Item]
+}
+
+// shopInventories selects the IDs of a shop's inventories matching the extra condition.
+func (r gormItemRepository) shopInventories(ctx context.Context, shopID uint, condition string, args ...interface{}) *gorm.DB {
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.Inventory{}).Select("id").Where("shop_id = ?", shopID)
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
+	return query
+}
+
+func (r gormItemRepository) SoftDeleteByInventory(ctx context.Context, inventoryID uint, at time.Time) error {
+	return translateError(r.db.WithContext(ctx).Model(&models.Item{}).Where("inventory_id = ?", inventoryID).Update("deleted_at", at).Error)
+}
+
+func (r gormItemRepository) RestoreByInventory(ctx context.Context, inventoryID uint, deletedAt time.Time) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Model(&models.Item{}).
+		Where("inventory_id = ? AND deleted_at = ?", inventoryID, deletedAt).
+		Update("deleted_at", nil).Error)
+}
+
+func (r gormItemRepository) PurgeByInventory(ctx context.Context, inventoryID uint) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Where("inventory_id = ?", inventoryID).Delete(&models.Item{}).Error)
+}
+
+func (r gormItemRepository) SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error {
+	inventories := r.shopInventories(ctx, shopID, "deleted_at IS NULL")
+	return translateError(r.db.WithContext(ctx).Model(&models.Item{}).Where("inventory_id IN (?)", inventories).Update("deleted_at", at).Error)
+}
+
+func (r gormItemRepository) RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error {
+	inventories := r.shopInventories(ctx, shopID, "deleted_at = ?", deletedAt)
+	return translateError(r.db.WithContext(ctx).Unscoped().Model(&models.Item{}).
+		Where("inventory_id IN (?) AND deleted_at = ?", inventories, deletedAt).
+		Update("deleted_at", nil).Error)
+}
+
+func (r gormItemRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	inventories := r.shopInventories(ctx, shopID, "")
+	return translateError(r.db.WithContext(ctx).Unscoped().Where("inventory_id IN (?)", inventories).Delete(&models.Item{}).Error)
+}
+
+func (r gormItemRepository) StockByShop(ctx context.Context, shopID uint) (int64, error) {
+	var stock int64
+	err := r.db.WithContext(ctx).Model(&models.Item{}).
+		Joins("JOIN inventories ON inventories.id = items.inventory_id AND inventories.deleted_at IS NULL").
+		Where("inventories.shop_id = ?", shopID).
+		Select("COALESCE(SUM(items.quantity), 0)").
+		Scan(&stock).Error
+	return stock, translateError(err)
+}
+
+func (r gormItemRepository) CountBelow(ctx context.Context, quantity int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Item{}).
+		Joins("JOIN inventories ON inventories.id = items.inventory_id AND inventories.deleted_at IS NULL").
+		Where("items.quantity < ?", quantity).
+		Count(&count).Error
+	return count, translateError(err)
+}
+
+type gormTransferRepository struct {
+	db *gorm.DB
+}
+
+func (r gormTransferRepository) Create(ctx context.Context, transfer *models.ShopTransfer) error {
+	return translateError(r.db.WithContext(ctx).Create(transfer).Error)
+}
+
+func (r gormTransferRepository) Get(ctx context.Context, shopID, id uint) (models.ShopTransfer, error) {
+	var transfer models.ShopTransfer
+	err := r.db.WithContext(ctx).Where("shop_id = ?", shopID).First(&transfer, id).Error
+	return transfer, translateError(err)
+}
+
+func (r gormTransferRepository) ListByOwner(ctx context.Context, ownerID uint) ([]models.ShopTransfer, error) {
+	var transfers []models.ShopTransfer
+	err := r.db.WithContext(ctx).
+		Where("from_owner_id = ? OR to_owner_id = ?", ownerID, ownerID).
+		Order("created_at DESC, id DESC").
+		Find(&transfers).Error
+	return transfers, translateError(err)
+}
+
+func (r gormTransferRepository) CountPending(ctx context.Context, shopID uint) (int64, error) {
+	var pending int64
+	err := r.db.WithContext(ctx).Model(&models.ShopTransfer{}).
+		Where("shop_id = ? AND status = ?", shopID, models.TransferPending).
+		Count(&pending).Error
+	return pending, translateError(err)
+}
+
+func (r gormTransferRepository) SetStatus(ctx context.Context, transfer *models.ShopTransfer, status string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.ShopTransfer{}).
+		Where("id = ? AND status = ?", transfer.ID, models.TransferPending).
+		Updates(map[string]interface{}{"status": status, "responded_at": at})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, translateError(result.Error)
+	}
+	transfer.Status = status
+	transfer.RespondedAt = &at
+	return true, nil
+}
+
+func (r gormTransferRepository) ExpirePending(ctx context.Context, shopID uint, now time.Time) error {
+	return translateError(r.db.WithContext(ctx).Model(&models.ShopTransfer{}).
+		Where("shop_id = ? AND status = ? AND expires_at < ?", shopID, models.TransferPending, now).
+		Updates(map[string]interface{}{"status": models.TransferExpired, "responded_at": now}).Error)
+}
+
+func (r gormTransferRepository) CancelPendingByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return translateError(r.db.WithContext(ctx).Model(&models.ShopTransfer{}).
+		Where("shop_id = ? AND status = ?", shopID, models.TransferPending).
+		Updates(map[string]interface{}{"status": models.TransferCancelled, "responded_at": at}).Error)
+}
+
+func (r gormTransferRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Where("shop_id = ?", shopID).Delete(&models.ShopTransfer{}).Error)
+}
+
+func (r gormTransferRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&models.ShopTransfer{})
+	return result.RowsAffected, translateError(result.Error)
+}
+
+type gormAuditRepository struct {
+	db *gorm.DB
+}
+
+// Append locks the chain head so concurrent writers link their entries one
+// after the other.
+func (r gormAuditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	return translateError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var head models.AuditChain
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, auditChainID).Error; err != nil {
+			return fmt.Errorf("locking the audit chain: %w", err)
+		}
+
+		linkAuditEntry(entry, head)
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&head).Updates(map[string]interface{}{"seq": entry.Seq, "hash": entry.Hash}).Error
+	}))
+}
+
+func (r gormAuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditEntry{})
+	if filter.ShopIDs != nil {
+		query = query.Where("shop_id IN ?", filter.ShopIDs)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorType != "" {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.BeforeSeq != 0 {
+		query = query.Where("seq < ?", filter.BeforeSeq)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var entries []models.AuditEntry
+	err := query.Order("seq DESC").Find(&entries).Error
+	return entries, translateError(err)
+}
+
+func (r gormAuditRepository) Chain(ctx context.Context, afterSeq uint64, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := r.db.WithContext(ctx).Where("seq > ?", afterSeq).Order("seq").Limit(limit).Find(&entries).Error
+	return entries, translateError(err)
+}
+
+func (r gormAuditRepository) Head(ctx context.Context) (models.AuditChain, error) {
+	var head models.AuditChain
+	err := r.db.WithContext(ctx).First(&head, auditChainID).Error
+	return head, translateError(err)
+}
+
+type gormOutboxRepository struct {
+	db *gorm.DB
+}
+
+func (r gormOutboxRepository) Add(ctx context.Context, event *models.OutboxEvent) error {
+	return translateError(r.db.WithContext(ctx).Create(event).Error)
+}
+
+func (r gormOutboxRepository) Get(ctx context.Context, id uint) (models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	err := r.db.WithContext(ctx).First(&event, id).Error
+	return event, translateError(err)
+}
+
+func (r gormOutboxRepository) ListByShop(ctx context.Context, shopID uint, types []string, afterID uint, limit int) ([]models.OutboxEvent, error) {
+	query := r.db.WithContext(ctx).Where("shop_id = ? AND id > ? AND dead_at IS NULL", shopID, afterID)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+	var events []models.OutboxEvent
+	err := query.Order("id").Limit(limit).Find(&events).Error
+	return events, translateError(err)
+}
+
+// Claim locks the rows it claims so concurrent dispatchers claim one after
+// the other and never see an event both as free. A later event of an
+// aggregate stays unclaimed until the earlier ones are published or dead,
+// whichever dispatcher holds them.
+func (r gormOutboxRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var claimed []models.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("published_at IS NULL AND dead_at IS NULL").
+			Where("next_attempt_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", now, now).
+			Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier
+				WHERE earlier.aggregate_type = outbox_events.aggregate_type AND earlier.aggregate_id = outbox_events.aggregate_id
+				AND earlier.id < outbox_events.id AND earlier.published_at IS NULL AND earlier.dead_at IS NULL)`).
+			Order("id").Limit(limit).Find(&claimed).Error
+		if err != nil {
+			return err
+		}
+
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(claimed))
+		lockedUntil := now.Add(lease)
+		for i := range claimed {
+			ids[i] = claimed[i].ID
+			claimed[i].LockedUntil = &lockedUntil
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("locked_until", lockedUntil).Error
+	})
+	return claimed, translateError(err)
+}
+
+func (r gormOutboxRepository) Save(ctx context.Context, event *models.OutboxEvent) error {
+	return translateError(r.db.WithContext(ctx).Save(event).Error)
+}
+
+func (r gormOutboxRepository) PurgePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("published_at < ?", cutoff).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, translateError(result.Error)
+}
+
+type gormWebhookRepository struct {
+	db *gorm.DB
+}
+
+func (r gormWebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	return translateError(r.db.WithContext(ctx).Create(webhook).Error)
+}
+
+func (r gormWebhookRepository) Get(ctx context.Context, id uint) (models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.WithContext(ctx).First(&webhook, id).Error
+	return webhook, translateError(err)
+}
+
+func (r gormWebhookRepository) ListByShop(ctx context.Context, shopID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).Where("shop_id = ?", shopID).Order("id").Find(&webhooks).Error
+	return webhooks, translateError(err)
+}
+
+func (r gormWebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	return translateError(r.db.WithContext(ctx).Save(webhook).Error)
+}
+
+// Delete relies on the foreign key cascade to remove the deliveries.
+func (r gormWebhookRepository) Delete(ctx context.Context, id uint) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Delete(&models.Webhook{}, id).Error)
+}
+
+func (r gormWebhookRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return translateError(r.db.WithContext(ctx).Unscoped().Where("shop_id = ?", shopID).Delete(&models.Webhook{}).Error)
+}
+
+func (r gormWebhookRepository) PurgeOfShopsDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	db := r.db.WithContext(ctx)
+	expired := db.Unscoped().Model(&models.Shop{}).Select("id").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	result := db.Unscoped().Where("shop_id IN (?)", expired).Delete(&models.Webhook{})
+	return result.RowsAffected, translateError(result.Error)
+}
+
+func (r gormWebhookRepository) RecordSuccess(ctx context.Context, id uint) error {
+	return translateError(r.db.WithContext(ctx).Model(&models.Webhook{}).
+		Where("id = ? AND consecutive_failures > 0", id).
+		Update("consecutive_failures", 0).Error)
+}
+
+// RecordFailure increments the streak in the database so concurrent
+// deliveries to the same webhook all count.
+func (r gormWebhookRepository) RecordFailure(ctx context.Context, id uint, limit int, reason string, at time.Time) (bool, error) {
+	var disabled bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Webhook{}).Where("id = ?", id).
+			Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&models.Webhook{}).
+			Where("id = ? AND active = ? AND consecutive_failures >= ?", id, true, limit).
+			Updates(map[string]interface{}{"active": false, "disabled_at": at, "disabled_reason": reason})
+		disabled = result.RowsAffected > 0
+		return result.Error
+	})
+	return disabled, translateError(err)
+}
+
+type gormWebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func (r gormWebhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return translateError(r.db.WithContext(ctx).Create(delivery).Error)
+}
+
+func (r gormWebhookDeliveryRepository) Get(ctx context.Context, webhookID, id uint) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID).First(&delivery, id).Error
+	return delivery, translateError(err)
+}
+
+func (r gormWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID, beforeID uint, limit int) ([]models.WebhookDelivery, error) {
+	query := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID)
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, translateError(err)
+}
+
+func (r gormWebhookDeliveryRepository) ListByEvent(ctx context.Context, eventID uint) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Order("id").Find(&deliveries).Error
+	return deliveries, translateError(err)
+}
+
+// Claim locks the due rows so concurrent workers claim different deliveries.
+func (r gormWebhookDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var claimed []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		active := tx.Model(&models.Webhook{}).Select("id").Where("active = ?", true)
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			Where("webhook_id IN (?)", active).
+			Order("id").Limit(limit).Find(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(claimed))
+		lockedUntil := now.Add(lease)
+		for i := range claimed {
+			ids[i] = claimed[i].ID
+			claimed[i].LockedUntil = &lockedUntil
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("locked_until", lockedUntil).Error
+	})
+	return claimed, translateError(err)
+}
+
+func (r gormWebhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return translateError(r.db.WithContext(ctx).Save(delivery).Error)
+}
+
+func (r gormWebhookDeliveryRepository) PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("finished_at < ?", cutoff).Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, translateError(result.Error)
+}
+
+type gormJobRepository struct {
+	db *gorm.DB
+}
+
+func (r gormJobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	return translateError(r.db.WithContext(ctx).Create(job).Error)
+}
+
+func (r gormJobRepository) Get(ctx context.Context, id uint) (models.Job, error) {
+	var job models.Job
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	return job, translateError(err)
+}
+
+func (r gormJobRepository) List(ctx context.Context, filter JobFilter) ([]models.Job, error) {
+	query := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	var jobs []models.Job
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&jobs).Error
+	return jobs, translateError(err)
+}
+
+func (r gormJobRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.Job{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, translateError(err)
+}
+
+// Claim locks the runnable rows; where the database supports it, workers skip
+// the rows locked by each other instead of waiting for them.
+func (r gormJobRepository) Claim(ctx context.Context, worker string, types []string, now time.Time, lease time.Duration, limit int) ([]models.Job, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	var claimed []models.Job
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locking := clause.Locking{Strength: "UPDATE"}
+		if database.CapabilitiesOf(tx).SkipLocked {
+			locking.Options = "SKIP LOCKED"
+		}
+		err := tx.Clauses(locking).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)", models.JobQueued, now, models.JobRunning, now).
+			Where("type IN ?", types).
+			Order("run_at, id").Limit(limit).Find(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(claimed))
+		lockedUntil := now.Add(lease)
+		for i := range claimed {
+			ids[i] = claimed[i].ID
+			startJob(&claimed[i], worker, now, lockedUntil)
+		}
+		return tx.Model(&models.Job{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":       models.JobRunning,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"locked_by":    worker,
+			"locked_until": lockedUntil,
+			"started_at":   now,
+		}).Error
+	})
+	return claimed, translateError(err)
+}
+
+func (r gormJobRepository) Extend(ctx context.Context, id uint, worker string, until time.Time) error {
+	return translateError(r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", id, models.JobRunning, worker).
+		Update("locked_until", until).Error)
+}
+
+func (r gormJobRepository) Save(ctx context.Context, job *models.Job) error {
+	return translateError(r.db.WithContext(ctx).Save(job).Error)
+}
+
+func (r gormJobRepository) PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []string{models.JobSucceeded, models.JobCancelled}, cutoff).
+		Delete(&models.Job{})
+	return result.RowsAffected, translateError(result.Error)
+}
+
+type gormJobScheduleRepository struct {
+	db *gorm.DB
+}
+
+func (r gormJobScheduleRepository) Get(ctx context.Context, name string) (models.JobSchedule, error) {
+	var schedule models.JobSchedule
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&schedule).Error
+	return schedule, translateError(err)
+}
+
+func (r gormJobScheduleRepository) List(ctx context.Context) ([]models.JobSchedule, error) {
+	var schedules []models.JobSchedule
+	err := r.db.WithContext(ctx).Order("name").Find(&schedules).Error
+	return schedules, translateError(err)
+}
+
+func (r gormJobScheduleRepository) Init(ctx context.Context, schedule *models.JobSchedule) error {
+	return translateError(r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(schedule).Error)
+}
+
+func (r gormJobScheduleRepository) Advance(ctx context.Context, name string, now, next time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.JobSchedule{}).
+		Where("name = ? AND next_run_at <= ?", name, now).
+		Updates(map[string]interface{}{"next_run_at": next, "last_run_at": now})
+	return result.RowsAffected > 0, translateError(result.Error)
+}
+
+type gormLeaseRepository struct {
+	db *gorm.DB
+}
+
+// Acquire takes over or renews an existing lease with a conditional update, or
+// creates it; when replicas race, the database lets only one of them win.
+func (r gormLeaseRepository) Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	db := r.db.WithContext(ctx)
+	expiresAt := now.Add(ttl)
+	result := db.Model(&models.LeaderLease{}).
+		Where("name = ? AND (holder = ? OR expires_at <= ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": expiresAt})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.RowsAffected > 0, translateError(result.Error)
+	}
+
+	result = db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.LeaderLease{Name: name, Holder: holder, ExpiresAt: expiresAt})
+	return result.RowsAffected > 0, translateError(result.Error)
+}
+
+func (r gormLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	return translateError(r.db.WithContext(ctx).
+		Where("name = ? AND holder = ?", name, holder).
+		Delete(&models.LeaderLease{}).Error)
+}
+
+func (r gormLeaseRepository) Get(ctx context.Context, name string) (models.LeaderLease, error) {
+	var lease models.LeaderLease
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&lease).Error
+	return lease, translateError(err)
+}
+
+type gormRateLimitRepository struct {
+	db *gorm.DB
+}
+
+// Take creates the bucket unless it exists, then refills and takes from it
+// under a row lock, so that the replicas sharing it don't take the same token.
+func (r gormRateLimitRepository) Take(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (models.RateLimitBucket, bool, error) {
+	var bucket models.RateLimitBucket
+	var taken bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RateLimitBucket{Key: key, Tokens: float64(limit), UpdatedAt: now}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&models.RateLimitBucket{Key: key}).First(&bucket).Error
+		if err != nil {
+			return err
+		}
+
+		taken = takeToken(&bucket, limit, window, now)
+		// Not Save, which would stamp updated_at with the time of the database.
+		return tx.Model(&bucket).UpdateColumns(map[string]interface{}{
+			"tokens":     bucket.Tokens,
+			"updated_at": bucket.UpdatedAt,
+		}).Error
+	})
+	return bucket, taken, translateError(err)
+}
+
+func (r gormRateLimitRepository) PurgeIdleBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("updated_at < ?", cutoff).Delete(&models.RateLimitBucket{})
+	return result.RowsAffected, translateError(result.Error)
+}
+
+type gormQuotaRepository struct {
+	db *gorm.DB
+}
+
+// Consume counts the request under a row lock, so that concurrent requests
+// can't exceed the limit together.
+func (r gormQuotaRepository) Consume(ctx context.Context, shopID uint, period string, limit int64) (models.ShopAPIUsage, bool, error) {
+	var usage models.ShopAPIUsage
+	var counted bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.ShopAPIUsage{ShopID: shopID, Period: period}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("shop_id = ? AND period = ?", shopID, period).First(&usage).Error
+		if err != nil || limit > 0 && usage.Requests >= limit {
+			return err
+		}
+
+		usage.Requests++
+		counted = true
+		return tx.Model(&models.ShopAPIUsage{}).Where("shop_id = ? AND period = ?", shopID, period).
+			Update("requests", gorm.Expr("requests + 1")).Error
+	})
+	return usage, counted, translateError(err)
+}
+
+func (r gormQuotaRepository) Get(ctx context.Context, shopID uint, period string) (models.ShopAPIUsage, error) {
+	var usage models.ShopAPIUsage
+	err := r.db.WithContext(ctx).Where("shop_id = ? AND period = ?", shopID, period).First(&usage).Error
+	return usage, translateError(err)
+}
@@ -0,0 +1,66 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/repositories/storetest"
+)
+
+// TestInventoryTrash deletes an inventory with its items and restores it: the
+// item deleted on its own before stays in the trash.
+func TestInventoryTrash(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		shop := createShop(t, store, "trash")
+		inventory, items := createInventory(t, store, shop.ID, 1, 2, 3)
+
+		must(t, store.Items().SoftDelete(ctx, items[0].ID, time.Now().Add(-time.Hour)))
+		deletedAt := time.Now()
+		must(t, store.Items().SoftDeleteByInventory(ctx, inventory.ID, deletedAt))
+		must(t, store.Inventories().SoftDelete(ctx, inventory.ID, deletedAt))
+
+		if _, err := store.Inventories().Get(ctx, inventory.ID); !errors.Is(err, repositories.ErrNotFound) {
+			t.Fatalf("got %v, want the deleted inventory not found", err)
+		}
+		stock, err := store.Items().StockByShop(ctx, shop.ID)
+		must(t, err)
+		if stock != 0 {
+			t.Errorf("got a stock of %d, want 0", stock)
+		}
+		trash, err := store.Inventories().List(ctx, repositories.ListOptions{Deleted: true})
+		must(t, err)
+		if len(trash) != 1 || trash[0].ID != inventory.ID || len(trash[0].Items) != 3 {
+			t.Fatalf("got trash %+v, want the inventory with its 3 items", trash)
+		}
+
+		// Restore with the deletion time read back, as the services do.
+		deleted, err := store.Inventories().GetDeleted(ctx, inventory.ID)
+		must(t, err)
+		must(t, store.Items().RestoreByInventory(ctx, inventory.ID, deleted.DeletedAt.Time))
+		must(t, store.Inventories().Restore(ctx, inventory.ID))
+
+		restored, err := store.Inventories().Get(ctx, inventory.ID)
+		must(t, err)
+		if len(restored.Items) != 2 {
+			t.Errorf("got %d items back, want the 2 deleted with the inventory", len(restored.Items))
+		}
+		stock, err = store.Items().StockByShop(ctx, shop.ID)
+		must(t, err)
+		if stock != 5 {
+			t.Errorf("got a stock of %d, want 5", stock)
+		}
+
+		must(t, store.Items().PurgeByInventory(ctx, inventory.ID))
+		must(t, store.Inventories().Purge(ctx, inventory.ID))
+		if _, err := store.Inventories().GetAny(ctx, inventory.ID); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("got %v, want the purged inventory not found", err)
+		}
+		if _, err := store.Items().GetAny(ctx, items[0].ID); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("got %v, want the items purged with the inventory", err)
+		}
+	})
+}
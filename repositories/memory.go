@@ -0,0 +1,1543 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// MemoryStore is a Store keeping every row in memory. It mirrors the soft
+// delete, unique email and preloading behavior of the GORM store so the API can
+// be exercised without a database. Foreign keys are not enforced.
+//
+// Transactions are serialized: they hold the store's lock until they finish and
+// roll back to a snapshot when they fail.
+type MemoryStore struct {
+	mu   *sync.Mutex
+	data *memoryData
+	// inTx is set on the Store handed to a transaction, which already holds mu.
+	inTx bool
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{mu: &sync.Mutex{}, data: newMemoryData()}
+}
+
+// memoryData holds one table per model.
+type memoryData struct {
+	shops       *memoryTable[models.Shop]
+	owners      *memoryTable[models.ShopOwner]
+	employees   *memoryTable[models.ShopEmployee]
+	customers   *memoryTable[models.Customer]
+	inventories *memoryTable[models.Inventory]
+	items       *memoryTable[models.Item]
+	transfers   *memoryTable[models.ShopTransfer]
+	// audit is append-only, in Seq order.
+	audit     []models.AuditEntry
+	auditHead models.AuditChain
+	// outbox is in ID order.
+	outbox       []models.OutboxEvent
+	nextOutboxID uint
+	webhooks     *memoryTable[models.Webhook]
+	// deliveries is in ID order.
+	deliveries     []models.WebhookDelivery
+	nextDeliveryID uint
+	// jobs is in ID order.
+	jobs      []models.Job
+	nextJobID uint
+	schedules map[string]models.JobSchedule
+	leases    map[string]models.LeaderLease
+	buckets   map[string]models.RateLimitBucket
+	usage     map[shopPeriod]models.ShopAPIUsage
+}
+
+// shopPeriod keys the API usage of a shop in a month.
+type shopPeriod struct {
+	shopID uint
+	period string
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		shops: newMemoryTable(func(s models.Shop) string { return s.Email }, func(s *models.Shop) {
+			s.Owner, s.Employees, s.Inventories = models.ShopOwner{}, nil, nil
+		}),
+		owners:    newMemoryTable(func(o models.ShopOwner) string { return o.Email }, nil),
+		employees: newMemoryTable(func(e models.ShopEmployee) string { return e.Email }, nil),
+		customers: newMemoryTable(func(c models.Customer) string { return c.Email }, nil),
+		inventories: newMemoryTable(nil, func(i *models.Inventory) {
+			i.Items = nil
+		}),
+		items:          newMemoryTable[models.Item](nil, nil),
+		transfers:      newMemoryTable(pendingTransferKey, nil),
+		auditHead:      models.AuditChain{ID: auditChainID},
+		nextOutboxID:   1,
+		webhooks:       newMemoryTable[models.Webhook](nil, nil),
+		nextDeliveryID: 1,
+		nextJobID:      1,
+		schedules:      map[string]models.JobSchedule{},
+		leases:         map[string]models.LeaderLease{},
+		buckets:        map[string]models.RateLimitBucket{},
+		usage:          map[shopPeriod]models.ShopAPIUsage{},
+	}
+}
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		shops:       d.shops.clone(),
+		owners:      d.owners.clone(),
+		employees:   d.employees.clone(),
+		customers:   d.customers.clone(),
+		inventories: d.inventories.clone(),
+		items:       d.items.clone(),
+		transfers:   d.transfers.clone(),
+		audit:       d.audit[:len(d.audit):len(d.audit)],
+		auditHead:   d.auditHead,
+		// Outbox events are updated in place, unlike audit entries.
+		outbox:         slices.Clone(d.outbox),
+		nextOutboxID:   d.nextOutboxID,
+		webhooks:       d.webhooks.clone(),
+		deliveries:     slices.Clone(d.deliveries),
+		nextDeliveryID: d.nextDeliveryID,
+		jobs:           slices.Clone(d.jobs),
+		nextJobID:      d.nextJobID,
+		schedules:      maps.Clone(d.schedules),
+		leases:         maps.Clone(d.leases),
+		buckets:        maps.Clone(d.buckets),
+		usage:          maps.Clone(d.usage),
+	}
+}
+
+// run executes fn under the store's lock, unless the store belongs to a
+// transaction that already holds it.
+func (s *MemoryStore) run(ctx context.Context, fn func(d *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !s.inTx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	return fn(s.data)
+}
+
+// Transaction runs fn while holding the store's lock and restores the previous
+// state if fn fails. Nested transactions join the outer one.
+func (s *MemoryStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	if err := fn(&MemoryStore{mu: s.mu, data: s.data, inTx: true}); err != nil {
+		*s.data = *snapshot
+		return err
+	}
+	return nil
+}
+
+// Shops returns the shop repository.
+func (s *MemoryStore) Shops() ShopRepository {
+	return memoryShopRepository{memoryRepository[models.Shop]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.Shop] { return d.shops },
+		load: func(d *memoryData, shop *models.Shop, _ bool) {
+			shop.Owner, _ = d.owners.get(shop.OwnerID, anyRow)
+		},
+	}}
+}
+
+// Owners returns the shop owner repository.
+func (s *MemoryStore) Owners() ShopOwnerRepository {
+	return memoryOwnerRepository{memoryRepository[models.ShopOwner]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.ShopOwner] { return d.owners },
+	}}
+}
+
+// Employees returns the shop employee repository.
+func (s *MemoryStore) Employees() EmployeeRepository {
+	return memoryEmployeeRepository{memoryRepository[models.ShopEmployee]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.ShopEmployee] { return d.employees },
+	}}
+}
+
+// Customers returns the customer repository.
+func (s *MemoryStore) Customers() CustomerRepository {
+	return memoryCustomerRepository{memoryRepository[models.Customer]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.Customer] { return d.customers },
+	}}
+}
+
+// Inventories returns the inventory repository.
+func (s *MemoryStore) Inventories() InventoryRepository {
+	return memoryInventoryRepository{memoryRepository[models.Inventory]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.Inventory] { return d.inventories },
+		load:  loadInventoryItems,
+	}}
+}
+
+// Items returns the item repository.
+func (s *MemoryStore) Items() ItemRepository {
+	return memoryItemRepository{memoryRepository[models.Item]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.Item] { return d.items },
+	}}
+}
+
+// Audit returns the audit log repository.
+func (s *MemoryStore) Audit() AuditRepository {
+	return memoryAuditRepository{store: s}
+}
+
+// Outbox returns the domain event outbox repository.
+func (s *MemoryStore) Outbox() OutboxRepository {
+	return memoryOutboxRepository{store: s}
+}
+
+// Webhooks returns the webhook repository.
+func (s *MemoryStore) Webhooks() WebhookRepository {
+	return memoryWebhookRepository{memoryRepository[models.Webhook]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.Webhook] { return d.webhooks },
+	}}
+}
+
+// WebhookDeliveries returns the webhook delivery repository.
+func (s *MemoryStore) WebhookDeliveries() WebhookDeliveryRepository {
+	return memoryWebhookDeliveryRepository{store: s}
+}
+
+// Jobs returns the background job repository.
+func (s *MemoryStore) Jobs() JobRepository {
+	return memoryJobRepository{store: s}
+}
+
+// JobSchedules returns the cron schedule repository.
+func (s *MemoryStore) JobSchedules() JobScheduleRepository {
+	return memoryJobScheduleRepository{store: s}
+}
+
+// Leases returns the leader lease repository.
+func (s *MemoryStore) Leases() LeaseRepository {
+	return memoryLeaseRepository{store: s}
+}
+
+// RateLimits returns the rate limit bucket repository.
+func (s *MemoryStore) RateLimits() RateLimitRepository {
+	return memoryRateLimitRepository{store: s}
+}
+
+// Quotas returns the shop API usage repository.
+func (s *MemoryStore) Quotas() QuotaRepository {
+	return memoryQuotaRepository{store: s}
+}
+
+// Transfers returns the shop transfer repository.
+func (s *MemoryStore) Transfers() TransferRepository {
+	return memoryTransferRepository{memoryRepository[models.ShopTransfer]{
+		store: s,
+		table: func(d *memoryData) *memoryTable[models.ShopTransfer] { return d.transfers },
+	}}
+}
+
+// loadInventoryItems attaches an inventory's items; unscoped reads include the deleted ones.
+func loadInventoryItems(d *memoryData, inventory *models.Inventory, unscoped bool) {
+	scope := activeRow
+	if unscoped {
+		scope = anyRow
+	}
+	inventory.Items = d.items.filter(scope, func(item models.Item) bool { return item.InventoryID == inventory.ID })
+}
+
+// rowScope selects rows by their soft delete state.
+type rowScope int
+
+const (
+	activeRow rowScope = iota
+	deletedRow
+	anyRow
+)
+
+func (s rowScope) matches(m *gorm.Model) bool {
+	switch s {
+	case activeRow:
+		return !m.DeletedAt.Valid
+	case deletedRow:
+		return m.DeletedAt.Valid
+	}
+	return true
+}
+
+// modelOf returns the gorm.Model embedded in every model.
+func modelOf[T any](entity *T) *gorm.Model {
+	return reflect.ValueOf(entity).Elem().FieldByName("Model").Addr().Interface().(*gorm.Model)
+}
+
+// memoryTable stores the rows of one model by ID.
+type memoryTable[T any] struct {
+	rows   map[uint]T
+	nextID uint
+	// unique returns the value that must be unique among active rows, if any;
+	// the empty value is exempt.
+	unique func(T) string
+	// strip clears the relations of a row before it is stored.
+	strip func(*T)
+}
+
+func newMemoryTable[T any](unique func(T) string, strip func(*T)) *memoryTable[T] {
+	return &memoryTable[T]{rows: map[uint]T{}, nextID: 1, unique: unique, strip: strip}
+}
+
+func (t *memoryTable[T]) clone() *memoryTable[T] {
+	rows := make(map[uint]T, len(t.rows))
+	for id, row := range t.rows {
+		rows[id] = row
+	}
+	return &memoryTable[T]{rows: rows, nextID: t.nextID, unique: t.unique, strip: t.strip}
+}
+
+// checkUnique reports ErrDuplicate when another active row has the same unique value.
+func (t *memoryTable[T]) checkUnique(entity T, id uint) error {
+	if t.unique == nil {
+		return nil
+	}
+	key := t.unique(entity)
+	if key == "" {
+		return nil
+	}
+	for otherID, row := range t.rows {
+		if otherID != id && !modelOf(&row).DeletedAt.Valid && t.unique(row) == key {
+			return ErrDuplicate
+		}
+	}
+	return nil
+}
+
+func (t *memoryTable[T]) insert(entity *T) error {
+	if err := t.checkUnique(*entity, 0); err != nil {
+		return err
+	}
+
+	m := modelOf(entity)
+	if m.ID == 0 {
+		m.ID = t.nextID
+	}
+	if _, exists := t.rows[m.ID]; exists {
+		return ErrDuplicate
+	}
+	if m.ID >= t.nextID {
+		t.nextID = m.ID + 1
+	}
+	now := time.Now()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	if m.UpdatedAt.IsZero() {
+		m.UpdatedAt = now
+	}
+	t.put(*entity)
+	return nil
+}
+
+// put stores a copy of the row without its relations.
+func (t *memoryTable[T]) put(row T) {
+	if t.strip != nil {
+		t.strip(&row)
+	}
+	t.rows[modelOf(&row).ID] = row
+}
+
+func (t *memoryTable[T]) get(id uint, scope rowScope) (T, bool) {
+	row, ok := t.rows[id]
+	if !ok || !scope.matches(modelOf(&row)) {
+		var zero T
+		return zero, false
+	}
+	return row, true
+}
+
+// filter returns the rows in scope accepted by keep, ordered by ID.
+func (t *memoryTable[T]) filter(scope rowScope, keep func(T) bool) []T {
+	rows := make([]T, 0, len(t.rows))
+	for _, row := range t.rows {
+		if scope.matches(modelOf(&row)) && (keep == nil || keep(row)) {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return modelOf(&rows[i]).ID < modelOf(&rows[j]).ID })
+	return rows
+}
+
+// update applies fn to every row in scope accepted by keep and returns how many changed.
+func (t *memoryTable[T]) update(scope rowScope, keep func(T) bool, fn func(*T)) int64 {
+	var n int64
+	for _, row := range t.filter(scope, keep) {
+		fn(&row)
+		t.rows[modelOf(&row).ID] = row
+		n++
+	}
+	return n
+}
+
+// remove permanently deletes every row in scope accepted by keep.
+func (t *memoryTable[T]) remove(scope rowScope, keep func(T) bool) int64 {
+	var n int64
+	for _, row := range t.filter(scope, keep) {
+		delete(t.rows, modelOf(&row).ID)
+		n++
+	}
+	return n
+}
+
+// softDelete returns an update function setting deleted_at.
+func softDelete[T any](at time.Time) func(*T) {
+	return func(row *T) { modelOf(row).DeletedAt = gorm.DeletedAt{Time: at, Valid: true} }
+}
+
+// restore returns an update function clearing deleted_at.
+func restore[T any]() func(*T) {
+	return func(row *T) { modelOf(row).DeletedAt = gorm.DeletedAt{} }
+}
+
+// deletedAt returns a filter matching rows deleted at exactly the given time.
+func deletedAt[T any](at time.Time) func(T) bool {
+	return func(row T) bool { return modelOf(&row).DeletedAt.Time.Equal(at) }
+}
+
+// memoryRepository implements Repository on top of a memoryTable.
+type memoryRepository[T any] struct {
+	store *MemoryStore
+	table func(*memoryData) *memoryTable[T]
+	// load attaches the relations of a row read with the given scope; nil if none.
+	load func(d *memoryData, row *T, unscoped bool)
+}
+
+func (r memoryRepository[T]) withRelations(d *memoryData, rows []T, unscoped bool) []T {
+	if r.load != nil {
+		for i := range rows {
+			r.load(d, &rows[i], unscoped)
+		}
+	}
+	return rows
+}
+
+func (r memoryRepository[T]) get(ctx context.Context, id uint, scope rowScope) (T, error) {
+	var entity T
+	err := r.store.run(ctx, func(d *memoryData) error {
+		row, ok := r.table(d).get(id, scope)
+		if !ok {
+			return ErrNotFound
+		}
+		entity = r.withRelations(d, []T{row}, scope != activeRow)[0]
+		return nil
+	})
+	return entity, err
+}
+
+func (r memoryRepository[T]) find(ctx context.Context, scope rowScope, keep func(T) bool) ([]T, error) {
+	var rows []T
+	err := r.store.run(ctx, func(d *memoryData) error {
+		rows = r.withRelations(d, r.table(d).filter(scope, keep), scope != activeRow)
+		return nil
+	})
+	return rows, err
+}
+
+func (r memoryRepository[T]) first(ctx context.Context, keep func(T) bool) (T, error) {
+	rows, err := r.find(ctx, activeRow, keep)
+	if err == nil && len(rows) == 0 {
+		err = ErrNotFound
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return rows[0], nil
+}
+
+// change runs fn on the table under the store's lock.
+func (r memoryRepository[T]) change(ctx context.Context, fn func(t *memoryTable[T]) error) error {
+	return r.store.run(ctx, func(d *memoryData) error { return fn(r.table(d)) })
+}
+
+func (r memoryRepository[T]) Create(ctx context.Context, entity *T) error {
+	return r.change(ctx, func(t *memoryTable[T]) error { return t.insert(entity) })
+}
+
+func (r memoryRepository[T]) Get(ctx context.Context, id uint) (T, error) {
+	return r.get(ctx, id, activeRow)
+}
+
+func (r memoryRepository[T]) GetDeleted(ctx context.Context, id uint) (T, error) {
+	return r.get(ctx, id, deletedRow)
+}
+
+func (r memoryRepository[T]) GetAny(ctx context.Context, id uint) (T, error) {
+	return r.get(ctx, id, anyRow)
+}
+
+func (r memoryRepository[T]) List(ctx context.Context, opts ListOptions) ([]T, error) {
+	if opts.Deleted {
+		return r.find(ctx, deletedRow, nil)
+	}
+	return r.find(ctx, activeRow, nil)
+}
+
+func (r memoryRepository[T]) Update(ctx context.Context, entity *T) error {
+	return r.change(ctx, func(t *memoryTable[T]) error {
+		m := modelOf(entity)
+		if _, ok := t.rows[m.ID]; !ok {
+			return t.insert(entity)
+		}
+		if err := t.checkUnique(*entity, m.ID); err != nil {
+			return err
+		}
+		m.UpdatedAt = time.Now()
+		t.put(*entity)
+		return nil
+	})
+}
+
+func (r memoryRepository[T]) SoftDelete(ctx context.Context, id uint, at time.Time) error {
+	return r.change(ctx, func(t *memoryTable[T]) error {
+		t.update(activeRow, byID[T](id), softDelete[T](at))
+		return nil
+	})
+}
+
+func (r memoryRepository[T]) Restore(ctx context.Context, id uint) error {
+	return r.change(ctx, func(t *memoryTable[T]) error {
+		row, ok := t.get(id, deletedRow)
+		if !ok {
+			return nil
+		}
+		// Mirrors the partial unique index rejecting the restored row.
+		if err := t.checkUnique(row, id); err != nil {
+			return err
+		}
+		t.update(deletedRow, byID[T](id), restore[T]())
+		return nil
+	})
+}
+
+func (r memoryRepository[T]) Purge(ctx context.Context, id uint) error {
+	return r.change(ctx, func(t *memoryTable[T]) error {
+		t.remove(anyRow, byID[T](id))
+		return nil
+	})
+}
+
+func (r memoryRepository[T]) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var n int64
+	err := r.change(ctx, func(t *memoryTable[T]) error {
+		n = t.remove(deletedRow, func(row T) bool { return modelOf(&row).DeletedAt.Time.Before(cutoff) })
+		return nil
+	})
+	return n, err
+}
+
+func byID[T any](id uint) func(T) bool {
+	return func(row T) bool { return modelOf(&row).ID == id }
+}
+
+func byEmail[T any](email func(T) string, value string) func(T) bool {
+	return func(row T) bool { return email(row) == value }
+}
+
+type memoryShopRepository struct {
+	memoryRepository[models.Shop]
+}
+
+func (r memoryShopRepository) GetWithRelations(ctx context.Context, id uint, relations ShopRelations) (models.Shop, error) {
+	var shop models.Shop
+	err := r.store.run(ctx, func(d *memoryData) error {
+		var ok bool
+		if shop, ok = d.shops.get(id, activeRow); !ok {
+			return ErrNotFound
+		}
+		r.load(d, &shop, false)
+
+		if relations.Employees {
+			shop.Employees = d.employees.filter(activeRow, func(e models.ShopEmployee) bool { return e.ShopID == id })
+		}
+		if relations.Inventories || relations.Items {
+			shop.Inventories = d.inventories.filter(activeRow, func(i models.Inventory) bool { return i.ShopID == id })
+			if relations.Items {
+				for i := range shop.Inventories {
+					loadInventoryItems(d, &shop.Inventories[i], false)
+				}
+			}
+		}
+		return nil
+	})
+	return shop, err
+}
+
+func (r memoryShopRepository) FindByEmail(ctx context.Context, email string) (models.Shop, error) {
+	return r.first(ctx, byEmail(func(s models.Shop) string { return s.Email }, email))
+}
+
+func (r memoryShopRepository) ListByOwner(ctx context.Context, ownerID uint) ([]models.Shop, error) {
+	return r.find(ctx, activeRow, func(s models.Shop) bool { return s.OwnerID == ownerID })
+}
+
+func (r memoryShopRepository) ChangeOwner(ctx context.Context, shopID, fromOwnerID, toOwnerID uint) (bool, error) {
+	var changed int64
+	err := r.change(ctx, func(t *memoryTable[models.Shop]) error {
+		changed = t.update(activeRow, func(s models.Shop) bool { return s.ID == shopID && s.OwnerID == fromOwnerID }, func(s *models.Shop) {
+			s.OwnerID = toOwnerID
+			s.UpdatedAt = time.Now()
+		})
+		return nil
+	})
+	return changed > 0, err
+}
+
+type memoryOwnerRepository struct {
+	memoryRepository[models.ShopOwner]
+}
+
+func (r memoryOwnerRepository) FindByEmail(ctx context.Context, email string) (models.ShopOwner, error) {
+	return r.first(ctx, byEmail(func(o models.ShopOwner) string { return o.Email }, email))
+}
+
+type memoryEmployeeRepository struct {
+	memoryRepository[models.ShopEmployee]
+}
+
+func (r memoryEmployeeRepository) FindByEmail(ctx context.Context, email string) (models.ShopEmployee, error) {
+	return r.first(ctx, byEmail(func(e models.ShopEmployee) string { return e.Email }, email))
+}
+
+func (r memoryEmployeeRepository) SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return r.change(ctx, func(t *memoryTable[models.ShopEmployee]) error {
+		t.update(activeRow, func(e models.ShopEmployee) bool { return e.ShopID == shopID }, softDelete[models.ShopEmployee](at))
+		return nil
+	})
+}
+
+func (r memoryEmployeeRepository) RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error {
+	return r.change(ctx, func(t *memoryTable[models.ShopEmployee]) error {
+		t.update(deletedRow, func(e models.ShopEmployee) bool {
+			return e.ShopID == shopID && e.DeletedAt.Time.Equal(deletedAt)
+		}, restore[models.ShopEmployee]())
+		return nil
+	})
+}
+
+func (r memoryEmployeeRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return r.change(ctx, func(t *memoryTable[models.ShopEmployee]) error {
+		t.remove(anyRow, func(e models.ShopEmployee) bool { return e.ShopID == shopID })
+		return nil
+	})
+}
+
+type memoryCustomerRepository struct {
+	memoryRepository[models.Customer]
+}
+
+func (r memoryCustomerRepository) FindByEmail(ctx context.Context, email string) (models.Customer, error) {
+	return r.first(ctx, byEmail(func(c models.Customer) string { return c.Email }, email))
+}
+
+type memoryInventoryRepository struct {
+	memoryRepository[models.Inventory]
+}
+
+func (r memoryInventoryRepository) SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return r.change(ctx, func(t *memoryTable[models.Inventory]) error {
+		t.update(activeRow, func(i models.Inventory) bool { return i.ShopID == shopID }, softDelete[models.Inventory](at))
+		return nil
+	})
+}
+
+func (r memoryInventoryRepository) RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error {
+	return r.change(ctx, func(t *memoryTable[models.Inventory]) error {
+		t.update(deletedRow, func(i models.Inventory) bool {
+			return i.ShopID == shopID && i.DeletedAt.Time.Equal(deletedAt)
+		}, restore[models.Inventory]())
+		return nil
+	})
+}
+
+func (r memoryInventoryRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return r.change(ctx, func(t *memoryTable[models.Inventory]) error {
+		t.remove(anyRow, func(i models.Inventory) bool { return i.ShopID == shopID })
+		return nil
+	})
+}
+
+type memoryItemRepository struct {
+	memoryRepository[models.Item]
+}
+
+// inInventories returns a filter matching items of the shop's inventories in scope
+// that are also accepted by keep.
+func inInventories(d *memoryData, shopID uint, scope rowScope, keep func(models.Inventory) bool) func(models.Item) bool {
+	ids := map[uint]bool{}
+	for _, inventory := range d.inventories.filter(scope, func(i models.Inventory) bool {
+		return i.ShopID == shopID && (keep == nil || keep(i))
+	}) {
+		ids[inventory.ID] = true
+	}
+	return func(item models.Item) bool { return ids[item.InventoryID] }
+}
+
+func (r memoryItemRepository) SoftDeleteByInventory(ctx context.Context, inventoryID uint, at time.Time) error {
+	return r.change(ctx, func(t *memoryTable[models.Item]) error {
+		t.update(activeRow, func(i models.Item) bool { return i.InventoryID == inventoryID }, softDelete[models.Item](at))
+		return nil
+	})
+}
+
+func (r memoryItemRepository) RestoreByInventory(ctx context.Context, inventoryID uint, at time.Time) error {
+	return r.change(ctx, func(t *memoryTable[models.Item]) error {
+		t.update(deletedRow, func(i models.Item) bool {
+			return i.InventoryID == inventoryID && i.DeletedAt.Time.Equal(at)
+		}, restore[models.Item]())
+		return nil
+	})
+}
+
+func (r memoryItemRepository) PurgeByInventory(ctx context.Context, inventoryID uint) error {
+	return r.change(ctx, func(t *memoryTable[models.Item]) error {
+		t.remove(anyRow, func(i models.Item) bool { return i.InventoryID == inventoryID })
+		return nil
+	})
+}
+
+func (r memoryItemRepository) SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		d.items.update(activeRow, inInventories(d, shopID, activeRow, nil), softDelete[models.Item](at))
+		return nil
+	})
+}
+
+func (r memoryItemRepository) RestoreByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		inShop := inInventories(d, shopID, deletedRow, deletedAt[models.Inventory](at))
+		d.items.update(deletedRow, func(i models.Item) bool {
+			return inShop(i) && i.DeletedAt.Time.Equal(at)
+		}, restore[models.Item]())
+		return nil
+	})
+}
+
+func (r memoryItemRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		d.items.remove(anyRow, inInventories(d, shopID, anyRow, nil))
+		return nil
+	})
+}
+
+func (r memoryItemRepository) StockByShop(ctx context.Context, shopID uint) (int64, error) {
+	var stock int64
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for _, item := range d.items.filter(activeRow, inInventories(d, shopID, activeRow, nil)) {
+			stock += int64(item.Quantity)
+		}
+		return nil
+	})
+	return stock, err
+}
+
+func (r memoryItemRepository) CountBelow(ctx context.Context, quantity int) (int64, error) {
+	var count int64
+	err := r.store.run(ctx, func(d *memoryData) error {
+		active := map[uint]bool{}
+		for _, inventory := range d.inventories.filter(activeRow, nil) {
+			active[inventory.ID] = true
+		}
+		count = int64(len(d.items.filter(activeRow, func(item models.Item) bool {
+			return active[item.InventoryID] && item.Quantity < quantity
+		})))
+		return nil
+	})
+	return count, err
+}
+
+type memoryTransferRepository struct {
+	memoryRepository[models.ShopTransfer]
+}
+
+func (r memoryTransferRepository) Get(ctx context.Context, shopID, id uint) (models.ShopTransfer, error) {
+	return r.first(ctx, func(t models.ShopTransfer) bool { return t.ID == id && t.ShopID == shopID })
+}
+
+func (r memoryTransferRepository) ListByOwner(ctx context.Context, ownerID uint) ([]models.ShopTransfer, error) {
+	transfers, err := r.find(ctx, activeRow, func(t models.ShopTransfer) bool {
+		return t.FromOwnerID == ownerID || t.ToOwnerID == ownerID
+	})
+	// Newest first.
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].CreatedAt.After(transfers[j].CreatedAt) ||
+			transfers[i].CreatedAt.Equal(transfers[j].CreatedAt) && transfers[i].ID > transfers[j].ID
+	})
+	return transfers, err
+}
+
+func (r memoryTransferRepository) CountPending(ctx context.Context, shopID uint) (int64, error) {
+	transfers, err := r.find(ctx, activeRow, pendingOf(shopID))
+	return int64(len(transfers)), err
+}
+
+func (r memoryTransferRepository) SetStatus(ctx context.Context, transfer *models.ShopTransfer, status string, at time.Time) (bool, error) {
+	var changed int64
+	err := r.change(ctx, func(t *memoryTable[models.ShopTransfer]) error {
+		changed = t.update(activeRow, func(row models.ShopTransfer) bool {
+			return row.ID == transfer.ID && row.Status == models.TransferPending
+		}, respond(status, at))
+		return nil
+	})
+	if err != nil || changed == 0 {
+		return false, err
+	}
+	transfer.Status = status
+	transfer.RespondedAt = &at
+	return true, nil
+}
+
+func (r memoryTransferRepository) ExpirePending(ctx context.Context, shopID uint, now time.Time) error {
+	pending := pendingOf(shopID)
+	return r.change(ctx, func(t *memoryTable[models.ShopTransfer]) error {
+		t.update(activeRow, func(row models.ShopTransfer) bool {
+			return pending(row) && row.ExpiresAt.Before(now)
+		}, respond(models.TransferExpired, now))
+		return nil
+	})
+}
+
+func (r memoryTransferRepository) CancelPendingByShop(ctx context.Context, shopID uint, at time.Time) error {
+	return r.change(ctx, func(t *memoryTable[models.ShopTransfer]) error {
+		t.update(activeRow, pendingOf(shopID), respond(models.TransferCancelled, at))
+		return nil
+	})
+}
+
+func (r memoryTransferRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return r.change(ctx, func(t *memoryTable[models.ShopTransfer]) error {
+		t.remove(anyRow, func(row models.ShopTransfer) bool { return row.ShopID == shopID })
+		return nil
+	})
+}
+
+// pendingTransferKey makes the pending transfer of a shop unique, like the
+// index of the databases.
+func pendingTransferKey(t models.ShopTransfer) string {
+	if t.Status != models.TransferPending {
+		return ""
+	}
+	return strconv.FormatUint(uint64(t.ShopID), 10)
+}
+
+func pendingOf(shopID uint) func(models.ShopTransfer) bool {
+	return func(t models.ShopTransfer) bool { return t.ShopID == shopID && t.Status == models.TransferPending }
+}
+
+func respond(status string, at time.Time) func(*models.ShopTransfer) {
+	return func(t *models.ShopTransfer) {
+		t.Status, t.RespondedAt, t.UpdatedAt = status, &at, time.Now()
+	}
+}
+
+type memoryAuditRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryAuditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		linkAuditEntry(entry, d.auditHead)
+		entry.ID = uint(entry.Seq)
+		d.audit = append(d.audit, *entry)
+		d.auditHead.Seq, d.auditHead.Hash = entry.Seq, entry.Hash
+		return nil
+	})
+}
+
+func (r memoryAuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	matches := func(e models.AuditEntry) bool {
+		switch {
+		case filter.ShopIDs != nil && (e.ShopID == nil || !slices.Contains(filter.ShopIDs, *e.ShopID)),
+			filter.EntityType != "" && e.EntityType != filter.EntityType,
+			filter.EntityID != 0 && e.EntityID != filter.EntityID,
+			filter.ActorType != "" && e.ActorType != filter.ActorType,
+			filter.ActorID != 0 && (e.ActorID == nil || *e.ActorID != filter.ActorID),
+			!filter.From.IsZero() && e.CreatedAt.Before(filter.From),
+			!filter.To.IsZero() && !e.CreatedAt.Before(filter.To),
+			filter.BeforeSeq != 0 && e.Seq >= filter.BeforeSeq:
+			return false
+		}
+		return true
+	}
+
+	var entries []models.AuditEntry
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for i := len(d.audit) - 1; i >= 0; i-- {
+			if filter.Limit > 0 && len(entries) == filter.Limit {
+				break
+			}
+			if matches(d.audit[i]) {
+				entries = append(entries, d.audit[i])
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (r memoryAuditRepository) Chain(ctx context.Context, afterSeq uint64, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := r.store.run(ctx, func(d *memoryData) error {
+		// Seq n is stored at index n-1.
+		start := min(int(afterSeq), len(d.audit))
+		end := min(start+limit, len(d.audit))
+		entries = append(entries, d.audit[start:end]...)
+		return nil
+	})
+	return entries, err
+}
+
+func (r memoryAuditRepository) Head(ctx context.Context) (models.AuditChain, error) {
+	var head models.AuditChain
+	err := r.store.run(ctx, func(d *memoryData) error {
+		head = d.auditHead
+		return nil
+	})
+	return head, err
+}
+
+type memoryOutboxRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryOutboxRepository) Add(ctx context.Context, event *models.OutboxEvent) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		event.ID = d.nextOutboxID
+		d.nextOutboxID++
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = time.Now()
+		}
+		d.outbox = append(d.outbox, *event)
+		return nil
+	})
+}
+
+func (r memoryOutboxRepository) Get(ctx context.Context, id uint) (models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	err := r.store.run(ctx, func(d *memoryData) error {
+		index := r.index(d, id)
+		if index < 0 {
+			return ErrNotFound
+		}
+		event = d.outbox[index]
+		return nil
+	})
+	return event, err
+}
+
+func (r memoryOutboxRepository) ListByShop(ctx context.Context, shopID uint, types []string, afterID uint, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for _, event := range d.outbox {
+			if len(events) == limit {
+				break
+			}
+			if event.ID <= afterID || event.DeadAt != nil || event.ShopID == nil || *event.ShopID != shopID {
+				continue
+			}
+			if len(types) == 0 || slices.Contains(types, event.Type) {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (r memoryOutboxRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var claimed []models.OutboxEvent
+	err := r.store.run(ctx, func(d *memoryData) error {
+		var pending []models.OutboxEvent
+		for _, event := range d.outbox {
+			if event.Pending() {
+				pending = append(pending, event)
+			}
+		}
+
+		lockedUntil := now.Add(lease)
+		claimed = claimable(pending, now, limit)
+		for i := range claimed {
+			claimed[i].LockedUntil = &lockedUntil
+			if index := r.index(d, claimed[i].ID); index >= 0 {
+				d.outbox[index].LockedUntil = &lockedUntil
+			}
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+// claimable returns the events of pending, in ID order, that can be claimed at
+// now: the oldest of their aggregate, due and not claimed by another
+// dispatcher.
+func claimable(pending []models.OutboxEvent, now time.Time, limit int) []models.OutboxEvent {
+	type aggregate struct {
+		typ string
+		id  uint
+	}
+	seen := map[aggregate]bool{}
+
+	var claimed []models.OutboxEvent
+	for _, event := range pending {
+		if len(claimed) == limit {
+			break
+		}
+		key := aggregate{event.AggregateType, event.AggregateID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if event.NextAttemptAt.After(now) || event.LockedUntil != nil && event.LockedUntil.After(now) {
+			continue
+		}
+		claimed = append(claimed, event)
+	}
+	return claimed
+}
+
+func (r memoryOutboxRepository) Save(ctx context.Context, event *models.OutboxEvent) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		index := r.index(d, event.ID)
+		if index < 0 {
+			return ErrNotFound
+		}
+		d.outbox[index] = *event
+		return nil
+	})
+}
+
+func (r memoryOutboxRepository) PurgePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.store.run(ctx, func(d *memoryData) error {
+		d.outbox = slices.DeleteFunc(d.outbox, func(event models.OutboxEvent) bool {
+			if event.PublishedAt != nil && event.PublishedAt.Before(cutoff) {
+				purged++
+				return true
+			}
+			return false
+		})
+		return nil
+	})
+	return purged, err
+}
+
+// index returns the position of the event with id in the outbox, or -1.
+func (memoryOutboxRepository) index(d *memoryData, id uint) int {
+	index, found := slices.BinarySearchFunc(d.outbox, id, func(event models.OutboxEvent, id uint) int {
+		return cmp.Compare(event.ID, id)
+	})
+	if !found {
+		return -1
+	}
+	return index
+}
+
+type memoryWebhookRepository struct {
+	memoryRepository[models.Webhook]
+}
+
+func (r memoryWebhookRepository) ListByShop(ctx context.Context, shopID uint) ([]models.Webhook, error) {
+	return r.find(ctx, activeRow, func(w models.Webhook) bool { return w.ShopID == shopID })
+}
+
+func (r memoryWebhookRepository) Delete(ctx context.Context, id uint) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		removeWebhooks(d, byID[models.Webhook](id))
+		return nil
+	})
+}
+
+func (r memoryWebhookRepository) PurgeByShop(ctx context.Context, shopID uint) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		removeWebhooks(d, func(w models.Webhook) bool { return w.ShopID == shopID })
+		return nil
+	})
+}
+
+func (r memoryWebhookRepository) PurgeOfShopsDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.store.run(ctx, func(d *memoryData) error {
+		expired := map[uint]bool{}
+		for _, shop := range d.shops.filter(deletedRow, func(shop models.Shop) bool { return shop.DeletedAt.Time.Before(cutoff) }) {
+			expired[shop.ID] = true
+		}
+		purged = removeWebhooks(d, func(w models.Webhook) bool { return expired[w.ShopID] })
+		return nil
+	})
+	return purged, err
+}
+
+// removeWebhooks deletes the matching webhooks and, like the foreign key
+// cascade, their deliveries.
+func removeWebhooks(d *memoryData, keep func(models.Webhook) bool) int64 {
+	removed := map[uint]bool{}
+	for _, webhook := range d.webhooks.filter(anyRow, keep) {
+		removed[webhook.ID] = true
+	}
+	d.deliveries = slices.DeleteFunc(d.deliveries, func(delivery models.WebhookDelivery) bool {
+		return removed[delivery.WebhookID]
+	})
+	return d.webhooks.remove(anyRow, keep)
+}
+
+func (r memoryWebhookRepository) RecordSuccess(ctx context.Context, id uint) error {
+	return r.change(ctx, func(t *memoryTable[models.Webhook]) error {
+		t.update(anyRow, byID[models.Webhook](id), func(w *models.Webhook) { w.ConsecutiveFailures = 0 })
+		return nil
+	})
+}
+
+func (r memoryWebhookRepository) RecordFailure(ctx context.Context, id uint, limit int, reason string, at time.Time) (bool, error) {
+	var disabled bool
+	err := r.change(ctx, func(t *memoryTable[models.Webhook]) error {
+		t.update(anyRow, byID[models.Webhook](id), func(w *models.Webhook) {
+			w.ConsecutiveFailures++
+			if w.Active && w.ConsecutiveFailures >= limit {
+				w.Active, w.DisabledAt, w.DisabledReason = false, &at, reason
+				disabled = true
+			}
+		})
+		return nil
+	})
+	return disabled, err
+}
+
+type memoryWebhookDeliveryRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryWebhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		delivery.ID = d.nextDeliveryID
+		d.nextDeliveryID++
+		if delivery.CreatedAt.IsZero() {
+			delivery.CreatedAt = time.Now()
+		}
+		d.deliveries = append(d.deliveries, *delivery)
+		return nil
+	})
+}
+
+func (r memoryWebhookDeliveryRepository) Get(ctx context.Context, webhookID, id uint) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.store.run(ctx, func(d *memoryData) error {
+		index := r.index(d, id)
+		if index < 0 || d.deliveries[index].WebhookID != webhookID {
+			return ErrNotFound
+		}
+		delivery = d.deliveries[index]
+		return nil
+	})
+	return delivery, err
+}
+
+func (r memoryWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID, beforeID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for i := len(d.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+			delivery := d.deliveries[i]
+			if delivery.WebhookID == webhookID && (beforeID == 0 || delivery.ID < beforeID) {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func (r memoryWebhookDeliveryRepository) ListByEvent(ctx context.Context, eventID uint) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for _, delivery := range d.deliveries {
+			if delivery.EventID == eventID {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func (r memoryWebhookDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var claimed []models.WebhookDelivery
+	err := r.store.run(ctx, func(d *memoryData) error {
+		lockedUntil := now.Add(lease)
+		for i := range d.deliveries {
+			if len(claimed) == limit {
+				break
+			}
+			delivery := &d.deliveries[i]
+			webhook, ok := d.webhooks.get(delivery.WebhookID, activeRow)
+			if !ok || !webhook.Active || !dueDelivery(*delivery, now) {
+				continue
+			}
+			delivery.LockedUntil = &lockedUntil
+			claimed = append(claimed, *delivery)
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func (r memoryWebhookDeliveryRepository) Save(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		index := r.index(d, delivery.ID)
+		if index < 0 {
+			return ErrNotFound
+		}
+		d.deliveries[index] = *delivery
+		return nil
+	})
+}
+
+func (r memoryWebhookDeliveryRepository) PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.store.run(ctx, func(d *memoryData) error {
+		d.deliveries = slices.DeleteFunc(d.deliveries, func(delivery models.WebhookDelivery) bool {
+			if delivery.FinishedAt != nil && delivery.FinishedAt.Before(cutoff) {
+				purged++
+				return true
+			}
+			return false
+		})
+		return nil
+	})
+	return purged, err
+}
+
+// index returns the position of the delivery with id, or -1.
+func (memoryWebhookDeliveryRepository) index(d *memoryData, id uint) int {
+	index, found := slices.BinarySearchFunc(d.deliveries, id, func(delivery models.WebhookDelivery, id uint) int {
+		return cmp.Compare(delivery.ID, id)
+	})
+	if !found {
+		return -1
+	}
+	return index
+}
+
+// dueDelivery reports whether a delivery can be claimed at now.
+func dueDelivery(delivery models.WebhookDelivery, now time.Time) bool {
+	return delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) &&
+		(delivery.LockedUntil == nil || !delivery.LockedUntil.After(now))
+}
+
+type memoryJobRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryJobRepository) Enqueue(ctx context.Context, job *models.Job) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		job.ID = d.nextJobID
+		d.nextJobID++
+		now := time.Now()
+		if job.CreatedAt.IsZero() {
+			job.CreatedAt = now
+		}
+		job.UpdatedAt = now
+		d.jobs = append(d.jobs, *job)
+		return nil
+	})
+}
+
+func (r memoryJobRepository) Get(ctx context.Context, id uint) (models.Job, error) {
+	var job models.Job
+	err := r.store.run(ctx, func(d *memoryData) error {
+		index := r.index(d, id)
+		if index < 0 {
+			return ErrNotFound
+		}
+		job = d.jobs[index]
+		return nil
+	})
+	return job, err
+}
+
+func (r memoryJobRepository) List(ctx context.Context, filter JobFilter) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for i := len(d.jobs) - 1; i >= 0 && len(jobs) < filter.Limit; i-- {
+			job := d.jobs[i]
+			if (filter.Status == "" || job.Status == filter.Status) &&
+				(filter.Type == "" || job.Type == filter.Type) &&
+				(filter.BeforeID == 0 || job.ID < filter.BeforeID) {
+				jobs = append(jobs, job)
+			}
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+func (r memoryJobRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for _, job := range d.jobs {
+			counts[job.Status]++
+		}
+		return nil
+	})
+	return counts, err
+}
+
+func (r memoryJobRepository) Claim(ctx context.Context, worker string, types []string, now time.Time, lease time.Duration, limit int) ([]models.Job, error) {
+	var claimed []models.Job
+	err := r.store.run(ctx, func(d *memoryData) error {
+		var runnable []int
+		for i, job := range d.jobs {
+			due := job.Status == models.JobQueued && !job.RunAt.After(now) ||
+				job.Status == models.JobRunning && job.LockedUntil != nil && !job.LockedUntil.After(now)
+			if due && slices.Contains(types, job.Type) {
+				runnable = append(runnable, i)
+			}
+		}
+		slices.SortStableFunc(runnable, func(a, b int) int { return d.jobs[a].RunAt.Compare(d.jobs[b].RunAt) })
+
+		lockedUntil := now.Add(lease)
+		for _, i := range runnable[:min(limit, len(runnable))] {
+			startJob(&d.jobs[i], worker, now, lockedUntil)
+			d.jobs[i].UpdatedAt = now
+			claimed = append(claimed, d.jobs[i])
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func (r memoryJobRepository) Extend(ctx context.Context, id uint, worker string, until time.Time) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		if index := r.index(d, id); index >= 0 && d.jobs[index].Status == models.JobRunning && d.jobs[index].LockedBy == worker {
+			d.jobs[index].LockedUntil = &until
+		}
+		return nil
+	})
+}
+
+func (r memoryJobRepository) Save(ctx context.Context, job *models.Job) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		index := r.index(d, job.ID)
+		if index < 0 {
+			return ErrNotFound
+		}
+		job.UpdatedAt = time.Now()
+		d.jobs[index] = *job
+		return nil
+	})
+}
+
+func (r memoryJobRepository) PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.store.run(ctx, func(d *memoryData) error {
+		d.jobs = slices.DeleteFunc(d.jobs, func(job models.Job) bool {
+			if (job.Status == models.JobSucceeded || job.Status == models.JobCancelled) && job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+				purged++
+				return true
+			}
+			return false
+		})
+		return nil
+	})
+	return purged, err
+}
+
+// index returns the position of the job with id, or -1.
+func (memoryJobRepository) index(d *memoryData, id uint) int {
+	index, found := slices.BinarySearchFunc(d.jobs, id, func(job models.Job, id uint) int {
+		return cmp.Compare(job.ID, id)
+	})
+	if !found {
+		return -1
+	}
+	return index
+}
+
+type memoryJobScheduleRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryJobScheduleRepository) Get(ctx context.Context, name string) (models.JobSchedule, error) {
+	var schedule models.JobSchedule
+	err := r.store.run(ctx, func(d *memoryData) error {
+		var ok bool
+		if schedule, ok = d.schedules[name]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return schedule, err
+}
+
+func (r memoryJobScheduleRepository) List(ctx context.Context) ([]models.JobSchedule, error) {
+	var schedules []models.JobSchedule
+	err := r.store.run(ctx, func(d *memoryData) error {
+		for _, name := range slices.Sorted(maps.Keys(d.schedules)) {
+			schedules = append(schedules, d.schedules[name])
+		}
+		return nil
+	})
+	return schedules, err
+}
+
+func (r memoryJobScheduleRepository) Init(ctx context.Context, schedule *models.JobSchedule) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		if _, ok := d.schedules[schedule.Name]; !ok {
+			d.schedules[schedule.Name] = *schedule
+		}
+		return nil
+	})
+}
+
+func (r memoryJobScheduleRepository) Advance(ctx context.Context, name string, now, next time.Time) (bool, error) {
+	var advanced bool
+	err := r.store.run(ctx, func(d *memoryData) error {
+		schedule, ok := d.schedules[name]
+		if !ok || schedule.NextRunAt.After(now) {
+			return nil
+		}
+		schedule.NextRunAt, schedule.LastRunAt = next, &now
+		d.schedules[name] = schedule
+		advanced = true
+		return nil
+	})
+	return advanced, err
+}
+
+type memoryLeaseRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryLeaseRepository) Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	var acquired bool
+	err := r.store.run(ctx, func(d *memoryData) error {
+		lease, ok := d.leases[name]
+		if ok && lease.Holder != holder && lease.ExpiresAt.After(now) {
+			return nil
+		}
+		d.leases[name] = models.LeaderLease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+		acquired = true
+		return nil
+	})
+	return acquired, err
+}
+
+func (r memoryLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	return r.store.run(ctx, func(d *memoryData) error {
+		if d.leases[name].Holder == holder {
+			delete(d.leases, name)
+		}
+		return nil
+	})
+}
+
+func (r memoryLeaseRepository) Get(ctx context.Context, name string) (models.LeaderLease, error) {
+	var lease models.LeaderLease
+	err := r.store.run(ctx, func(d *memoryData) error {
+		var ok bool
+		if lease, ok = d.leases[name]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return lease, err
+}
+
+type memoryRateLimitRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryRateLimitRepository) Take(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (models.RateLimitBucket, bool, error) {
+	var bucket models.RateLimitBucket
+	var taken bool
+	err := r.store.run(ctx, func(d *memoryData) error {
+		var ok bool
+		if bucket, ok = d.buckets[key]; !ok {
+			bucket = models.RateLimitBucket{Key: key, Tokens: float64(limit), UpdatedAt: now}
+		}
+		taken = takeToken(&bucket, limit, window, now)
+		d.buckets[key] = bucket
+		return nil
+	})
+	return bucket, taken, err
+}
+
+func (r memoryRateLimitRepository) PurgeIdleBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.store.run(ctx, func(d *memoryData) error {
+		maps.DeleteFunc(d.buckets, func(_ string, bucket models.RateLimitBucket) bool {
+			if bucket.UpdatedAt.Before(cutoff) {
+				purged++
+				return true
+			}
+			return false
+		})
+		return nil
+	})
+	return purged, err
+}
+
+type memoryQuotaRepository struct {
+	store *MemoryStore
+}
+
+func (r memoryQuotaRepository) Consume(ctx context.Context, shopID uint, period string, limit int64) (models.ShopAPIUsage, bool, error) {
+	var usage models.ShopAPIUsage
+	var counted bool
+	err := r.store.run(ctx, func(d *memoryData) error {
+		key := shopPeriod{shopID, period}
+		var ok bool
+		if usage, ok = d.usage[key]; !ok {
+			usage = models.ShopAPIUsage{ShopID: shopID, Period: period}
+		}
+		if limit > 0 && usage.Requests >= limit {
+			return nil
+		}
+		usage.Requests++
+		counted = true
+		d.usage[key] = usage
+		return nil
+	})
+	return usage, counted, err
+}
+
+func (r memoryQuotaRepository) Get(ctx context.Context, shopID uint, period string) (models.ShopAPIUsage, error) {
+	var usage models.ShopAPIUsage
+	err := r.store.run(ctx, func(d *memoryData) error {
+		var ok bool
+		if usage, ok = d.usage[shopPeriod{shopID, period}]; !ok {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return usage, err
+}
@@ -0,0 +1,49 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/repositories/storetest"
+)
+
+func TestOutboxClaimsOldestEventOfEachAggregate(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		now := time.Now()
+		add := func(aggregateID uint, nextAttemptAt time.Time) models.OutboxEvent {
+			t.Helper()
+			event := models.OutboxEvent{
+				CreatedAt: now, Type: "item.updated", AggregateType: models.AuditItem, AggregateID: aggregateID,
+				Payload: "{}", NextAttemptAt: nextAttemptAt,
+			}
+			must(t, store.Outbox().Add(ctx, &event))
+			return event
+		}
+
+		// The head of item 1 waits for a retry, with a long backlog behind it.
+		add(1, now.Add(time.Hour))
+		for range 1200 {
+			add(1, now)
+		}
+		second := add(2, now)
+		add(2, now)
+		third := add(3, now)
+
+		claimed, err := store.Outbox().Claim(ctx, now, time.Minute, 10)
+		must(t, err)
+		if len(claimed) != 2 || claimed[0].ID != second.ID || claimed[1].ID != third.ID {
+			t.Fatalf("got %+v, want the oldest events of items 2 and 3", claimed)
+		}
+
+		// The events behind a claimed one wait for it to be published.
+		claimed, err = store.Outbox().Claim(ctx, now, time.Minute, 10)
+		must(t, err)
+		if len(claimed) != 0 {
+			t.Errorf("got %+v, want nothing claimable", claimed)
+		}
+	})
+}
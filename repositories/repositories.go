@@ -0,0 +1,380 @@
+// Package repositories defines the persistence interfaces of every aggregate
+// together with a GORM implementation and an in-memory one for tests.
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// Errors returned by every repository implementation.
+var (
+	// ErrNotFound is returned when no row matches a lookup.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a write violates a unique constraint.
+	ErrDuplicate = errors.New("duplicate record")
+	// ErrForeignKey is returned when a write references a missing row or
+	// removes a row that is still referenced.
+	ErrForeignKey = errors.New("foreign key violation")
+)
+
+// ListOptions selects which rows a list query returns.
+type ListOptions struct {
+	// Deleted lists soft-deleted rows (the trash) instead of active ones.
+	Deleted bool
+}
+
+// Repository holds the operations shared by every soft-deletable aggregate.
+type Repository[T any] interface {
+	// Create inserts a new row and fills in its ID and timestamps.
+	Create(ctx context.Context, entity *T) error
+	// Get returns an active row by ID.
+	Get(ctx context.Context, id uint) (T, error)
+	// GetDeleted returns a soft-deleted row by ID.
+	GetDeleted(ctx context.Context, id uint) (T, error)
+	// GetAny returns a row by ID whether or not it is deleted.
+	GetAny(ctx context.Context, id uint) (T, error)
+	// List returns every active row, or the trash with opts.Deleted.
+	List(ctx context.Context, opts ListOptions) ([]T, error)
+	// Update saves every column of an existing row.
+	Update(ctx context.Context, entity *T) error
+	// SoftDelete moves a row to the trash with the given deletion time.
+	SoftDelete(ctx context.Context, id uint, at time.Time) error
+	// Restore brings a soft-deleted row back.
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently removes a row.
+	Purge(ctx context.Context, id uint) error
+	// PurgeDeletedBefore permanently removes rows soft-deleted before cutoff.
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ShopRelations selects the relations loaded with a shop in addition to its owner.
+type ShopRelations struct {
+	Employees   bool
+	Inventories bool
+	Items       bool
+}
+
+// ShopRepository stores shops. Shops are always returned with their owner.
+type ShopRepository interface {
+	Repository[models.Shop]
+	GetWithRelations(ctx context.Context, id uint, relations ShopRelations) (models.Shop, error)
+	FindByEmail(ctx context.Context, email string) (models.Shop, error)
+	ListByOwner(ctx context.Context, ownerID uint) ([]models.Shop, error)
+	// ChangeOwner moves a shop from one owner to another and reports whether the
+	// shop was still owned by fromOwnerID.
+	ChangeOwner(ctx context.Context, shopID, fromOwnerID, toOwnerID uint) (bool, error)
+}
+
+// ShopOwnerRepository stores shop owners.
+type ShopOwnerRepository interface {
+	Repository[models.ShopOwner]
+	FindByEmail(ctx context.Context, email string) (models.ShopOwner, error)
+}
+
+// EmployeeRepository stores shop employees.
+type EmployeeRepository interface {
+	Repository[models.ShopEmployee]
+	FindByEmail(ctx context.Context, email string) (models.ShopEmployee, error)
+	SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error
+	RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error
+	PurgeByShop(ctx context.Context, shopID uint) error
+}
+
+// CustomerRepository stores customers.
+type CustomerRepository interface {
+	Repository[models.Customer]
+	FindByEmail(ctx context.Context, email string) (models.Customer, error)
+}
+
+// InventoryRepository stores inventories. Inventories are always returned with
+// their items; trash listings include the deleted items.
+type InventoryRepository interface {
+	Repository[models.Inventory]
+	SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error
+	RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error
+	PurgeByShop(ctx context.Context, shopID uint) error
+}
+
+// ItemRepository stores inventory items.
+type ItemRepository interface {
+	Repository[models.Item]
+	SoftDeleteByInventory(ctx context.Context, inventoryID uint, at time.Time) error
+	RestoreByInventory(ctx context.Context, inventoryID uint, deletedAt time.Time) error
+	PurgeByInventory(ctx context.Context, inventoryID uint) error
+	// The *ByShop variants act on the items of every active inventory of a shop
+	// (RestoreByShop: of every inventory deleted at deletedAt).
+	SoftDeleteByShop(ctx context.Context, shopID uint, at time.Time) error
+	RestoreByShop(ctx context.Context, shopID uint, deletedAt time.Time) error
+	PurgeByShop(ctx context.Context, shopID uint) error
+	// StockByShop sums the quantity of the active items in a shop's active inventories.
+	StockByShop(ctx context.Context, shopID uint) (int64, error)
+	// CountBelow counts the active items of active inventories holding fewer
+	// than quantity.
+	CountBelow(ctx context.Context, quantity int) (int64, error)
+}
+
+// TransferRepository stores shop ownership transfers.
+type TransferRepository interface {
+	Create(ctx context.Context, transfer *models.ShopTransfer) error
+	// Get returns a transfer of the given shop.
+	Get(ctx context.Context, shopID, id uint) (models.ShopTransfer, error)
+	// ListByOwner returns the transfers sent or received by an owner, newest first.
+	ListByOwner(ctx context.Context, ownerID uint) ([]models.ShopTransfer, error)
+	CountPending(ctx context.Context, shopID uint) (int64, error)
+	// SetStatus moves a pending transfer into a final status and reports
+	// whether it was still pending, false leaving it unchanged.
+	SetStatus(ctx context.Context, transfer *models.ShopTransfer, status string, at time.Time) (bool, error)
+	// ExpirePending marks pending transfers of a shop past their deadline as expired.
+	ExpirePending(ctx context.Context, shopID uint, now time.Time) error
+	// CancelPendingByShop cancels every pending transfer of a shop.
+	CancelPendingByShop(ctx context.Context, shopID uint, at time.Time) error
+	PurgeByShop(ctx context.Context, shopID uint) error
+	// PurgeDeletedBefore permanently removes transfers soft-deleted before cutoff.
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// AuditFilter selects audit entries. Zero fields don't filter.
+type AuditFilter struct {
+	// ShopIDs restricts the entries to these shops; nil means every entry.
+	ShopIDs    []uint
+	EntityType string
+	EntityID   uint
+	ActorType  string
+	ActorID    uint
+	From, To   time.Time
+	// BeforeSeq pages backwards: only entries older than this sequence number.
+	BeforeSeq uint64
+	Limit     int
+}
+
+// AuditRepository stores the append-only audit log.
+type AuditRepository interface {
+	// Append links entry to the head of the hash chain (setting Seq, PrevHash
+	// and Hash) and stores it. It must run in the transaction of the change it
+	// records.
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	// List returns the entries matching filter, newest first.
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error)
+	// Chain returns up to limit entries after sequence number afterSeq, oldest first.
+	Chain(ctx context.Context, afterSeq uint64, limit int) ([]models.AuditEntry, error)
+	// Head returns the head of the hash chain.
+	Head(ctx context.Context) (models.AuditChain, error)
+}
+
+// auditChainID is the ID of the single audit_chain row.
+const auditChainID = 1
+
+// linkAuditEntry makes entry the successor of head in the hash chain. The
+// timestamp is truncated to the microsecond precision of the databases so the
+// hash can be verified after a round trip.
+func linkAuditEntry(entry *models.AuditEntry, head models.AuditChain) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+	entry.Seq = head.Seq + 1
+	entry.PrevHash = head.Hash
+	entry.Hash = entry.ComputeHash()
+}
+
+// OutboxRepository stores the transactional outbox of domain events.
+type OutboxRepository interface {
+	// Add stores a new event. It must run in the transaction of the change the
+	// event describes.
+	Add(ctx context.Context, event *models.OutboxEvent) error
+	Get(ctx context.Context, id uint) (models.OutboxEvent, error)
+	// ListByShop returns up to limit events of shopID with an ID above afterID,
+	// oldest first, restricted to types unless it is empty. Dead events are
+	// left out.
+	ListByShop(ctx context.Context, shopID uint, types []string, afterID uint, limit int) ([]models.OutboxEvent, error)
+	// Claim locks up to limit pending events that are due at now for lease and
+	// returns them in ID order. Only the oldest pending event of an aggregate
+	// is claimed, so that dispatchers publish each aggregate in order and the
+	// backlog of one aggregate doesn't hold up the others.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error)
+	// Save stores the delivery state of an event.
+	Save(ctx context.Context, event *models.OutboxEvent) error
+	// PurgePublishedBefore permanently removes events published before cutoff.
+	PurgePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// WebhookRepository stores the webhook endpoints of shops.
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	Get(ctx context.Context, id uint) (models.Webhook, error)
+	ListByShop(ctx context.Context, shopID uint) ([]models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	// Delete permanently removes a webhook and its deliveries.
+	Delete(ctx context.Context, id uint) error
+	// PurgeByShop permanently removes the webhooks of a shop and their deliveries.
+	PurgeByShop(ctx context.Context, shopID uint) error
+	// PurgeOfShopsDeletedBefore permanently removes the webhooks of the shops
+	// soft-deleted before cutoff, ahead of the shops themselves.
+	PurgeOfShopsDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	// RecordSuccess resets the failure streak of a webhook.
+	RecordSuccess(ctx context.Context, id uint) error
+	// RecordFailure extends the failure streak of a webhook and disables it
+	// with reason once the streak reaches limit. It reports whether the webhook
+	// was disabled by this failure.
+	RecordFailure(ctx context.Context, id uint, limit int, reason string, at time.Time) (bool, error)
+}
+
+// WebhookDeliveryRepository stores the deliveries of webhooks.
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error
+	// Get returns a delivery of the given webhook.
+	Get(ctx context.Context, webhookID, id uint) (models.WebhookDelivery, error)
+	// ListByWebhook returns up to limit deliveries of a webhook older than
+	// beforeID (any when zero), newest first.
+	ListByWebhook(ctx context.Context, webhookID, beforeID uint, limit int) ([]models.WebhookDelivery, error)
+	// ListByEvent returns the deliveries of an outbox event.
+	ListByEvent(ctx context.Context, eventID uint) ([]models.WebhookDelivery, error)
+	// Claim locks up to limit pending deliveries of active webhooks that are due
+	// at now for lease and returns them, oldest first.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	// Save stores the state of a delivery.
+	Save(ctx context.Context, delivery *models.WebhookDelivery) error
+	// PurgeFinishedBefore permanently removes deliveries finished before cutoff.
+	PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// JobFilter selects jobs; zero fields match every job.
+type JobFilter struct {
+	Status string
+	Type   string
+	// BeforeID pages backwards: only jobs older than this ID.
+	BeforeID uint
+	Limit    int
+}
+
+// JobRepository stores the background job queue.
+type JobRepository interface {
+	// Enqueue stores a new job. Run it in the transaction of the change that
+	// calls for the job, so that the job only exists if the change does.
+	Enqueue(ctx context.Context, job *models.Job) error
+	Get(ctx context.Context, id uint) (models.Job, error)
+	// List returns the jobs matching filter, newest first.
+	List(ctx context.Context, filter JobFilter) ([]models.Job, error)
+	// CountByStatus returns how many jobs are in each status.
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+	// Claim marks up to limit runnable jobs of the given types as running on
+	// worker until now+lease, counting an attempt, and returns them by run_at.
+	// Runnable jobs are the queued ones due at now and the running ones whose
+	// lease expired because their worker died. Concurrent workers claim
+	// different jobs.
+	Claim(ctx context.Context, worker string, types []string, now time.Time, lease time.Duration, limit int) ([]models.Job, error)
+	// Extend renews the lease of a job still running on worker.
+	Extend(ctx context.Context, id uint, worker string, until time.Time) error
+	// Save stores the state of a job.
+	Save(ctx context.Context, job *models.Job) error
+	// PurgeFinishedBefore permanently removes the succeeded and cancelled jobs
+	// finished before cutoff. Dead jobs are kept for inspection.
+	PurgeFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// startJob marks a claimed job as running on worker until lockedUntil.
+func startJob(job *models.Job, worker string, now, lockedUntil time.Time) {
+	job.Status = models.JobRunning
+	job.Attempts++
+	job.LockedBy = worker
+	job.LockedUntil = &lockedUntil
+	job.StartedAt = &now
+}
+
+// JobScheduleRepository stores the state of the cron schedules.
+type JobScheduleRepository interface {
+	Get(ctx context.Context, name string) (models.JobSchedule, error)
+	List(ctx context.Context) ([]models.JobSchedule, error)
+	// Init stores a new schedule unless it already exists.
+	Init(ctx context.Context, schedule *models.JobSchedule) error
+	// Advance records a run of a schedule due at now and moves its next run to
+	// next. It reports false when the schedule was not due, e.g. because
+	// another replica advanced it first.
+	Advance(ctx context.Context, name string, now, next time.Time) (bool, error)
+}
+
+// LeaseRepository stores the leader leases.
+type LeaseRepository interface {
+	// Acquire gives the lease name to holder until now+ttl, renewing it if
+	// holder already has it, unless another holder's lease is still running.
+	// It reports whether holder has the lease.
+	Acquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	// Release gives up the lease name if holder has it.
+	Release(ctx context.Context, name, holder string) error
+	Get(ctx context.Context, name string) (models.LeaderLease, error)
+}
+
+// RateLimitRepository stores the token buckets of the rate limits.
+type RateLimitRepository interface {
+	// Take refills the bucket key, created full, at limit tokens per window and
+	// takes a token from it at now. It returns the bucket and whether a token
+	// was left. Concurrent requests take different tokens.
+	Take(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (models.RateLimitBucket, bool, error)
+	// PurgeIdleBefore removes the buckets last used before cutoff, which are
+	// full again.
+	PurgeIdleBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// takeToken refills bucket at limit tokens per window, up to limit, for the
+// time since its last refill and takes a token from it if one is left. The
+// clocks of the replicas may disagree, a bucket never goes back in time.
+func takeToken(bucket *models.RateLimitBucket, limit int, window time.Duration, now time.Time) bool {
+	if elapsed := now.Sub(bucket.UpdatedAt); elapsed > 0 {
+		refill := float64(limit) * elapsed.Seconds() / window.Seconds()
+		bucket.Tokens = min(float64(limit), bucket.Tokens+refill)
+		bucket.UpdatedAt = now
+	}
+	bucket.Tokens = min(float64(limit), bucket.Tokens) // The limit may have been lowered
+	if bucket.Tokens < 1 {
+		return false
+	}
+	bucket.Tokens--
+	return true
+}
+
+// QuotaRepository stores the monthly API usage of the shops.
+type QuotaRepository interface {
+	// Consume counts a request for shopID in period unless the shop already
+	// made limit requests then, zero meaning no limit. It returns the usage
+	// and whether the request was counted.
+	Consume(ctx context.Context, shopID uint, period string, limit int64) (models.ShopAPIUsage, bool, error)
+	// Get returns the usage of shopID in period.
+	Get(ctx context.Context, shopID uint, period string) (models.ShopAPIUsage, error)
+}
+
+// Store gives access to every repository and runs units of work atomically.
+type Store interface {
+	Shops() ShopRepository
+	Owners() ShopOwnerRepository
+	Employees() EmployeeRepository
+	Customers() CustomerRepository
+	Inventories() InventoryRepository
+	Items() ItemRepository
+	Transfers() TransferRepository
+	Audit() AuditRepository
+	Outbox() OutboxRepository
+	Webhooks() WebhookRepository
+	WebhookDeliveries() WebhookDeliveryRepository
+	Jobs() JobRepository
+	JobSchedules() JobScheduleRepository
+	Leases() LeaseRepository
+	RateLimits() RateLimitRepository
+	Quotas() QuotaRepository
+
+	// Transaction runs fn with a Store whose operations are committed together,
+	// or rolled back if fn returns an error.
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+// Pool is implemented by stores backed by a database connection pool.
+type Pool interface {
+	// Ping checks that the database is reachable.
+	Ping(ctx context.Context) error
+	// PoolStats returns the connection pool statistics.
+	PoolStats() sql.DBStats
+}
@@ -0,0 +1,95 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/repositories/storetest"
+)
+
+func TestShopEmailUniqueAmongActiveShops(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		shop := createShop(t, store, "unique")
+
+		duplicate := models.Shop{Name: "copy", Email: shop.Email, OwnerID: shop.OwnerID}
+		if err := store.Shops().Create(ctx, &duplicate); !errors.Is(err, repositories.ErrDuplicate) {
+			t.Fatalf("got %v, want ErrDuplicate", err)
+		}
+
+		// A deleted shop frees its email.
+		must(t, store.Shops().SoftDelete(ctx, shop.ID, time.Now()))
+		must(t, store.Shops().Create(ctx, &duplicate))
+		found, err := store.Shops().FindByEmail(ctx, shop.Email)
+		must(t, err)
+		if found.ID != duplicate.ID {
+			t.Errorf("found shop %d, want the active one %d", found.ID, duplicate.ID)
+		}
+	})
+}
+
+func TestShopChangeOwner(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		shop := createShop(t, store, "sold")
+		buyer := createShop(t, store, "buyer")
+
+		changed, err := store.Shops().ChangeOwner(ctx, shop.ID, buyer.OwnerID, buyer.OwnerID)
+		must(t, err)
+		if changed {
+			t.Error("changed the owner from an owner the shop doesn't have")
+		}
+
+		changed, err = store.Shops().ChangeOwner(ctx, shop.ID, shop.OwnerID, buyer.OwnerID)
+		must(t, err)
+		if !changed {
+			t.Fatal("didn't change the owner")
+		}
+		got, err := store.Shops().Get(ctx, shop.ID)
+		must(t, err)
+		if got.OwnerID != buyer.OwnerID || got.Owner.ID != buyer.OwnerID {
+			t.Errorf("got owner %d loaded as %d, want %d", got.OwnerID, got.Owner.ID, buyer.OwnerID)
+		}
+
+		mine, err := store.Shops().ListByOwner(ctx, buyer.OwnerID)
+		must(t, err)
+		if len(mine) != 2 {
+			t.Errorf("got %d shops of the buyer, want 2", len(mine))
+		}
+	})
+}
+
+func TestShopWithRelations(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		shop := createShop(t, store, "relations")
+		inventory, items := createInventory(t, store, shop.ID, 1, 2)
+		deleted, _ := createInventory(t, store, shop.ID, 3)
+		must(t, store.Inventories().SoftDelete(ctx, deleted.ID, time.Now()))
+		must(t, store.Items().SoftDelete(ctx, items[1].ID, time.Now()))
+		employee := models.ShopEmployee{Name: "clerk", Email: "clerk@employees.test", Password: "hash", ShopID: shop.ID}
+		must(t, store.Employees().Create(ctx, &employee))
+
+		got, err := store.Shops().GetWithRelations(ctx, shop.ID, repositories.ShopRelations{Employees: true, Inventories: true, Items: true})
+		must(t, err)
+		if len(got.Employees) != 1 || got.Employees[0].ID != employee.ID {
+			t.Errorf("got employees %+v, want %d", got.Employees, employee.ID)
+		}
+		if len(got.Inventories) != 1 || got.Inventories[0].ID != inventory.ID {
+			t.Fatalf("got inventories %+v, want only the active %d", got.Inventories, inventory.ID)
+		}
+		if active := got.Inventories[0].Items; len(active) != 1 || active[0].ID != items[0].ID {
+			t.Errorf("got items %+v, want only the active %d", active, items[0].ID)
+		}
+
+		got, err = store.Shops().GetWithRelations(ctx, shop.ID, repositories.ShopRelations{})
+		must(t, err)
+		if got.Owner.ID != shop.OwnerID || got.Employees != nil || got.Inventories != nil {
+			t.Errorf("got %+v, want the shop with its owner only", got)
+		}
+	})
+}
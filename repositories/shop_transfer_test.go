@@ -0,0 +1,106 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/repositories/storetest"
+)
+
+func TestTransferOnePendingPerShop(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		shop := createShop(t, store, "handover")
+		buyer := createShop(t, store, "taker")
+		transfer := func(expiresAt time.Time) models.ShopTransfer {
+			return models.ShopTransfer{
+				ShopID: shop.ID, FromOwnerID: shop.OwnerID, ToOwnerID: buyer.OwnerID,
+				Status: models.TransferPending, ExpiresAt: expiresAt,
+			}
+		}
+
+		first := transfer(time.Now().Add(-time.Minute))
+		must(t, store.Transfers().Create(ctx, &first))
+		second := transfer(time.Now().Add(time.Hour))
+		if err := store.Transfers().Create(ctx, &second); !errors.Is(err, repositories.ErrDuplicate) {
+			t.Fatalf("got %v, want ErrDuplicate for a second pending transfer", err)
+		}
+
+		// Once the first expired, another can be pending.
+		must(t, store.Transfers().ExpirePending(ctx, shop.ID, time.Now()))
+		pending, err := store.Transfers().CountPending(ctx, shop.ID)
+		must(t, err)
+		if pending != 0 {
+			t.Fatalf("got %d pending transfers, want the expired one gone", pending)
+		}
+		second = transfer(time.Now().Add(time.Hour))
+		must(t, store.Transfers().Create(ctx, &second))
+
+		expired, err := store.Transfers().Get(ctx, shop.ID, first.ID)
+		must(t, err)
+		if expired.Status != models.TransferExpired || expired.RespondedAt == nil {
+			t.Errorf("got %+v, want the first transfer expired", expired)
+		}
+		received, err := store.Transfers().ListByOwner(ctx, buyer.OwnerID)
+		must(t, err)
+		if len(received) != 2 || received[0].ID != second.ID {
+			t.Errorf("got %+v, want both transfers, newest first", received)
+		}
+	})
+}
+
+func TestTransferRespondedOnce(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		shop := createShop(t, store, "contested")
+		buyer := createShop(t, store, "bidder")
+		transfer := models.ShopTransfer{
+			ShopID: shop.ID, FromOwnerID: shop.OwnerID, ToOwnerID: buyer.OwnerID,
+			Status: models.TransferPending, ExpiresAt: time.Now().Add(time.Hour),
+		}
+		must(t, store.Transfers().Create(ctx, &transfer))
+
+		// An accept and a cancel race, each on its own copy of the pending
+		// transfer.
+		statuses := []string{models.TransferAccepted, models.TransferCancelled}
+		changed := make([]bool, len(statuses))
+		var wg sync.WaitGroup
+		for i, status := range statuses {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pending := transfer
+				var err error
+				changed[i], err = store.Transfers().SetStatus(ctx, &pending, status, time.Now())
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		if changed[0] == changed[1] {
+			t.Fatalf("got %v, want exactly one response to succeed", changed)
+		}
+
+		winner := statuses[0]
+		if changed[1] {
+			winner = statuses[1]
+		}
+		stored, err := store.Transfers().Get(ctx, shop.ID, transfer.ID)
+		must(t, err)
+		if stored.Status != winner {
+			t.Errorf("got status %s, want %s", stored.Status, winner)
+		}
+		if again, err := store.Transfers().SetStatus(ctx, &transfer, models.TransferDeclined, time.Now()); err != nil || again {
+			t.Errorf("got %v, %v declining a %s transfer, want false", again, err, winner)
+		}
+		if transfer.Status != models.TransferPending {
+			t.Errorf("got status %s, want the transfer of a failed response unchanged", transfer.Status)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/repositories/storetest"
+)
+
+// must fails the test on err.
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createShop stores a shop with its owner, both named name.
+func createShop(t *testing.T, store repositories.Store, name string) models.Shop {
+	t.Helper()
+	ctx := context.Background()
+	owner := models.ShopOwner{Name: name, Email: name + "@owners.test", Password: "hash"}
+	must(t, store.Owners().Create(ctx, &owner))
+	shop := models.Shop{Name: name, Email: name + "@shops.test", OwnerID: owner.ID}
+	must(t, store.Shops().Create(ctx, &shop))
+	return shop
+}
+
+// createInventory stores an inventory of shopID holding items of the given
+// quantities.
+func createInventory(t *testing.T, store repositories.Store, shopID uint, quantities ...int) (models.Inventory, []models.Item) {
+	t.Helper()
+	ctx := context.Background()
+	inventory := models.Inventory{ShopID: shopID, InventoryName: "stock"}
+	must(t, store.Inventories().Create(ctx, &inventory))
+	items := make([]models.Item, len(quantities))
+	for i, quantity := range quantities {
+		items[i] = models.Item{InventoryID: inventory.ID, Name: "item " + strconv.Itoa(i), Quantity: quantity}
+		must(t, store.Items().Create(ctx, &items[i]))
+	}
+	return inventory, items
+}
+
+func TestTransactionRollsBack(t *testing.T) {
+	storetest.ForEach(t, func(t *testing.T, store repositories.Store) {
+		ctx := context.Background()
+		shop := createShop(t, store, "rollback")
+		failure := errors.New("failure")
+
+		var inventory models.Inventory
+		err := store.Transaction(ctx, func(tx repositories.Store) error {
+			inventory = models.Inventory{ShopID: shop.ID, InventoryName: "lost"}
+			if err := tx.Inventories().Create(ctx, &inventory); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("got %v, want the error of the transaction", err)
+		}
+		if _, err := store.Inventories().GetAny(ctx, inventory.ID); !errors.Is(err, repositories.ErrNotFound) {
+			t.Errorf("got %v, want the inventory rolled back", err)
+		}
+	})
+}
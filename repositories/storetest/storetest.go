@@ -0,0 +1,96 @@
+// Package storetest runs tests on every implementation of the repositories:
+// the MemoryStore, and the GormStore on SQLite and Postgres.
+package storetest
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mohamedhabas11/golang-api/database"
+	"github.com/mohamedhabas11/golang-api/repositories"
+)
+
+// PostgresEnv names the variable holding the URL of a Postgres database the
+// tests run against too, each test in a schema of its own. Without it the
+// GormStore is only tested on SQLite.
+const PostgresEnv = "TEST_POSTGRES_URL"
+
+// ForEach runs test, as a subtest named after the store, on an empty store of
+// every implementation: the MemoryStore, and the GormStore on an in-memory
+// SQLite database and on Postgres, both migrated.
+func ForEach(t *testing.T, test func(t *testing.T, store repositories.Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, repositories.NewMemoryStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, repositories.NewGormStore(openDB(t, "sqlite::memory:")))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		databaseURL := os.Getenv(PostgresEnv)
+		if databaseURL == "" {
+			t.Skip(PostgresEnv + " is not set")
+		}
+		test(t, repositories.NewGormStore(openDB(t, postgresSchema(t, databaseURL))))
+	})
+}
+
+// openDB connects to databaseURL and applies the migrations.
+func openDB(t *testing.T, databaseURL string) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenURL(databaseURL, logger.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	return db
+}
+
+// postgresSchema creates a schema for the test, dropped once it is done, and
+// returns databaseURL with the schema as search path.
+func postgresSchema(t *testing.T, databaseURL string) string {
+	t.Helper()
+	db, err := database.OpenURL(databaseURL, logger.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Actor is who performs the mutations of a request, as recorded in the audit log.
+type Actor struct {
+	// Type is the principal's role, models.ActorAnonymous or models.ActorSystem.
+	Type      string
+	ID        uint // Zero for anonymous and system actors
+	RequestID string
+	IP        string
+}
+
+// actorKey is the context key holding the Actor.
+type actorKey struct{}
+
+// WithActor attaches the actor performing the mutations run with ctx.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor attached to ctx. Without one, the mutation is
+// done by the system (background jobs, seeding).
+func ActorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Type: models.ActorSystem}
+}
+
+// auditRecord describes one mutation to record.
+type auditRecord struct {
+	Action     string
+	EntityType string
+	EntityID   uint
+	Entity     any  // The entity once mutated
+	ShopID     uint // Zero for entities outside of any shop
+	// BeforeShopID is the shop of Before when it doesn't tell it itself,
+	// such as an item moved out of an inventory of another shop.
+	BeforeShopID uint
+	// Before and After are compared field by field to record what changed;
+	// nil stands for "no row" (e.g. Before of a create).
+	Before, After any
+}
+
+// record appends rec to the audit log of tx, the transaction of the mutation,
+// writes the domain events it raises to the outbox and notes the cached
+// responses it changes.
+func record(ctx context.Context, tx repositories.Store, rec auditRecord) error {
+	fieldChanges := diff(rec.Before, rec.After)
+	changes, err := json.Marshal(fieldChanges)
+	if err != nil {
+		return err
+	}
+
+	actor := ActorFrom(ctx)
+	entry := models.AuditEntry{
+		ActorType:  actor.Type,
+		EntityType: rec.EntityType,
+		EntityID:   rec.EntityID,
+		Action:     rec.Action,
+		Changes:    string(changes),
+		RequestID:  actor.RequestID,
+		IP:         actor.IP,
+	}
+	if actor.ID != 0 {
+		entry.ActorID = &actor.ID
+	}
+	if rec.ShopID != 0 {
+		entry.ShopID = &rec.ShopID
+	}
+	if err := tx.Audit().Append(ctx, &entry); err != nil {
+		return err
+	}
+	cache.Changed(ctx, cacheTags(rec)...)
+	return raiseEvents(ctx, tx, rec, fieldChanges)
+}
+
+// fieldChange is the old and new value of one changed field.
+type fieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// redacted replaces the value of secret fields in the audit log; only the fact
+// that they changed is recorded.
+const redacted = "[REDACTED]"
+
+// diff returns the fields of two values of the same model that differ, by JSON
+// name. Bookkeeping fields (ID, timestamps) and relations are left out.
+func diff(before, after any) map[string]fieldChange {
+	changes := map[string]fieldChange{}
+	oldFields, newFields := auditFields(before), auditFields(after)
+	for name, newValue := range newFields {
+		if oldValue, ok := oldFields[name]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[name] = fieldChange{Old: oldFields[name], New: newValue}
+		}
+	}
+	for name, oldValue := range oldFields {
+		if _, ok := newFields[name]; !ok {
+			changes[name] = fieldChange{Old: oldValue}
+		}
+	}
+	return changes
+}
+
+// auditFields returns the audited fields of a model by JSON name, with secrets redacted.
+func auditFields(model any) map[string]any {
+	return modelFields(model, true)
+}
+
+// modelFields returns the fields of a model by JSON name, leaving out the
+// bookkeeping fields and relations. Secrets are redacted, or left out unless
+// withSecrets is set.
+func modelFields(model any, withSecrets bool) map[string]any {
+	fields := map[string]any{}
+	if model == nil {
+		return fields
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(model))
+	secretType, timeType := reflect.TypeOf(models.Secret("")), reflect.TypeOf(time.Time{})
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		relation := field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Struct && field.Type != timeType
+		if field.Anonymous || !field.IsExported() || name == "" || name == "-" || relation {
+			continue
+		}
+
+		if field.Type == secretType && !withSecrets {
+			continue
+		}
+		fields[name] = value.Field(i).Interface()
+		if field.Type == secretType {
+			fields[name] = redacted
+			if value.Field(i).IsZero() {
+				fields[name] = nil
+			}
+		}
+	}
+	return fields
+}
+
+// AuditService reads the audit log.
+type AuditService struct {
+	store repositories.Store
+}
+
+// NewAuditService creates an AuditService.
+func NewAuditService(store repositories.Store) *AuditService {
+	return &AuditService{store: store}
+}
+
+// ListForOwner returns the audit entries of the shops managed by ownerID that
+// match filter, newest first. filter.ShopIDs may only name shops of the owner.
+func (s *AuditService) ListForOwner(ctx context.Context, ownerID uint, filter repositories.AuditFilter) ([]models.AuditEntry, error) {
+	shops, err := s.store.Shops().ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	owned := make([]uint, 0, len(shops))
+	for _, shop := range shops {
+		owned = append(owned, shop.ID)
+	}
+
+	for _, id := range filter.ShopIDs {
+		if !slices.Contains(owned, id) {
+			return nil, utils.ErrForbidden("Only the shop owner can read the audit log of this shop")
+		}
+	}
+	if filter.ShopIDs == nil {
+		filter.ShopIDs = owned
+	}
+	if len(filter.ShopIDs) == 0 {
+		return []models.AuditEntry{}, nil
+	}
+	return s.store.Audit().List(ctx, filter)
+}
+
+// AuditVerification is the outcome of checking the audit hash chain.
+type AuditVerification struct {
+	Valid   bool
+	Checked uint64 // Number of entries checked
+	// FirstInvalidSeq and Reason point at the first broken link.
+	FirstInvalidSeq uint64
+	Reason          string
+}
+
+// verifyBatchSize is how many entries Verify loads at once.
+const verifyBatchSize = 500
+
+// Verify walks the whole audit log and checks that every entry still matches
+// its hash and links to its predecessor, and that the chain ends at the
+// recorded head.
+func (s *AuditService) Verify(ctx context.Context) (AuditVerification, error) {
+	var result AuditVerification
+	head, err := s.store.Audit().Head(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	var seq uint64
+	prevHash := ""
+	for {
+		entries, err := s.store.Audit().Chain(ctx, seq, verifyBatchSize)
+		if err != nil {
+			return result, err
+		}
+		for _, entry := range entries {
+			switch {
+			case entry.Seq != seq+1:
+				return result.broken(seq+1, fmt.Sprintf("entry %d is missing", seq+1)), nil
+			case entry.PrevHash != prevHash:
+				return result.broken(entry.Seq, "the entry doesn't link to the previous one"), nil
+			case entry.ComputeHash() != entry.Hash:
+				return result.broken(entry.Seq, "the entry was modified"), nil
+			}
+			seq, prevHash = entry.Seq, entry.Hash
+			result.Checked++
+		}
+		if len(entries) < verifyBatchSize {
+			break
+		}
+	}
+
+	if seq != head.Seq || prevHash != head.Hash {
+		return result.broken(seq+1, fmt.Sprintf("the chain ends at entry %d but its head is entry %d", seq, head.Seq)), nil
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// broken marks the verification as failed at seq.
+func (v AuditVerification) broken(seq uint64, reason string) AuditVerification {
+	v.FirstInvalidSeq, v.Reason = seq, reason
+	return v
+}
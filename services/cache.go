@@ -0,0 +1,53 @@
+package services
+
+import (
+	"slices"
+
+	"github.com/mohamedhabas11/golang-api/cache"
+	"github.com/mohamedhabas11/golang-api/models"
+)
+
+// cascades lists the kinds of rows deleted and restored along with a row of
+// each kind.
+var cascades = map[string][]string{
+	models.AuditShop:      {models.AuditEmployee, models.AuditInventory, models.AuditItem},
+	models.AuditInventory: {models.AuditItem},
+}
+
+// cacheTags returns the tags of the cached responses the mutation rec changes:
+// those of the row, of the lists of its kind and of its shop, which embeds its
+// employees, inventories and items; an item also changes the responses of its
+// inventory. A row moved to another inventory or shop changes those of the
+// ones it left too. Deletes and restores cascading to other rows change every
+// response showing rows of their kinds.
+func cacheTags(rec auditRecord) []string {
+	tags := []string{cache.Tag(rec.EntityType, rec.EntityID), cache.ListTag(rec.EntityType)}
+	shops := []uint{rec.ShopID, rec.BeforeShopID}
+	var inventories []uint
+	if item, ok := rec.Entity.(models.Item); ok {
+		inventories = append(inventories, item.InventoryID)
+	}
+	switch before := rec.Before.(type) {
+	case models.Item:
+		inventories = append(inventories, before.InventoryID)
+	case models.Inventory:
+		shops = append(shops, before.ShopID)
+	case models.ShopEmployee:
+		shops = append(shops, before.ShopID)
+	}
+
+	for _, id := range shops {
+		if id != 0 {
+			tags = append(tags, cache.Tag(models.AuditShop, id))
+		}
+	}
+	for _, id := range inventories {
+		tags = append(tags, cache.Tag(models.AuditInventory, id), cache.ListTag(models.AuditInventory))
+	}
+	switch rec.Action {
+	case models.AuditDelete, models.AuditRestore, models.AuditPurge:
+		tags = append(tags, cascades[rec.EntityType]...)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
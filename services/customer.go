@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// CustomerService registers and authenticates customers.
+type CustomerService struct {
+	store repositories.Store
+}
+
+// NewCustomerService creates a CustomerService.
+func NewCustomerService(store repositories.Store) *CustomerService {
+	return &CustomerService{store: store}
+}
+
+// Register creates a new customer with a hashed password.
+func (s *CustomerService) Register(ctx context.Context, req dto.CreateCustomerRequest) (models.Customer, error) {
+	customer := req.ToModel()
+
+	// Check if customer already exists
+	found, err := exists(s.store.Customers().FindByEmail(ctx, customer.Email))
+	if err != nil {
+		return customer, err
+	}
+	if found {
+		return customer, utils.ErrConflict("Customer with this email already exists")
+	}
+
+	// Hash the password
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return customer, utils.ErrInternal("Error hashing password")
+	}
+	customer.Password = models.Secret(hashedPassword)
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Customers().Create(ctx, &customer); err != nil {
+			return err
+		}
+		return record(ctx, tx, auditRecord{
+			Action: models.AuditCreate, EntityType: models.AuditCustomer, EntityID: customer.ID, Entity: customer, After: customer,
+		})
+	})
+	return customer, err
+}
+
+// Authenticate returns the customer matching the credentials.
+func (s *CustomerService) Authenticate(ctx context.Context, email, password string) (models.Customer, error) {
+	customer, err := s.store.Customers().FindByEmail(ctx, email)
+	if err != nil {
+		return customer, orUnauthorized(err)
+	}
+
+	// Compare provided password with hashed password
+	if !utils.ComparePassword(customer.Password.Reveal(), password) {
+		return customer, utils.ErrUnauthorized("Invalid credentials")
+	}
+	return customer, nil
+}
+
+// List returns every customer.
+func (s *CustomerService) List(ctx context.Context) ([]models.Customer, error) {
+	return s.store.Customers().List(ctx, repositories.ListOptions{})
+}
+
+// orUnauthorized turns an unknown account into the same error as a wrong password.
+func orUnauthorized(err error) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return utils.ErrUnauthorized("Invalid credentials")
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// EmployeeService manages shop employees.
+type EmployeeService struct {
+	store repositories.Store
+}
+
+// NewEmployeeService creates an EmployeeService.
+func NewEmployeeService(store repositories.Store) *EmployeeService {
+	return &EmployeeService{store: store}
+}
+
+// Create registers a new employee of an existing shop.
+func (s *EmployeeService) Create(ctx context.Context, req dto.CreateEmployeeRequest) (models.ShopEmployee, error) {
+	employee := req.ToModel()
+
+	// Check if an employee with the same email already exists.
+	if err := checkEmployeeEmail(ctx, s.store, employee.Email, "Employee with this email already exists"); err != nil {
+		return employee, err
+	}
+
+	// Ensure the associated shop exists.
+	if _, err := s.store.Shops().Get(ctx, employee.ShopID); err != nil {
+		return employee, orNotFound(err, "Associated shop not found")
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return employee, utils.ErrInternal("Error hashing password")
+	}
+	employee.Password = models.Secret(hashedPassword)
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Employees().Create(ctx, &employee); err != nil {
+			return err
+		}
+		return recordEmployee(ctx, tx, models.AuditCreate, employee, nil, employee)
+	})
+	return employee, err
+}
+
+// List returns every employee, or the trash with opts.Deleted.
+func (s *EmployeeService) List(ctx context.Context, opts repositories.ListOptions) ([]models.ShopEmployee, error) {
+	return s.store.Employees().List(ctx, opts)
+}
+
+// Get returns an employee.
+func (s *EmployeeService) Get(ctx context.Context, id uint) (models.ShopEmployee, error) {
+	employee, err := s.store.Employees().Get(ctx, id)
+	return employee, orNotFound(err, "Employee not found")
+}
+
+// Update applies the allowed changes to an employee, re-hashing a new password.
+func (s *EmployeeService) Update(ctx context.Context, id uint, req dto.UpdateEmployeeRequest) (models.ShopEmployee, error) {
+	employee, err := s.Get(ctx, id)
+	if err != nil {
+		return employee, err
+	}
+
+	// Check if new email is already taken.
+	if req.Email != nil && *req.Email != employee.Email {
+		if err := checkEmployeeEmail(ctx, s.store, *req.Email, "Another employee with this email already exists"); err != nil {
+			return employee, err
+		}
+	}
+
+	// Moving the employee requires the new shop to exist.
+	if req.ShopID != nil && *req.ShopID != employee.ShopID {
+		if _, err := s.store.Shops().Get(ctx, *req.ShopID); err != nil {
+			return employee, orNotFound(err, "New associated shop not found")
+		}
+	}
+
+	before := employee
+	req.ApplyTo(&employee)
+
+	if req.Password != nil {
+		hashedPassword, err := utils.HashPassword(*req.Password)
+		if err != nil {
+			return employee, utils.ErrInternal("Error hashing password")
+		}
+		employee.Password = models.Secret(hashedPassword)
+	}
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Employees().Update(ctx, &employee); err != nil {
+			return err
+		}
+		return recordEmployee(ctx, tx, models.AuditUpdate, employee, before, employee)
+	})
+	return employee, err
+}
+
+// Delete soft-deletes an employee, or removes it permanently with opts.Purge
+// when ownerID owns their shop.
+func (s *EmployeeService) Delete(ctx context.Context, ownerID, id uint, opts DeleteOptions) error {
+	get := s.store.Employees().Get
+	if opts.Purge {
+		get = s.store.Employees().GetAny
+	}
+	employee, err := get(ctx, id)
+	if err != nil {
+		return orNotFound(err, "Employee not found")
+	}
+	if opts.Purge {
+		if _, err := ownedShop(ctx, s.store.Shops().GetAny, ownerID, employee.ShopID); err != nil {
+			return err
+		}
+	}
+
+	return s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if opts.Purge {
+			if err := tx.Employees().Purge(ctx, employee.ID); err != nil {
+				return err
+			}
+			return recordEmployee(ctx, tx, models.AuditPurge, employee, employee, nil)
+		}
+
+		if err := tx.Employees().SoftDelete(ctx, employee.ID, time.Now()); err != nil {
+			return err
+		}
+		return recordEmployee(ctx, tx, models.AuditDelete, employee, nil, nil)
+	})
+}
+
+// Restore brings a soft-deleted employee back from the trash, for ownerID, the
+// owner of their shop.
+func (s *EmployeeService) Restore(ctx context.Context, ownerID, id uint) (models.ShopEmployee, error) {
+	employee, err := s.store.Employees().GetDeleted(ctx, id)
+	if err != nil {
+		return employee, orNotFound(err, "Deleted employee not found")
+	}
+	if _, err := ownedShop(ctx, s.store.Shops().GetAny, ownerID, employee.ShopID); err != nil {
+		return employee, err
+	}
+
+	// The email may have been reused by an active employee in the meantime.
+	if err := checkEmployeeEmail(ctx, s.store, employee.Email, "Another employee with this email already exists"); err != nil {
+		return employee, err
+	}
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Employees().Restore(ctx, employee.ID); err != nil {
+			return err
+		}
+		return recordEmployee(ctx, tx, models.AuditRestore, employee, nil, nil)
+	})
+	if err != nil {
+		return employee, err
+	}
+	return s.store.Employees().Get(ctx, employee.ID)
+}
+
+// recordEmployee records a mutation of employee in the audit log of their shop.
+func recordEmployee(ctx context.Context, tx repositories.Store, action string, employee models.ShopEmployee, before, after any) error {
+	return record(ctx, tx, auditRecord{
+		Action: action, EntityType: models.AuditEmployee, EntityID: employee.ID, Entity: employee, ShopID: employee.ShopID, Before: before, After: after,
+	})
+}
+
+// checkEmployeeEmail rejects an email already used by an active employee.
+func checkEmployeeEmail(ctx context.Context, store repositories.Store, email, conflict string) error {
+	found, err := exists(store.Employees().FindByEmail(ctx, email))
+	if err != nil {
+		return err
+	}
+	if found {
+		return utils.ErrConflict(conflict)
+	}
+	return nil
+}
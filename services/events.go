@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/events"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+)
+
+// eventTypes maps each audited mutation to the domain event it raises.
+// Transfer status changes are mapped by transferEvents instead.
+var eventTypes = map[string]map[string]string{
+	models.AuditShop: {
+		models.AuditCreate:  events.ShopCreated,
+		models.AuditUpdate:  events.ShopUpdated,
+		models.AuditDelete:  events.ShopDeleted,
+		models.AuditRestore: events.ShopRestored,
+		models.AuditPurge:   events.ShopPurged,
+	},
+	models.AuditShopOwner: {
+		models.AuditCreate: events.ShopOwnerRegistered,
+	},
+	models.AuditTransfer: {
+		models.AuditCreate: events.TransferRequested,
+	},
+	models.AuditEmployee: {
+		models.AuditCreate:  events.EmployeeCreated,
+		models.AuditUpdate:  events.EmployeeUpdated,
+		models.AuditDelete:  events.EmployeeDeleted,
+		models.AuditRestore: events.EmployeeRestored,
+		models.AuditPurge:   events.EmployeePurged,
+	},
+	models.AuditCustomer: {
+		models.AuditCreate: events.CustomerRegistered,
+	},
+	models.AuditInventory: {
+		models.AuditCreate:  events.InventoryCreated,
+		models.AuditUpdate:  events.InventoryUpdated,
+		models.AuditDelete:  events.InventoryDeleted,
+		models.AuditRestore: events.InventoryRestored,
+		models.AuditPurge:   events.InventoryPurged,
+	},
+	models.AuditItem: {
+		models.AuditCreate:  events.ItemCreated,
+		models.AuditUpdate:  events.ItemUpdated,
+		models.AuditDelete:  events.ItemDeleted,
+		models.AuditRestore: events.ItemRestored,
+		models.AuditPurge:   events.ItemPurged,
+	},
+}
+
+// transferEvents maps the final statuses of a transfer to their event.
+var transferEvents = map[string]string{
+	models.TransferAccepted:  events.TransferAccepted,
+	models.TransferDeclined:  events.TransferDeclined,
+	models.TransferCancelled: events.TransferCancelled,
+	models.TransferExpired:   events.TransferExpired,
+}
+
+// eventData is the Data of the domain events.
+type eventData struct {
+	Changes map[string]fieldChange `json:"changes"`
+	State   map[string]any         `json:"state"` // Nil once deleted
+}
+
+// raiseEvents writes the domain events raised by the mutation rec to the
+// outbox of tx, with the field changes recorded in the audit log.
+func raiseEvents(ctx context.Context, tx repositories.Store, rec auditRecord, changes map[string]fieldChange) error {
+	types := domainEvents(rec, changes)
+	if len(types) == 0 {
+		return nil
+	}
+
+	data := eventData{Changes: changes}
+	if rec.Action != models.AuditDelete && rec.Action != models.AuditPurge && rec.Entity != nil {
+		data.State = modelFields(rec.Entity, false)
+		data.State["id"] = rec.EntityID
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, eventType := range types {
+		event := models.OutboxEvent{
+			CreatedAt:     now,
+			Type:          eventType,
+			AggregateType: rec.EntityType,
+			AggregateID:   rec.EntityID,
+			Payload:       string(payload),
+			NextAttemptAt: now,
+		}
+		if rec.ShopID != 0 {
+			event.ShopID = &rec.ShopID
+		}
+		if err := tx.Outbox().Add(ctx, &event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// domainEvents returns the types of the events raised by a mutation: the one
+// of eventTypes, followed by the more specific ones derived from its changes.
+func domainEvents(rec auditRecord, changes map[string]fieldChange) []string {
+	var types []string
+	if eventType, ok := eventTypes[rec.EntityType][rec.Action]; ok {
+		types = append(types, eventType)
+	}
+	if rec.Action != models.AuditUpdate {
+		return types
+	}
+
+	switch rec.EntityType {
+	case models.AuditTransfer:
+		if status, ok := changes["status"].New.(string); ok && transferEvents[status] != "" {
+			types = append(types, transferEvents[status])
+		}
+	case models.AuditShop:
+		if _, ok := changes["owner_id"]; ok {
+			types = append(types, events.ShopOwnershipChanged)
+		}
+	case models.AuditItem:
+		if quantity, ok := changes["quantity"]; ok {
+			types = append(types, events.ItemQuantityChanged)
+			oldQuantity, _ := quantity.Old.(int)
+			newQuantity, _ := quantity.New.(int)
+			if newQuantity <= 0 && oldQuantity > 0 {
+				types = append(types, events.ItemOutOfStock)
+			}
+		}
+	}
+	return types
+}
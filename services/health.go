@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// DefaultCheckTimeout bounds the readiness checks without a timeout of their own.
+const DefaultCheckTimeout = 2 * time.Second
+
+// HealthCheck is a dependency the readiness of the API depends on.
+type HealthCheck struct {
+	Name string
+	// Timeout bounds the check, DefaultCheckTimeout if zero.
+	Timeout time.Duration
+	// Optional checks are reported without failing the readiness, for the
+	// dependencies the API works without, such as the read replicas.
+	Optional bool
+	Check    func(ctx context.Context) error
+}
+
+// Readiness states of the API.
+const (
+	ReadinessStarting = "starting" // Startup hasn't completed yet
+	ReadinessReady    = "ready"
+	ReadinessNotReady = "not_ready" // A required check failed
+	ReadinessDraining = "draining"  // Shutting down, no new traffic wanted
+)
+
+// Results of a readiness check.
+const (
+	CheckOK     = "ok"
+	CheckFailed = "failed"
+)
+
+// CheckResult is the outcome of one readiness check.
+type CheckResult struct {
+	Name     string
+	Status   string
+	Optional bool
+	Duration time.Duration
+	Error    error
+}
+
+// Readiness is the outcome of the readiness checks.
+type Readiness struct {
+	Status string
+	Checks []CheckResult
+}
+
+// Ready reports whether the API should get traffic.
+func (r Readiness) Ready() bool {
+	return r.Status == ReadinessReady
+}
+
+// HealthService reports the state of the storage behind the API and whether
+// the replica is started, ready or draining.
+type HealthService struct {
+	store repositories.Store
+	// Checks run on each readiness request, besides the database ping.
+	Checks []HealthCheck
+
+	started  atomic.Bool
+	draining atomic.Bool
+}
+
+// NewHealthService creates a HealthService on top of store.
+func NewHealthService(store repositories.Store) *HealthService {
+	return &HealthService{store: store}
+}
+
+// Database pings the database and returns its connection pool statistics, or
+// nil for stores without a connection pool.
+func (s *HealthService) Database(ctx context.Context) (*sql.DBStats, error) {
+	pool, ok := s.store.(repositories.Pool)
+	if !ok {
+		return nil, nil
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		slog.ErrorContext(ctx, "Database ping failed", "error", err)
+		return nil, utils.ErrUnavailable("The database is not reachable")
+	}
+	stats := pool.PoolStats()
+	return &stats, nil
+}
+
+// MarkStarted records that startup completed: the migrations are applied and
+// the seed loaded.
+func (s *HealthService) MarkStarted() {
+	s.started.Store(true)
+}
+
+// Started reports whether startup completed.
+func (s *HealthService) Started() bool {
+	return s.started.Load()
+}
+
+// Drain makes the replica report itself not ready for good, so that load
+// balancers stop routing to it before it shuts down.
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Readiness runs every check concurrently, each within its timeout. The
+// checks are skipped while starting or draining, the answer being known.
+func (s *HealthService) Readiness(ctx context.Context) Readiness {
+	switch {
+	case s.draining.Load():
+		return Readiness{Status: ReadinessDraining}
+	case !s.started.Load():
+		return Readiness{Status: ReadinessStarting}
+	}
+
+	checks := s.Checks
+	if pool, ok := s.store.(repositories.Pool); ok {
+		checks = append([]HealthCheck{{Name: "database", Check: pool.Ping}}, checks...)
+	}
+
+	readiness := Readiness{Status: ReadinessReady, Checks: make([]CheckResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readiness.Checks[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range readiness.Checks {
+		if result.Status == CheckFailed && !result.Optional {
+			readiness.Status = ReadinessNotReady
+		}
+	}
+	return readiness
+}
+
+// runCheck runs check within its timeout, logging failures.
+func runCheck(ctx context.Context, check HealthCheck) CheckResult {
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = DefaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	result := CheckResult{Name: check.Name, Status: CheckOK, Optional: check.Optional, Duration: time.Since(start), Error: err}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = errors.New("timed out after " + timeout.String())
+		}
+		result.Status = CheckFailed
+		slog.WarnContext(ctx, "Readiness check failed", "check", check.Name, "optional", check.Optional, "error", result.Error)
+	}
+	return result
+}
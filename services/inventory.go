@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// InventoryService manages shop inventories.
+type InventoryService struct {
+	store repositories.Store
+}
+
+// NewInventoryService creates an InventoryService.
+func NewInventoryService(store repositories.Store) *InventoryService {
+	return &InventoryService{store: store}
+}
+
+// Create adds an inventory to an existing shop.
+func (s *InventoryService) Create(ctx context.Context, req dto.CreateInventoryRequest) (models.Inventory, error) {
+	inventory := req.ToModel()
+
+	// Ensure the associated shop exists.
+	if _, err := s.store.Shops().Get(ctx, inventory.ShopID); err != nil {
+		return inventory, orNotFound(err, "Shop not found")
+	}
+
+	err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Inventories().Create(ctx, &inventory); err != nil {
+			return err
+		}
+		return recordInventory(ctx, tx, models.AuditCreate, inventory, nil, inventory)
+	})
+	return inventory, err
+}
+
+// List returns every inventory with its items, or the trash with opts.Deleted.
+func (s *InventoryService) List(ctx context.Context, opts repositories.ListOptions) ([]models.Inventory, error) {
+	return s.store.Inventories().List(ctx, opts)
+}
+
+// Get returns an inventory with its items.
+func (s *InventoryService) Get(ctx context.Context, id uint) (models.Inventory, error) {
+	inventory, err := s.store.Inventories().Get(ctx, id)
+	return inventory, orNotFound(err, "Inventory not found")
+}
+
+// Update applies the allowed changes to an inventory.
+func (s *InventoryService) Update(ctx context.Context, id uint, req dto.UpdateInventoryRequest) (models.Inventory, error) {
+	inventory, err := s.Get(ctx, id)
+	if err != nil {
+		return inventory, err
+	}
+
+	before := inventory
+	req.ApplyTo(&inventory)
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Inventories().Update(ctx, &inventory); err != nil {
+			return err
+		}
+		return recordInventory(ctx, tx, models.AuditUpdate, inventory, before, inventory)
+	})
+	return inventory, err
+}
+
+// Delete soft-deletes an inventory and its items, or removes them permanently
+// with opts.Purge when ownerID owns its shop.
+func (s *InventoryService) Delete(ctx context.Context, ownerID, id uint, opts DeleteOptions) error {
+	get := s.store.Inventories().Get
+	if opts.Purge {
+		get = s.store.Inventories().GetAny
+	}
+	inventory, err := get(ctx, id)
+	if err != nil {
+		return orNotFound(err, "Inventory not found")
+	}
+
+	// Permanently remove the inventory together with all of its items, for
+	// the owner of its shop only.
+	if opts.Purge {
+		if _, err := ownedShop(ctx, s.store.Shops().GetAny, ownerID, inventory.ShopID); err != nil {
+			return err
+		}
+		return s.store.Transaction(ctx, func(tx repositories.Store) error {
+			if err := tx.Items().PurgeByInventory(ctx, inventory.ID); err != nil {
+				return err
+			}
+			if err := tx.Inventories().Purge(ctx, inventory.ID); err != nil {
+				return err
+			}
+			return recordInventory(ctx, tx, models.AuditPurge, inventory, inventory, nil)
+		})
+	}
+
+	// Delete the inventory and cascade to its items. Both share the same
+	// deletion timestamp so a restore knows which items went with it.
+	deletedAt := time.Now()
+	return s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Items().SoftDeleteByInventory(ctx, inventory.ID, deletedAt); err != nil {
+			return err
+		}
+		if err := tx.Inventories().SoftDelete(ctx, inventory.ID, deletedAt); err != nil {
+			return err
+		}
+		return recordInventory(ctx, tx, models.AuditDelete, inventory, nil, nil)
+	})
+}
+
+// Restore brings a soft-deleted inventory back from the trash along with the
+// items that were deleted together with it, for ownerID, the owner of its shop.
+func (s *InventoryService) Restore(ctx context.Context, ownerID, id uint) (models.Inventory, error) {
+	inventory, err := s.store.Inventories().GetDeleted(ctx, id)
+	if err != nil {
+		return inventory, orNotFound(err, "Deleted inventory not found")
+	}
+
+	// The inventory can only come back into a shop that still exists.
+	shop, err := ownedShop(ctx, s.store.Shops().GetAny, ownerID, inventory.ShopID)
+	if err != nil {
+		return inventory, err
+	}
+	if shop.DeletedAt.Valid {
+		return inventory, utils.ErrConflict("The inventory's shop is deleted; restore the shop first")
+	}
+
+	// Restore the inventory and the items removed by the same cascade; items
+	// deleted individually before that stay in the trash.
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Items().RestoreByInventory(ctx, inventory.ID, inventory.DeletedAt.Time); err != nil {
+			return err
+		}
+		if err := tx.Inventories().Restore(ctx, inventory.ID); err != nil {
+			return err
+		}
+		return recordInventory(ctx, tx, models.AuditRestore, inventory, nil, nil)
+	})
+	if err != nil {
+		return inventory, err
+	}
+
+	// Reload with the restored items.
+	return s.store.Inventories().Get(ctx, inventory.ID)
+}
+
+// recordInventory records a mutation of inventory in the audit log of its shop.
+// Items removed or restored with it are covered by this entry.
+func recordInventory(ctx context.Context, tx repositories.Store, action string, inventory models.Inventory, before, after any) error {
+	return record(ctx, tx, auditRecord{
+		Action: action, EntityType: models.AuditInventory, EntityID: inventory.ID, Entity: inventory, ShopID: inventory.ShopID, Before: before, After: after,
+	})
+}
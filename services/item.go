@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// ItemService manages inventory items.
+type ItemService struct {
+	store repositories.Store
+	// ReorderPoint is the quantity below which an item needs restocking.
+	ReorderPoint int
+}
+
+// NewItemService creates an ItemService.
+func NewItemService(store repositories.Store) *ItemService {
+	return &ItemService{store: store, ReorderPoint: DefaultReorderPoint}
+}
+
+// DefaultReorderPoint is the ReorderPoint of new ItemServices.
+const DefaultReorderPoint = 10
+
+// CountBelowReorderPoint counts the active items that need restocking.
+func (s *ItemService) CountBelowReorderPoint(ctx context.Context) (int64, error) {
+	return s.store.Items().CountBelow(ctx, s.ReorderPoint)
+}
+
+// Create adds an item to an existing inventory.
+func (s *ItemService) Create(ctx context.Context, req dto.CreateItemRequest) (models.Item, error) {
+	item := req.ToModel()
+
+	// Ensure the associated inventory exists.
+	if _, err := s.store.Inventories().Get(ctx, item.InventoryID); err != nil {
+		return item, orNotFound(err, "Inventory not found")
+	}
+
+	err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Items().Create(ctx, &item); err != nil {
+			return err
+		}
+		return recordItem(ctx, tx, models.AuditCreate, item, nil, item)
+	})
+	return item, err
+}
+
+// List returns every item, or the trash with opts.Deleted.
+func (s *ItemService) List(ctx context.Context, opts repositories.ListOptions) ([]models.Item, error) {
+	return s.store.Items().List(ctx, opts)
+}
+
+// Get returns an item.
+func (s *ItemService) Get(ctx context.Context, id uint) (models.Item, error) {
+	item, err := s.store.Items().Get(ctx, id)
+	return item, orNotFound(err, "Item not found")
+}
+
+// Update applies the allowed changes to an item. Moving it to another inventory
+// requires that inventory to exist.
+func (s *ItemService) Update(ctx context.Context, id uint, req dto.UpdateItemRequest) (models.Item, error) {
+	item, err := s.Get(ctx, id)
+	if err != nil {
+		return item, err
+	}
+
+	if req.InventoryID != nil && *req.InventoryID != item.InventoryID {
+		if _, err := s.store.Inventories().Get(ctx, *req.InventoryID); err != nil {
+			return item, orNotFound(err, "New inventory not found")
+		}
+	}
+
+	before := item
+	req.ApplyTo(&item)
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Items().Update(ctx, &item); err != nil {
+			return err
+		}
+		return recordItem(ctx, tx, models.AuditUpdate, item, before, item)
+	})
+	return item, err
+}
+
+// Delete soft-deletes an item, or removes it permanently with opts.Purge when
+// ownerID owns its shop.
+func (s *ItemService) Delete(ctx context.Context, ownerID, id uint, opts DeleteOptions) error {
+	get := s.store.Items().Get
+	if opts.Purge {
+		get = s.store.Items().GetAny
+	}
+	item, err := get(ctx, id)
+	if err != nil {
+		return orNotFound(err, "Item not found")
+	}
+	if opts.Purge {
+		if err := checkItemOwner(ctx, s.store, ownerID, item); err != nil {
+			return err
+		}
+	}
+
+	return s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if opts.Purge {
+			if err := tx.Items().Purge(ctx, item.ID); err != nil {
+				return err
+			}
+			return recordItem(ctx, tx, models.AuditPurge, item, item, nil)
+		}
+
+		if err := tx.Items().SoftDelete(ctx, item.ID, time.Now()); err != nil {
+			return err
+		}
+		return recordItem(ctx, tx, models.AuditDelete, item, nil, nil)
+	})
+}
+
+// Restore brings a soft-deleted item back from the trash, for ownerID, the
+// owner of its shop.
+func (s *ItemService) Restore(ctx context.Context, ownerID, id uint) (models.Item, error) {
+	item, err := s.store.Items().GetDeleted(ctx, id)
+	if err != nil {
+		return item, orNotFound(err, "Deleted item not found")
+	}
+	if err := checkItemOwner(ctx, s.store, ownerID, item); err != nil {
+		return item, err
+	}
+
+	// The item can only come back into an inventory that still exists.
+	found, err := exists(s.store.Inventories().Get(ctx, item.InventoryID))
+	if err != nil {
+		return item, err
+	}
+	if !found {
+		return item, utils.ErrConflict("The item's inventory is deleted; restore the inventory first")
+	}
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Items().Restore(ctx, item.ID); err != nil {
+			return err
+		}
+		return recordItem(ctx, tx, models.AuditRestore, item, nil, nil)
+	})
+	if err != nil {
+		return item, err
+	}
+	return s.store.Items().Get(ctx, item.ID)
+}
+
+// checkItemOwner rejects the callers other than ownerID, the owner of the
+// shop of item's inventory, deleted or not.
+func checkItemOwner(ctx context.Context, store repositories.Store, ownerID uint, item models.Item) error {
+	inventory, err := store.Inventories().GetAny(ctx, item.InventoryID)
+	if err != nil {
+		return orNotFound(err, "Inventory not found")
+	}
+	_, err = ownedShop(ctx, store.Shops().GetAny, ownerID, inventory.ShopID)
+	return err
+}
+
+// recordItem records a mutation of item in the audit log of its inventory's shop,
+// noting the shop it left when it moved to another inventory.
+func recordItem(ctx context.Context, tx repositories.Store, action string, item models.Item, before, after any) error {
+	inventory, err := tx.Inventories().GetAny(ctx, item.InventoryID)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return err
+	}
+	rec := auditRecord{
+		Action: action, EntityType: models.AuditItem, EntityID: item.ID, Entity: item, ShopID: inventory.ShopID, Before: before, After: after,
+	}
+	if moved, ok := before.(models.Item); ok && moved.InventoryID != item.InventoryID {
+		previous, err := tx.Inventories().GetAny(ctx, moved.InventoryID)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return err
+		}
+		rec.BeforeShopID = previous.ShopID
+	}
+	return record(ctx, tx, rec)
+}
@@ -0,0 +1,485 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/jobs"
+	"github.com/mohamedhabas11/golang-api/logging"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Job queue settings.
+const (
+	// jobLease is how long a claimed job stays locked to its worker, renewed
+	// every third of it while the handler runs.
+	jobLease              = 5 * time.Minute
+	defaultJobMaxAttempts = 5
+	jobMinBackoff         = 10 * time.Second
+	jobMaxBackoff         = time.Hour
+	// schedulerLease names the lease electing the replica that enqueues the
+	// scheduled jobs.
+	schedulerLease    = "job-scheduler"
+	schedulerLeaseTTL = 30 * time.Second
+)
+
+// EnqueueOptions tunes a job being enqueued.
+type EnqueueOptions struct {
+	RunAt       time.Time // Zero runs the job right away
+	MaxAttempts int       // Zero uses defaultJobMaxAttempts
+}
+
+// RunOptions configures the job workers.
+type RunOptions struct {
+	Workers      int           // Number of jobs run concurrently
+	PollInterval time.Duration // How often idle workers look for due jobs
+	// DrainTimeout is how long running jobs get to finish on shutdown before
+	// their context is cancelled.
+	DrainTimeout time.Duration
+}
+
+// JobService runs the background job queue: typed handlers registered on its
+// Registry, retries with backoff, dead letters, and cron schedules enqueued by
+// a single replica at a time.
+type JobService struct {
+	store     repositories.Store
+	registry  *jobs.Registry
+	schedules []jobSchedule
+	// worker identifies this process in job locks and the scheduler lease.
+	worker string
+}
+
+// jobSchedule enqueues a job of jobType with payload at every run of schedule.
+type jobSchedule struct {
+	name     string
+	spec     string
+	jobType  string
+	payload  json.RawMessage
+	schedule jobs.Schedule
+}
+
+// NewJobService creates a JobService without handlers nor schedules.
+func NewJobService(store repositories.Store) *JobService {
+	host, _ := os.Hostname()
+	return &JobService{
+		store:    store,
+		registry: jobs.NewRegistry(),
+		worker:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// Registry returns the registry the job handlers are added to with
+// jobs.Register, before Run is called.
+func (s *JobService) Registry() *jobs.Registry {
+	return s.registry
+}
+
+// Enqueue adds a job of jobType with payload encoded as JSON to the queue.
+func (s *JobService) Enqueue(ctx context.Context, jobType string, payload any, opts EnqueueOptions) (models.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, err
+	}
+	return s.enqueue(ctx, s.store, jobType, raw, opts, "")
+}
+
+// enqueue stores a job with tx, recording the schedule that enqueued it.
+func (s *JobService) enqueue(ctx context.Context, tx repositories.Store, jobType string, payload json.RawMessage, opts EnqueueOptions, schedule string) (models.Job, error) {
+	if _, ok := s.registry.Handler(jobType); !ok {
+		return models.Job{}, fmt.Errorf("no handler registered for jobs of type %s", jobType)
+	}
+	job := models.Job{
+		Type:        jobType,
+		Payload:     string(payload),
+		Schedule:    schedule,
+		Status:      models.JobQueued,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = defaultJobMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	err := tx.Jobs().Enqueue(ctx, &job)
+	return job, err
+}
+
+// Schedule enqueues a job of jobType with payload at every run of spec, a cron
+// expression parsed by jobs.ParseSchedule. Only the replica holding the
+// scheduler lease enqueues it, and runs missed while no replica was up are
+// enqueued once. Schedules are added before Run is called.
+func (s *JobService) Schedule(name, spec, jobType string, payload any) error {
+	schedule, err := jobs.ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("schedule %q never runs", spec)
+	}
+	if _, ok := s.registry.Handler(jobType); !ok {
+		return fmt.Errorf("no handler registered for jobs of type %s", jobType)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	s.schedules = append(s.schedules, jobSchedule{name: name, spec: spec, jobType: jobType, payload: raw, schedule: schedule})
+	return nil
+}
+
+// Run works the queue until ctx is cancelled, then stops claiming jobs and
+// waits for the running ones. Jobs still running after opts.DrainTimeout have
+// their context cancelled and go back to the queue without counting the
+// attempt. Run returns once every worker stopped.
+func (s *JobService) Run(ctx context.Context, opts RunOptions) {
+	slog.Info("Job runner started", "workers", opts.Workers, "types", s.registry.Types(), "schedules", len(s.schedules))
+
+	// Jobs outlive ctx until the drain timeout; their bookkeeping outlives it
+	// until they return.
+	jobsCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	for range opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx, jobsCtx, opts.PollInterval)
+		}()
+	}
+	if len(s.schedules) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.lead(ctx, opts.PollInterval)
+		}()
+	}
+
+	<-ctx.Done()
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(opts.DrainTimeout):
+		slog.Warn("Job runner: jobs still running after the drain timeout, cancelling them", "drain_timeout", opts.DrainTimeout.String())
+		cancelJobs()
+		<-drained
+	}
+	slog.Info("Job runner stopped")
+}
+
+// work claims and runs jobs one at a time until ctx is cancelled, looking for
+// due jobs every interval while the queue is empty.
+func (s *JobService) work(ctx, jobsCtx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := s.store.Jobs().Claim(ctx, s.worker, s.registry.Types(), time.Now(), jobLease, 1)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Job worker failed to claim jobs", "error", err)
+		}
+		for i := range claimed {
+			s.runJob(jobsCtx, &claimed[i])
+		}
+		if len(claimed) > 0 && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob runs a claimed job, renewing its lease meanwhile, and records the
+// outcome.
+func (s *JobService) runJob(ctx context.Context, job *models.Job) {
+	ctx = logging.WithAttrs(ctx, slog.Uint64("job_id", uint64(job.ID)), slog.String("job_type", job.Type))
+	runCtx, stop := context.WithCancel(ctx)
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		ticker := time.NewTicker(jobLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				if err := s.store.Jobs().Extend(runCtx, job.ID, s.worker, time.Now().Add(jobLease)); err != nil && runCtx.Err() == nil {
+					slog.ErrorContext(runCtx, "Job: renewing the lease failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	err := s.handle(runCtx, job)
+	stop()
+	<-heartbeat
+
+	if err := s.finish(context.WithoutCancel(ctx), job, err, ctx.Err() != nil); err != nil {
+		slog.ErrorContext(ctx, "Job: recording the outcome failed", "error", err)
+	}
+}
+
+// handle runs the handler of job, turning a panic into a permanent failure.
+func (s *JobService) handle(ctx context.Context, job *models.Job) (err error) {
+	handler, ok := s.registry.Handler(job.Type)
+	if !ok {
+		return jobs.Permanent(fmt.Errorf("no handler registered for jobs of type %s", job.Type))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = jobs.Permanent(fmt.Errorf("handler panicked: %v", r))
+		}
+	}()
+	return handler(ctx, json.RawMessage(job.Payload))
+}
+
+// finish records the outcome of an attempt of job. A failed job is retried
+// with an exponential backoff, or goes to the dead letters once the failure is
+// permanent or its attempts are exhausted. A job interrupted by the shutdown
+// goes back to the queue as if the attempt never happened.
+func (s *JobService) finish(ctx context.Context, job *models.Job, jobErr error, interrupted bool) error {
+	now := time.Now()
+	job.LockedBy = ""
+	job.LockedUntil = nil
+
+	switch {
+	case jobErr == nil:
+		job.Status = models.JobSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+	case interrupted:
+		job.Status = models.JobQueued
+		job.Attempts--
+		job.RunAt = now
+		job.LastError = "interrupted by shutdown: " + jobErr.Error()
+		slog.WarnContext(ctx, "Job interrupted by shutdown, requeued")
+	case jobs.IsPermanent(jobErr) || job.Attempts >= job.MaxAttempts:
+		job.Status = models.JobDead
+		job.LastError = jobErr.Error()
+		job.FinishedAt = &now
+		slog.ErrorContext(ctx, "Job dead", "attempts", job.Attempts, "error", jobErr)
+	default:
+		job.Status = models.JobQueued
+		job.LastError = jobErr.Error()
+		job.RunAt = now.Add(jobBackoff(job.Attempts))
+		slog.WarnContext(ctx, "Job attempt failed, retrying", "attempts", job.Attempts, "max_attempts", job.MaxAttempts, "retry_at", job.RunAt, "error", jobErr)
+	}
+	return s.store.Jobs().Save(ctx, job)
+}
+
+// jobBackoff returns the delay before retrying a job that failed attempts
+// times: jobMinBackoff doubled per attempt, up to jobMaxBackoff.
+func jobBackoff(attempts int) time.Duration {
+	backoff := jobMinBackoff
+	for i := 1; i < attempts && backoff < jobMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, jobMaxBackoff)
+}
+
+// lead competes for the scheduler lease every interval until ctx is
+// cancelled, and enqueues the due scheduled jobs while holding it.
+func (s *JobService) lead(ctx context.Context, interval time.Duration) {
+	ttl := max(schedulerLeaseTTL, 3*interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	leader := false
+	for {
+		held, err := s.store.Leases().Acquire(ctx, schedulerLease, s.worker, time.Now(), ttl)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				slog.Error("Job scheduler failed to renew its lease", "error", err)
+			}
+		case held != leader:
+			leader = held
+			if leader {
+				slog.Info("Job scheduler: this replica now enqueues the scheduled jobs")
+			} else {
+				slog.Info("Job scheduler: another replica took over the scheduled jobs")
+			}
+		}
+		if leader && err == nil {
+			if err := s.EnqueueDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				slog.Error("Job scheduler failed", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if leader {
+				// Lets another replica take over without waiting for the lease to expire.
+				if err := s.store.Leases().Release(context.WithoutCancel(ctx), schedulerLease, s.worker); err != nil {
+					slog.Error("Job scheduler failed to release its lease", "error", err)
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnqueueDue enqueues a job for every schedule due at now and moves the
+// schedule to its next run. Advancing a schedule and enqueuing its job are
+// atomic, so a run is never lost nor enqueued twice.
+func (s *JobService) EnqueueDue(ctx context.Context, now time.Time) error {
+	for _, sched := range s.schedules {
+		state, err := s.store.JobSchedules().Get(ctx, sched.name)
+		if errors.Is(err, repositories.ErrNotFound) {
+			// First time this schedule is seen: it runs at its next time.
+			err = s.store.JobSchedules().Init(ctx, &models.JobSchedule{Name: sched.name, NextRunAt: sched.schedule.Next(now)})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if state.NextRunAt.After(now) {
+			continue
+		}
+
+		err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+			advanced, err := tx.JobSchedules().Advance(ctx, sched.name, now, sched.schedule.Next(now))
+			if err != nil || !advanced {
+				return err
+			}
+			job, err := s.enqueue(ctx, tx, sched.jobType, sched.payload, EnqueueOptions{}, sched.name)
+			if err == nil {
+				slog.Info("Job scheduler enqueued a job", "job_id", job.ID, "job_type", job.Type, "schedule", sched.name)
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("schedule %s: %w", sched.name, err)
+		}
+	}
+	return nil
+}
+
+// ListJobs returns the jobs matching filter, newest first.
+func (s *JobService) ListJobs(ctx context.Context, filter repositories.JobFilter) ([]models.Job, error) {
+	return s.store.Jobs().List(ctx, filter)
+}
+
+// GetJob returns a job.
+func (s *JobService) GetJob(ctx context.Context, id uint) (models.Job, error) {
+	job, err := s.store.Jobs().Get(ctx, id)
+	return job, orNotFound(err, "Job not found")
+}
+
+// ScheduleState describes a schedule registered in this process and its
+// shared state.
+type ScheduleState struct {
+	Name      string
+	Spec      string
+	JobType   string
+	NextRunAt *time.Time // Nil until the scheduler first saw it
+	LastRunAt *time.Time
+}
+
+// JobStats summarizes the job queue.
+type JobStats struct {
+	Counts    map[string]int64 // Number of jobs per status
+	Types     []string         // Job types this process handles
+	Schedules []ScheduleState
+	// Leader holds the scheduler lease, nil when no replica does.
+	Leader *models.LeaderLease
+}
+
+// Stats summarizes the job queue, the schedules and the scheduler leader.
+func (s *JobService) Stats(ctx context.Context) (JobStats, error) {
+	stats := JobStats{Types: s.registry.Types()}
+	var err error
+	if stats.Counts, err = s.store.Jobs().CountByStatus(ctx); err != nil {
+		return stats, err
+	}
+
+	states, err := s.store.JobSchedules().List(ctx)
+	if err != nil {
+		return stats, err
+	}
+	byName := map[string]models.JobSchedule{}
+	for _, state := range states {
+		byName[state.Name] = state
+	}
+	for _, sched := range s.schedules {
+		schedule := ScheduleState{Name: sched.name, Spec: sched.spec, JobType: sched.jobType}
+		if state, ok := byName[sched.name]; ok {
+			schedule.NextRunAt = &state.NextRunAt
+			schedule.LastRunAt = state.LastRunAt
+		}
+		stats.Schedules = append(stats.Schedules, schedule)
+	}
+
+	lease, err := s.store.Leases().Get(ctx, schedulerLease)
+	switch {
+	case err == nil:
+		if lease.ExpiresAt.After(time.Now()) {
+			stats.Leader = &lease
+		}
+	case !errors.Is(err, repositories.ErrNotFound):
+		return stats, err
+	}
+	return stats, nil
+}
+
+// RetryJob queues a dead or cancelled job again with a fresh set of attempts.
+func (s *JobService) RetryJob(ctx context.Context, id uint) (models.Job, error) {
+	var job models.Job
+	err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+		var err error
+		if job, err = tx.Jobs().Get(ctx, id); err != nil {
+			return orNotFound(err, "Job not found")
+		}
+		if job.Status != models.JobDead && job.Status != models.JobCancelled {
+			return utils.ErrConflict(fmt.Sprintf("Only dead or cancelled jobs can be retried, this one is %s", job.Status))
+		}
+		job.Status = models.JobQueued
+		job.Attempts = 0
+		job.RunAt = time.Now()
+		job.FinishedAt = nil
+		return tx.Jobs().Save(ctx, &job)
+	})
+	return job, err
+}
+
+// CancelJob stops a queued job from running. Running jobs can't be cancelled.
+func (s *JobService) CancelJob(ctx context.Context, id uint) (models.Job, error) {
+	var job models.Job
+	err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+		var err error
+		if job, err = tx.Jobs().Get(ctx, id); err != nil {
+			return orNotFound(err, "Job not found")
+		}
+		if job.Status != models.JobQueued {
+			return utils.ErrConflict(fmt.Sprintf("Only queued jobs can be cancelled, this one is %s", job.Status))
+		}
+		now := time.Now()
+		job.Status = models.JobCancelled
+		job.FinishedAt = &now
+		return tx.Jobs().Save(ctx, &job)
+	})
+	return job, err
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/events"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+)
+
+// Outbox dispatcher settings.
+const (
+	outboxBatchSize = 100
+	// outboxLease is how long a dispatcher owns the events it claimed. It must
+	// exceed the time to publish a batch, or another dispatcher takes over.
+	outboxLease = 5 * time.Minute
+	// outboxMaxAttempts is how often an event is tried before it is dead.
+	outboxMaxAttempts = 20
+	outboxMaxBackoff  = 10 * time.Minute
+)
+
+// OutboxService publishes the domain events written to the outbox.
+type OutboxService struct {
+	store repositories.Store
+}
+
+// NewOutboxService creates an OutboxService.
+func NewOutboxService(store repositories.Store) *OutboxService {
+	return &OutboxService{store: store}
+}
+
+// Dispatch publishes one batch of due events to sink and returns how many were
+// published. When an event fails, it is retried later with an exponential
+// backoff and the following events of its aggregate wait for it; after
+// outboxMaxAttempts it is given up on so its aggregate can move on.
+func (s *OutboxService) Dispatch(ctx context.Context, sink events.Sink) (int, error) {
+	claimed, err := s.store.Outbox().Claim(ctx, time.Now(), outboxLease, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	type aggregate struct {
+		typ string
+		id  uint
+	}
+	failed := map[aggregate]bool{}
+
+	published := 0
+	for i := range claimed {
+		event := &claimed[i]
+		key := aggregate{event.AggregateType, event.AggregateID}
+		event.LockedUntil = nil
+
+		if !failed[key] {
+			err := sink.Publish(ctx, toEvent(*event))
+			if ctx.Err() != nil {
+				// Shutting down: the claim expires and another dispatcher takes over.
+				return published, ctx.Err()
+			}
+
+			now := time.Now()
+			if err == nil {
+				event.PublishedAt = &now
+				published++
+			} else {
+				event.Attempts++
+				event.LastError = err.Error()
+				if event.Attempts >= outboxMaxAttempts {
+					event.DeadAt = &now
+					slog.Error("Outbox event dead", "event_id", event.ID, "event_type", event.Type, "attempts", event.Attempts, "error", err)
+				} else {
+					event.NextAttemptAt = now.Add(outboxBackoff(event.Attempts))
+					failed[key] = true
+					slog.Warn("Outbox event failed, retrying", "event_id", event.ID, "event_type", event.Type, "attempts", event.Attempts, "retry_at", event.NextAttemptAt, "error", err)
+				}
+			}
+		}
+
+		// Events following a failure of their aggregate are only released.
+		if err := s.store.Outbox().Save(ctx, event); err != nil {
+			return published, err
+		}
+	}
+	return published, nil
+}
+
+// Run publishes the outbox to sink until ctx is cancelled, checking for new
+// events every interval and right away while it publishes some: a batch
+// holds one event per aggregate, so the backlog of one takes several.
+func (s *OutboxService) Run(ctx context.Context, sink events.Sink, interval time.Duration) {
+	slog.Info("Outbox dispatcher started", "sink", sink.Name(), "interval", interval.String())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := s.Dispatch(ctx, sink)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Outbox dispatcher failed", "error", err)
+		}
+		if err == nil && published > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// outboxBackoff returns the delay before the next attempt to publish an event
+// that failed attempts times: one second, doubling up to outboxMaxBackoff.
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
+
+// toEvent turns an outbox row into the event delivered to sinks.
+func toEvent(event models.OutboxEvent) events.Event {
+	return events.Event{
+		ID:            event.ID,
+		Type:          event.Type,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		ShopID:        event.ShopID,
+		OccurredAt:    event.CreatedAt,
+		Data:          json.RawMessage(event.Payload),
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// QuotaPeriodLayout formats the months the API usage is counted in, in UTC.
+const QuotaPeriodLayout = "2006-01"
+
+// Usage is the API usage of a shop in a month.
+type Usage struct {
+	ShopID   uint
+	Period   string
+	Requests int64
+	// Quota caps Requests; zero means unlimited.
+	Quota int64
+	// Resets is the start of the next period.
+	Resets time.Time
+}
+
+// Remaining returns the requests the shop can still make in the period, -1
+// when unlimited.
+func (u Usage) Remaining() int64 {
+	if u.Quota == 0 {
+		return -1
+	}
+	return max(u.Quota-u.Requests, 0)
+}
+
+// QuotaService counts the API requests made for each shop against its monthly
+// quota.
+type QuotaService struct {
+	store repositories.Store
+	// DefaultMonthly is the quota of the shops without one of their own; zero
+	// means unlimited.
+	DefaultMonthly int64
+}
+
+// NewQuotaService creates a QuotaService on top of store, without a default
+// quota.
+func NewQuotaService(store repositories.Store) *QuotaService {
+	return &QuotaService{store: store}
+}
+
+// Consume counts a request made by ownerID for one of their shops, deleted
+// ones included, failing with a 429 once the shop used up its quota for the
+// month.
+func (s *QuotaService) Consume(ctx context.Context, ownerID, shopID uint) (Usage, error) {
+	shop, err := ownedShop(ctx, s.store.Shops().GetAny, ownerID, shopID)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := s.usage(shop, time.Now())
+	row, counted, err := s.store.Quotas().Consume(ctx, shop.ID, usage.Period, usage.Quota)
+	if err != nil {
+		return usage, err
+	}
+	usage.Requests = row.Requests
+	if !counted {
+		return usage, utils.ErrQuotaExceeded("The shop used up its monthly API quota of " + strconv.FormatInt(usage.Quota, 10) + " requests")
+	}
+	return usage, nil
+}
+
+// Usage returns the API usage of a shop managed by ownerID this month.
+func (s *QuotaService) Usage(ctx context.Context, ownerID, shopID uint) (Usage, error) {
+	shop, err := ownedShop(ctx, s.store.Shops().Get, ownerID, shopID)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := s.usage(shop, time.Now())
+	row, err := s.store.Quotas().Get(ctx, shop.ID, usage.Period)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return usage, err
+	}
+	usage.Requests = row.Requests
+	return usage, nil
+}
+
+// SetQuota sets the monthly quota of a shop, nil restoring the default one,
+// and returns the shop's usage under the new quota.
+func (s *QuotaService) SetQuota(ctx context.Context, shopID uint, quota *int64) (Usage, error) {
+	shop, err := s.store.Shops().Get(ctx, shopID)
+	if err != nil {
+		return Usage{}, orNotFound(err, "Shop not found")
+	}
+
+	before := shop
+	shop.MonthlyAPIQuota = quota
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Shops().Update(ctx, &shop); err != nil {
+			return err
+		}
+		return recordShop(ctx, tx, models.AuditUpdate, shop, before, shop)
+	})
+	if err != nil {
+		return Usage{}, err
+	}
+	return s.Usage(ctx, shop.OwnerID, shop.ID)
+}
+
+// usage returns the empty usage of shop in the period of now.
+func (s *QuotaService) usage(shop models.Shop, now time.Time) Usage {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	usage := Usage{
+		ShopID: shop.ID,
+		Period: start.Format(QuotaPeriodLayout),
+		Quota:  s.DefaultMonthly,
+		Resets: start.AddDate(0, 1, 0),
+	}
+	if shop.MonthlyAPIQuota != nil {
+		usage.Quota = *shop.MonthlyAPIQuota
+	}
+	return usage
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/repositories"
+)
+
+// RatePolicy lets each client send Limit requests per Window, in bursts of up
+// to Limit. The zero RatePolicy doesn't limit anything.
+type RatePolicy struct {
+	Name   string // Tells apart the buckets of the policies
+	Limit  int
+	Window time.Duration
+}
+
+// Enabled reports whether the policy limits the requests.
+func (p RatePolicy) Enabled() bool {
+	return p.Limit > 0 && p.Window > 0
+}
+
+// RateLimit is the state of the bucket of a client after a request.
+type RateLimit struct {
+	Policy  RatePolicy
+	Allowed bool
+	// Remaining is the number of requests the client can still send at once.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, when denied.
+	RetryAfter time.Duration
+}
+
+// RateLimitService enforces the rate limit policies with token buckets.
+type RateLimitService struct {
+	// Buckets stores the buckets: the Store's to share them between the
+	// replicas, or an in-memory one per replica.
+	Buckets repositories.RateLimitRepository
+
+	// Auth applies to the login and signup endpoints, Read to the GET requests
+	// of the API and Write to its other requests.
+	Auth, Read, Write RatePolicy
+}
+
+// NewRateLimitService creates a RateLimitService keeping the buckets in store,
+// with every policy disabled.
+func NewRateLimitService(store repositories.Store) *RateLimitService {
+	return &RateLimitService{Buckets: store.RateLimits()}
+}
+
+// Take takes a token from the bucket of client under policy.
+func (s *RateLimitService) Take(ctx context.Context, policy RatePolicy, client string) (RateLimit, error) {
+	bucket, allowed, err := s.Buckets.Take(ctx, policy.Name+":"+client, policy.Limit, policy.Window, time.Now())
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	// Time to refill n tokens.
+	refill := func(n float64) time.Duration {
+		return time.Duration(math.Ceil(n * float64(policy.Window) / float64(policy.Limit)))
+	}
+	limit := RateLimit{
+		Policy:    policy,
+		Allowed:   allowed,
+		Remaining: int(bucket.Tokens),
+		Reset:     refill(float64(policy.Limit) - bucket.Tokens),
+	}
+	if !allowed {
+		limit.RetryAfter = refill(1 - bucket.Tokens)
+	}
+	return limit, nil
+}
+
+// Run purges the buckets idle for longer than the longest window, which are
+// full again, every interval until ctx is cancelled.
+func (s *RateLimitService) Run(ctx context.Context, interval time.Duration) {
+	idle := max(s.Auth.Window, s.Read.Window, s.Write.Window)
+	if idle == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.Buckets.PurgeIdleBefore(ctx, time.Now().Add(-idle))
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to purge the idle rate limit buckets", "error", err)
+			} else if purged > 0 {
+				slog.DebugContext(ctx, "Purged the idle rate limit buckets", "count", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/jobs"
+	"github.com/mohamedhabas11/golang-api/repositories"
+)
+
+// RetentionService permanently removes soft-deleted rows, published outbox
+// events, finished webhook deliveries and finished jobs once they expire.
+type RetentionService struct {
+	store repositories.Store
+}
+
+// NewRetentionService creates a RetentionService.
+func NewRetentionService(store repositories.Store) *RetentionService {
+	return &RetentionService{store: store}
+}
+
+// PurgeDeletedBefore permanently removes rows that were soft-deleted before
+// cutoff, and outbox events, webhook deliveries and jobs that were published
+// or finished before it, and returns how many were removed. Dead jobs are kept
+// for inspection. Children are purged first so foreign keys never point at a
+// row that is already gone.
+func (s *RetentionService) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var total int64
+	for _, purge := range []func(context.Context, time.Time) (int64, error){
+		s.store.Items().PurgeDeletedBefore,
+		s.store.Inventories().PurgeDeletedBefore,
+		s.store.Employees().PurgeDeletedBefore,
+		s.store.Transfers().PurgeDeletedBefore,
+		s.store.Webhooks().PurgeOfShopsDeletedBefore,
+		s.store.Shops().PurgeDeletedBefore,
+		s.store.Customers().PurgeDeletedBefore,
+		s.store.Outbox().PurgePublishedBefore,
+		s.store.WebhookDeliveries().PurgeFinishedBefore,
+		s.store.Jobs().PurgeFinishedBefore,
+	} {
+		purged, err := purge(ctx, cutoff)
+		total += purged
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// JobPurgeExpired is the type of the job running PurgeDeletedBefore.
+const JobPurgeExpired = "retention.purge_expired"
+
+// PurgeExpiredPayload is the payload of JobPurgeExpired.
+type PurgeExpiredPayload struct {
+	// RetentionDays is how long expired rows are kept before being purged.
+	RetentionDays int `json:"retention_days"`
+}
+
+// registerJobs adds the handler of JobPurgeExpired to registry.
+func (s *RetentionService) registerJobs(registry *jobs.Registry) {
+	jobs.Register(registry, JobPurgeExpired, func(ctx context.Context, payload PurgeExpiredPayload) error {
+		if payload.RetentionDays <= 0 {
+			return jobs.Permanent(fmt.Errorf("invalid retention of %d days", payload.RetentionDays))
+		}
+		retention := time.Duration(payload.RetentionDays) * 24 * time.Hour
+		purged, err := s.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
+		if purged > 0 {
+			slog.InfoContext(ctx, "Retention job purged expired rows", "rows", purged)
+		}
+		return err
+	})
+}
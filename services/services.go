@@ -0,0 +1,80 @@
+// Package services holds the business rules of the API. Services work on top
+// of a repositories.Store and report rule violations as utils.APIError values.
+package services
+
+import (
+	"errors"
+
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Services groups every service built on one Store.
+type Services struct {
+	Customers   *CustomerService
+	Shops       *ShopService
+	Transfers   *TransferService
+	Inventories *InventoryService
+	Items       *ItemService
+	Employees   *EmployeeService
+	Retention   *RetentionService
+	Health      *HealthService
+	Audit       *AuditService
+	Outbox      *OutboxService
+	Webhooks    *WebhookService
+	Jobs        *JobService
+	Streams     *StreamService
+	RateLimits  *RateLimitService
+	Quotas      *QuotaService
+}
+
+// New creates every service on top of store and registers their job handlers.
+func New(store repositories.Store) *Services {
+	svc := &Services{
+		Customers:   NewCustomerService(store),
+		Shops:       NewShopService(store),
+		Transfers:   NewTransferService(store),
+		Inventories: NewInventoryService(store),
+		Items:       NewItemService(store),
+		Employees:   NewEmployeeService(store),
+		Retention:   NewRetentionService(store),
+		Health:      NewHealthService(store),
+		Audit:       NewAuditService(store),
+		Outbox:      NewOutboxService(store),
+		Webhooks:    NewWebhookService(store),
+		Jobs:        NewJobService(store),
+		Streams:     NewStreamService(store),
+		RateLimits:  NewRateLimitService(store),
+		Quotas:      NewQuotaService(store),
+	}
+	svc.Retention.registerJobs(svc.Jobs.Registry())
+	return svc
+}
+
+// DeleteOptions controls how a resource is deleted.
+type DeleteOptions struct {
+	// Purge removes the resource permanently instead of moving it to the trash.
+	Purge bool
+	// Force deletes a shop even though its inventories still hold stock.
+	Force bool
+}
+
+// orNotFound replaces repositories.ErrNotFound with a 404 carrying detail.
+func orNotFound(err error, detail string) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return utils.ErrNotFound(detail)
+	}
+	return err
+}
+
+// exists turns the result of a lookup into whether the row exists, passing on
+// any error other than ErrNotFound.
+func exists[T any](_ T, err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, repositories.ErrNotFound):
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// ShopService manages shops and authenticates their owners.
+type ShopService struct {
+	store repositories.Store
+}
+
+// NewShopService creates a ShopService.
+func NewShopService(store repositories.Store) *ShopService {
+	return &ShopService{store: store}
+}
+
+// Register creates a new shop along with its owner. An existing owner can open
+// another shop, but only with their own credentials.
+func (s *ShopService) Register(ctx context.Context, req dto.CreateShopRequest) (models.Shop, error) {
+	var shop models.Shop
+	err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := checkShopEmail(ctx, tx, req.Email, 0); err != nil {
+			return err
+		}
+
+		// Create or retrieve the shop owner.
+		owner, err := tx.Owners().FindByEmail(ctx, req.Owner.Email)
+		found, err := exists(owner, err)
+		if err != nil {
+			return err
+		} else if !found {
+			owner = req.Owner.ToModel()
+
+			// Hash the owner's password.
+			hashedPassword, err := utils.HashPassword(req.Owner.Password)
+			if err != nil {
+				return utils.ErrInternal("Error hashing owner password")
+			}
+			owner.Password = models.Secret(hashedPassword)
+
+			if err := tx.Owners().Create(ctx, &owner); err != nil {
+				return err
+			}
+		} else if !utils.ComparePassword(owner.Password.Reveal(), req.Owner.Password) {
+			return utils.ErrUnauthorized("Invalid owner credentials")
+		}
+
+		// Create the shop with the owner's ID.
+		created := req.ToModel()
+		created.OwnerID = owner.ID
+		if err := tx.Shops().Create(ctx, &created); err != nil {
+			return err
+		}
+		if err := recordShop(ctx, tx, models.AuditCreate, created, nil, created); err != nil {
+			return err
+		}
+		if !found {
+			err := record(ctx, tx, auditRecord{
+				Action: models.AuditCreate, EntityType: models.AuditShopOwner, EntityID: owner.ID, Entity: owner, ShopID: created.ID, After: owner,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Load the owner details for the response.
+		shop, err = tx.Shops().Get(ctx, created.ID)
+		return err
+	})
+	return shop, err
+}
+
+// AuthenticateOwner returns the shop owner matching the credentials.
+func (s *ShopService) AuthenticateOwner(ctx context.Context, email, password string) (models.ShopOwner, error) {
+	owner, err := s.store.Owners().FindByEmail(ctx, email)
+	if err != nil {
+		return owner, orUnauthorized(err)
+	}
+
+	// Compare the provided password with the stored hashed password.
+	if !utils.ComparePassword(owner.Password.Reveal(), password) {
+		return owner, utils.ErrUnauthorized("Invalid credentials")
+	}
+	return owner, nil
+}
+
+// List returns every shop with its owner.
+func (s *ShopService) List(ctx context.Context) ([]models.Shop, error) {
+	return s.store.Shops().List(ctx, repositories.ListOptions{})
+}
+
+// Get returns a shop with its owner and the requested relations.
+func (s *ShopService) Get(ctx context.Context, id uint, relations repositories.ShopRelations) (models.Shop, error) {
+	shop, err := s.store.Shops().GetWithRelations(ctx, id, relations)
+	return shop, orNotFound(err, "Shop not found")
+}
+
+// ListByOwner returns every shop managed by an owner.
+func (s *ShopService) ListByOwner(ctx context.Context, ownerID uint) ([]models.Shop, error) {
+	return s.store.Shops().ListByOwner(ctx, ownerID)
+}
+
+// CreateForOwner opens an additional shop for an existing owner.
+func (s *ShopService) CreateForOwner(ctx context.Context, ownerID uint, req dto.CreateOwnedShopRequest) (models.Shop, error) {
+	if err := checkShopEmail(ctx, s.store, req.Email, 0); err != nil {
+		return models.Shop{}, err
+	}
+
+	shop := req.ToModel()
+	shop.OwnerID = ownerID
+	err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Shops().Create(ctx, &shop); err != nil {
+			return err
+		}
+		return recordShop(ctx, tx, models.AuditCreate, shop, nil, shop)
+	})
+	if err != nil {
+		return shop, err
+	}
+	return s.store.Shops().Get(ctx, shop.ID)
+}
+
+// Update applies the allowed changes to a shop managed by ownerID.
+func (s *ShopService) Update(ctx context.Context, ownerID, id uint, req dto.UpdateShopRequest) (models.Shop, error) {
+	shop, err := ownedShop(ctx, s.store.Shops().Get, ownerID, id)
+	if err != nil {
+		return shop, err
+	}
+
+	// Check if the new email is already used by another shop.
+	if req.Email != nil && *req.Email != shop.Email {
+		if err := checkShopEmail(ctx, s.store, *req.Email, shop.ID); err != nil {
+			return shop, err
+		}
+	}
+	before := shop
+	req.ApplyTo(&shop)
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Shops().Update(ctx, &shop); err != nil {
+			return err
+		}
+		return recordShop(ctx, tx, models.AuditUpdate, shop, before, shop)
+	})
+	if err != nil {
+		return shop, err
+	}
+	return s.store.Shops().Get(ctx, shop.ID)
+}
+
+// Delete archives (soft-deletes) a shop managed by ownerID together with its
+// inventories, items, employees and pending transfers. Shops whose inventories
+// still hold stock are only deleted with opts.Force; opts.Purge removes
+// everything permanently, including a shop already in the trash.
+func (s *ShopService) Delete(ctx context.Context, ownerID, id uint, opts DeleteOptions) error {
+	get := s.store.Shops().Get
+	if opts.Purge {
+		get = s.store.Shops().GetAny
+	}
+	shop, err := ownedShop(ctx, get, ownerID, id)
+	if err != nil {
+		return err
+	}
+
+	// Safeguard: refuse to throw away stock unless explicitly forced.
+	if !opts.Force {
+		stock, err := s.store.Items().StockByShop(ctx, shop.ID)
+		if err != nil {
+			return err
+		}
+		if stock > 0 {
+			return utils.ErrConflict(fmt.Sprintf("The shop's inventories still hold %d items in stock; retry with ?force=true to delete it anyway", stock))
+		}
+	}
+
+	// Permanently remove the shop and everything that belongs to it.
+	if opts.Purge {
+		return s.store.Transaction(ctx, func(tx repositories.Store) error {
+			if err := tx.Items().PurgeByShop(ctx, shop.ID); err != nil {
+				return err
+			}
+			if err := tx.Inventories().PurgeByShop(ctx, shop.ID); err != nil {
+				return err
+			}
+			if err := tx.Employees().PurgeByShop(ctx, shop.ID); err != nil {
+				return err
+			}
+			if err := tx.Transfers().PurgeByShop(ctx, shop.ID); err != nil {
+				return err
+			}
+			if err := tx.Webhooks().PurgeByShop(ctx, shop.ID); err != nil {
+				return err
+			}
+			if err := tx.Shops().Purge(ctx, shop.ID); err != nil {
+				return err
+			}
+			return recordShop(ctx, tx, models.AuditPurge, shop, shop, nil)
+		})
+	}
+
+	// Archive the shop and cascade to its children with one shared timestamp so
+	// a restore knows which rows went with it.
+	deletedAt := time.Now()
+	return s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Items().SoftDeleteByShop(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		if err := tx.Inventories().SoftDeleteByShop(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		if err := tx.Employees().SoftDeleteByShop(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		// Pending transfers can no longer be accepted.
+		if err := tx.Transfers().CancelPendingByShop(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		if err := tx.Shops().SoftDelete(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		return recordShop(ctx, tx, models.AuditDelete, shop, nil, nil)
+	})
+}
+
+// Restore brings an archived shop managed by ownerID back together with the
+// inventories, items and employees that were deleted with it.
+func (s *ShopService) Restore(ctx context.Context, ownerID, id uint) (models.Shop, error) {
+	shop, err := ownedShop(ctx, s.store.Shops().GetDeleted, ownerID, id)
+	if err != nil {
+		return shop, err
+	}
+
+	deletedAt := shop.DeletedAt.Time
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Items().RestoreByShop(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		if err := tx.Inventories().RestoreByShop(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		if err := tx.Employees().RestoreByShop(ctx, shop.ID, deletedAt); err != nil {
+			return err
+		}
+		if err := tx.Shops().Restore(ctx, shop.ID); err != nil {
+			return err
+		}
+		return recordShop(ctx, tx, models.AuditRestore, shop, nil, nil)
+	})
+	if err != nil {
+		return shop, err
+	}
+
+	return s.store.Shops().Get(ctx, shop.ID)
+}
+
+// recordShop records a mutation of shop in its audit log. The inventories,
+// items, employees and transfers cascaded with it are covered by this entry.
+func recordShop(ctx context.Context, tx repositories.Store, action string, shop models.Shop, before, after any) error {
+	return record(ctx, tx, auditRecord{
+		Action: action, EntityType: models.AuditShop, EntityID: shop.ID, Entity: shop, ShopID: shop.ID, Before: before, After: after,
+	})
+}
+
+// ownedShop loads a shop with get and makes sure it is managed by ownerID.
+func ownedShop(ctx context.Context, get func(context.Context, uint) (models.Shop, error), ownerID, id uint) (models.Shop, error) {
+	shop, err := get(ctx, id)
+	if err != nil {
+		return shop, orNotFound(err, "Shop not found")
+	}
+	if shop.OwnerID != ownerID {
+		return shop, utils.ErrForbidden("Only the shop owner can manage this shop")
+	}
+	return shop, nil
+}
+
+// checkShopEmail rejects an email already used by a shop other than exceptID.
+func checkShopEmail(ctx context.Context, store repositories.Store, email string, exceptID uint) error {
+	existing, err := store.Shops().FindByEmail(ctx, email)
+	found, err := exists(existing, err)
+	if err != nil {
+		return err
+	}
+	if found && existing.ID != exceptID {
+		return utils.ErrConflict("Shop with this email already exists")
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// transferTTL is how long the receiving owner has to accept a shop transfer.
+const transferTTL = 72 * time.Hour
+
+// TransferService hands shops over between owners.
+type TransferService struct {
+	store repositories.Store
+}
+
+// NewTransferService creates a TransferService.
+func NewTransferService(store repositories.Store) *TransferService {
+	return &TransferService{store: store}
+}
+
+// Create starts handing a shop managed by ownerID over to another ShopOwner. The
+// current owner confirms by re-entering their password; the transfer only takes
+// effect once the receiving owner accepts it.
+func (s *TransferService) Create(ctx context.Context, ownerID, shopID uint, req dto.TransferShopRequest) (models.ShopTransfer, error) {
+	var transfer models.ShopTransfer
+
+	shop, err := ownedShop(ctx, s.store.Shops().Get, ownerID, shopID)
+	if err != nil {
+		return transfer, err
+	}
+
+	// The current owner confirms the transfer with their password.
+	currentOwner, err := s.store.Owners().Get(ctx, shop.OwnerID)
+	if err != nil {
+		return transfer, err
+	}
+	if !utils.ComparePassword(currentOwner.Password.Reveal(), req.Password) {
+		return transfer, utils.ErrUnauthorized("Invalid credentials")
+	}
+
+	// Look up the receiving owner.
+	newOwner, err := s.store.Owners().FindByEmail(ctx, req.NewOwnerEmail)
+	if err != nil {
+		return transfer, orNotFound(err, "No shop owner with this email")
+	}
+	if newOwner.ID == currentOwner.ID {
+		return transfer, utils.ErrConflict("The shop already belongs to this owner")
+	}
+
+	transfer = models.ShopTransfer{
+		ShopID:      shop.ID,
+		FromOwnerID: currentOwner.ID,
+		ToOwnerID:   newOwner.ID,
+		Status:      models.TransferPending,
+		ExpiresAt:   time.Now().Add(transferTTL),
+	}
+	pendingConflict := utils.ErrConflict("A transfer of this shop is already pending")
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		// Only one transfer may be pending per shop; the unique index on the
+		// pending transfers rejects the one of a concurrent request.
+		if err := tx.Transfers().ExpirePending(ctx, shop.ID, time.Now()); err != nil {
+			return err
+		}
+		pending, err := tx.Transfers().CountPending(ctx, shop.ID)
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return pendingConflict
+		}
+
+		if err := tx.Transfers().Create(ctx, &transfer); err != nil {
+			if errors.Is(err, repositories.ErrDuplicate) {
+				return pendingConflict
+			}
+			return err
+		}
+		return recordTransfer(ctx, tx, models.AuditCreate, transfer, nil, transfer)
+	})
+	return transfer, err
+}
+
+// Accept completes a pending transfer; only the receiving owner may accept it.
+// It returns the shop with its new owner.
+func (s *TransferService) Accept(ctx context.Context, ownerID, shopID, transferID uint) (models.Shop, error) {
+	var shop models.Shop
+
+	transfer, err := s.pending(ctx, shopID, transferID, func(t models.ShopTransfer) bool { return t.ToOwnerID == ownerID })
+	if err != nil {
+		return shop, err
+	}
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		// Accepting the transfer first rejects a concurrent decline or cancel.
+		if err := setTransferStatus(ctx, tx, &transfer, models.TransferAccepted, time.Now()); err != nil {
+			return err
+		}
+
+		// The shop must still belong to the owner who started the transfer.
+		changed, err := tx.Shops().ChangeOwner(ctx, transfer.ShopID, transfer.FromOwnerID, transfer.ToOwnerID)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return utils.ErrConflict("The shop is no longer owned by the initiating owner")
+		}
+		if shop, err = tx.Shops().Get(ctx, transfer.ShopID); err != nil {
+			return err
+		}
+
+		before := shop
+		before.OwnerID = transfer.FromOwnerID
+		return recordShop(ctx, tx, models.AuditUpdate, shop, before, shop)
+	})
+	return shop, err
+}
+
+// Decline rejects a pending transfer; only the receiving owner may decline it.
+func (s *TransferService) Decline(ctx context.Context, ownerID, shopID, transferID uint) (models.ShopTransfer, error) {
+	return s.respond(ctx, shopID, transferID, models.TransferDeclined, func(t models.ShopTransfer) bool { return t.ToOwnerID == ownerID })
+}
+
+// Cancel withdraws a pending transfer; only the initiating owner may cancel it.
+func (s *TransferService) Cancel(ctx context.Context, ownerID, shopID, transferID uint) (models.ShopTransfer, error) {
+	return s.respond(ctx, shopID, transferID, models.TransferCancelled, func(t models.ShopTransfer) bool { return t.FromOwnerID == ownerID })
+}
+
+// ListByOwner returns the transfers an owner sent or received, newest first.
+func (s *TransferService) ListByOwner(ctx context.Context, ownerID uint) ([]models.ShopTransfer, error) {
+	return s.store.Transfers().ListByOwner(ctx, ownerID)
+}
+
+// respond moves a pending transfer the caller is allowed to act on into status.
+func (s *TransferService) respond(ctx context.Context, shopID, transferID uint, status string, allowed func(models.ShopTransfer) bool) (models.ShopTransfer, error) {
+	transfer, err := s.pending(ctx, shopID, transferID, allowed)
+	if err != nil {
+		return transfer, err
+	}
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		return setTransferStatus(ctx, tx, &transfer, status, time.Now())
+	})
+	return transfer, err
+}
+
+// pending loads a transfer of the shop, checks that the caller is the party
+// allowed to act on it and that it is still pending.
+func (s *TransferService) pending(ctx context.Context, shopID, transferID uint, allowed func(models.ShopTransfer) bool) (models.ShopTransfer, error) {
+	transfer, err := s.store.Transfers().Get(ctx, shopID, transferID)
+	if err != nil {
+		return transfer, orNotFound(err, "Transfer not found")
+	}
+
+	if !allowed(transfer) {
+		return transfer, utils.ErrForbidden("You are not allowed to act on this transfer")
+	}
+
+	if transfer.Status == models.TransferPending && time.Now().After(transfer.ExpiresAt) {
+		err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+			return setTransferStatus(ctx, tx, &transfer, models.TransferExpired, transfer.ExpiresAt)
+		})
+		if err != nil {
+			return transfer, err
+		}
+	}
+	if transfer.Status != models.TransferPending {
+		return transfer, utils.ErrConflict("The transfer is " + transfer.Status)
+	}
+	return transfer, nil
+}
+
+// setTransferStatus moves transfer into status and records the change. It
+// fails with a conflict when a concurrent request responded to it first.
+func setTransferStatus(ctx context.Context, tx repositories.Store, transfer *models.ShopTransfer, status string, at time.Time) error {
+	before := *transfer
+	changed, err := tx.Transfers().SetStatus(ctx, transfer, status, at)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return utils.ErrConflict("The transfer is no longer pending")
+	}
+	return recordTransfer(ctx, tx, models.AuditUpdate, *transfer, before, *transfer)
+}
+
+// recordTransfer records a mutation of transfer in the audit log of its shop.
+func recordTransfer(ctx context.Context, tx repositories.Store, action string, transfer models.ShopTransfer, before, after any) error {
+	return record(ctx, tx, auditRecord{
+		Action: action, EntityType: models.AuditTransfer, EntityID: transfer.ID, Entity: transfer, ShopID: transfer.ShopID, Before: before, After: after,
+	})
+}
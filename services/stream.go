@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/events"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Stream settings.
+const (
+	// streamBuffer is how many events a subscriber may lag behind before it is
+	// dropped; it reconnects and resumes from its last event.
+	streamBuffer      = 256
+	streamReplayBatch = 500
+	// defaultStreamHeartbeat is how often idle streams send a heartbeat.
+	defaultStreamHeartbeat = 15 * time.Second
+	streamRelayRetry       = time.Second
+)
+
+// streamTypes are the events streamed to subscribers: the changes of the
+// inventories and of their items.
+var streamTypes = []string{
+	events.InventoryCreated, events.InventoryUpdated, events.InventoryDeleted, events.InventoryRestored, events.InventoryPurged,
+	events.ItemCreated, events.ItemUpdated, events.ItemQuantityChanged, events.ItemOutOfStock, events.ItemDeleted, events.ItemRestored, events.ItemPurged,
+}
+
+// errSubscriberTooSlow ends a subscription that fell streamBuffer events behind.
+var errSubscriberTooSlow = errors.New("subscriber too slow, dropped")
+
+// StreamService streams the changes of inventories and items to live
+// subscribers such as shop-floor dashboards. It is the events.Sink of the live
+// streams: with a Relay, the replica publishing an event notifies every
+// replica, each handing it to its own subscribers.
+type StreamService struct {
+	store repositories.Store
+	// Relay carries the events to the other replicas; nil keeps them on this
+	// one. It must be set before Run.
+	Relay events.Relay
+	// Heartbeat is how often idle streams send a heartbeat, keeping proxies
+	// from closing them and detecting clients that went away.
+	Heartbeat time.Duration
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{} // Subscribers of this replica
+
+	stopOnce sync.Once
+	stopped  chan struct{} // Closed when Run returns, ending every stream
+}
+
+// NewStreamService creates a StreamService serving the subscribers of this
+// replica only.
+func NewStreamService(store repositories.Store) *StreamService {
+	return &StreamService{
+		store:     store,
+		Heartbeat: defaultStreamHeartbeat,
+		subs:      map[*Subscription]struct{}{},
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Name identifies the sink in logs.
+func (s *StreamService) Name() string {
+	return "live-streams"
+}
+
+// Publish hands a streamed event to the subscribers of every replica. Only its
+// ID goes through the Relay; the replicas load it from the outbox.
+func (s *StreamService) Publish(ctx context.Context, event events.Event) error {
+	if event.ShopID == nil || !slices.Contains(streamTypes, event.Type) {
+		return nil
+	}
+	if s.Relay != nil {
+		return s.Relay.Notify(ctx, strconv.FormatUint(uint64(event.ID), 10))
+	}
+	s.broadcast(ctx, event)
+	return nil
+}
+
+// Run hands the events notified through the Relay to the subscribers of this
+// replica until ctx is cancelled, listening again after a failure. It then
+// ends every stream.
+func (s *StreamService) Run(ctx context.Context) {
+	defer s.stopOnce.Do(func() { close(s.stopped) })
+	if s.Relay == nil {
+		<-ctx.Done()
+		return
+	}
+
+	slog.Info("Stream relay started")
+	for {
+		err := s.Relay.Listen(ctx, func(payload string) { s.relay(ctx, payload) })
+		if ctx.Err() != nil {
+			slog.Info("Stream relay stopped")
+			return
+		}
+		slog.Error("Stream relay failed, listening again", "retry_in", streamRelayRetry.String(), "error", err)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stream relay stopped")
+			return
+		case <-time.After(streamRelayRetry):
+		}
+	}
+}
+
+// relay loads the event notified with payload and hands it to the subscribers.
+func (s *StreamService) relay(ctx context.Context, payload string) {
+	id, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		slog.Warn("Stream relay: ignoring notification", "payload", payload)
+		return
+	}
+	event, err := s.store.Outbox().Get(ctx, uint(id))
+	if err != nil {
+		slog.Error("Stream relay: loading event failed", "event_id", id, "error", err)
+		return
+	}
+	s.broadcast(ctx, toEvent(event))
+}
+
+// broadcast hands event to the subscribers of this replica watching its shop
+// or one of the inventories it concerns.
+func (s *StreamService) broadcast(ctx context.Context, event events.Event) {
+	s.mu.Lock()
+	subs := slices.Collect(maps.Keys(s.subs))
+	s.mu.Unlock()
+
+	var inventories []uint
+	resolved := false
+	for _, sub := range subs {
+		if sub.filter.ShopID != *event.ShopID {
+			continue
+		}
+		if sub.filter.InventoryID != 0 {
+			if !resolved {
+				inventories, resolved = s.inventoriesOf(ctx, event), true
+			}
+			if !slices.Contains(inventories, sub.filter.InventoryID) {
+				continue
+			}
+		}
+		sub.deliver(event)
+	}
+}
+
+// StreamFilter selects the events of a subscription.
+type StreamFilter struct {
+	ShopID      uint
+	InventoryID uint // Zero streams every inventory of the shop
+}
+
+// inventoriesOf returns the inventories event concerns: the inventory itself,
+// or the one holding the item and the one it left when it moved. The events of
+// a soft-deleted item carry neither, so its row is looked up.
+func (s *StreamService) inventoriesOf(ctx context.Context, event events.Event) []uint {
+	if event.AggregateType == models.AuditInventory {
+		return []uint{event.AggregateID}
+	}
+
+	var data struct {
+		Changes struct {
+			InventoryID *struct {
+				Old *uint `json:"old"`
+				New *uint `json:"new"`
+			} `json:"inventory_id"`
+		} `json:"changes"`
+		State *struct {
+			InventoryID uint `json:"inventory_id"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return nil
+	}
+
+	var inventories []uint
+	if data.State != nil {
+		inventories = append(inventories, data.State.InventoryID)
+	}
+	if change := data.Changes.InventoryID; change != nil {
+		for _, id := range []*uint{change.Old, change.New} {
+			if id != nil {
+				inventories = append(inventories, *id)
+			}
+		}
+	}
+	if len(inventories) == 0 {
+		if item, err := s.store.Items().GetAny(ctx, event.AggregateID); err == nil {
+			inventories = append(inventories, item.InventoryID)
+		}
+	}
+	return inventories
+}
+
+// Authorize checks that ownerID may watch the shop, or the inventory, and
+// returns the filter of the subscription. An inventory must belong to shopID
+// when both are given.
+func (s *StreamService) Authorize(ctx context.Context, ownerID, shopID, inventoryID uint) (StreamFilter, error) {
+	filter := StreamFilter{ShopID: shopID, InventoryID: inventoryID}
+	if inventoryID != 0 {
+		inventory, err := s.store.Inventories().Get(ctx, inventoryID)
+		if err == nil && shopID != 0 && inventory.ShopID != shopID {
+			err = repositories.ErrNotFound
+		}
+		if err != nil {
+			return filter, orNotFound(err, "Inventory not found")
+		}
+		filter.ShopID = inventory.ShopID
+	}
+	if filter.ShopID == 0 {
+		return filter, utils.ErrBadRequest("A shop or an inventory is required")
+	}
+	_, err := ownedShop(ctx, s.store.Shops().Get, ownerID, filter.ShopID)
+	return filter, err
+}
+
+// Subscription receives the events of a filter published from the moment it
+// was created.
+type Subscription struct {
+	service      *StreamService
+	filter       StreamFilter
+	lastEventID  uint
+	events       chan events.Event
+	overflowOnce sync.Once
+	overflow     chan struct{} // Closed once the subscriber fell too far behind
+}
+
+// Subscribe starts receiving the events matching filter, an authorized filter.
+// With a lastEventID, the events after it still in the outbox are replayed
+// first. The subscription ends with Serve.
+func (s *StreamService) Subscribe(filter StreamFilter, lastEventID uint) *Subscription {
+	sub := &Subscription{
+		service:     s,
+		filter:      filter,
+		lastEventID: lastEventID,
+		events:      make(chan events.Event, streamBuffer),
+		overflow:    make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+	return sub
+}
+
+// deliver queues event without blocking the publisher.
+func (sub *Subscription) deliver(event events.Event) {
+	select {
+	case sub.events <- event:
+	default:
+		sub.overflowOnce.Do(func() { close(sub.overflow) })
+	}
+}
+
+// unsubscribe stops the delivery of events to sub.
+func (sub *Subscription) unsubscribe() {
+	sub.service.mu.Lock()
+	delete(sub.service.subs, sub)
+	sub.service.mu.Unlock()
+}
+
+// Serve calls send with the replayed events and then with the live ones, and
+// heartbeat whenever the stream was idle for the Heartbeat of the service,
+// until ctx is cancelled, the service stops, a callback fails or the
+// subscriber falls too far behind. It ends the subscription.
+func (sub *Subscription) Serve(ctx context.Context, send func(events.Event) error, heartbeat func() error) error {
+	defer sub.unsubscribe()
+
+	// Live events received while replaying may be replayed too.
+	replayed := map[uint]bool{}
+	for after := sub.lastEventID; after != 0; {
+		batch, err := sub.service.store.Outbox().ListByShop(ctx, sub.filter.ShopID, streamTypes, after, streamReplayBatch)
+		if err != nil {
+			return err
+		}
+		for _, row := range batch {
+			after = row.ID
+			event := toEvent(row)
+			if sub.filter.InventoryID != 0 && !slices.Contains(sub.service.inventoriesOf(ctx, event), sub.filter.InventoryID) {
+				continue
+			}
+			replayed[event.ID] = true
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		if len(batch) < streamReplayBatch {
+			break
+		}
+	}
+
+	ticker := time.NewTicker(sub.service.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.service.stopped:
+			return nil
+		case <-sub.overflow:
+			return errSubscriberTooSlow
+		case event := <-sub.events:
+			if replayed[event.ID] {
+				delete(replayed, event.ID)
+				continue
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+			ticker.Reset(sub.service.Heartbeat)
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,513 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/mohamedhabas11/golang-api/dto"
+	"github.com/mohamedhabas11/golang-api/events"
+	"github.com/mohamedhabas11/golang-api/models"
+	"github.com/mohamedhabas11/golang-api/repositories"
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Webhook delivery settings.
+const (
+	webhookBatchSize = 20
+	webhookTimeout   = 10 * time.Second
+	// webhookLease is how long a worker owns the deliveries it claimed. It must
+	// exceed webhookBatchSize * webhookTimeout, or another worker takes over.
+	webhookLease = 5 * time.Minute
+	// webhookMaxAttempts is how often a delivery is tried before it failed.
+	webhookMaxAttempts = 10
+	webhookMinBackoff  = 5 * time.Second
+	webhookMaxBackoff  = time.Hour
+	// webhookFailureLimit is how many attempts in a row may fail before the
+	// webhook is disabled.
+	webhookFailureLimit = 20
+	// webhookResponseLimit is how much of the response body is logged.
+	webhookResponseLimit = 1 << 10
+)
+
+// WebhookService manages the webhooks of shops and delivers their events. It
+// is the events.Sink queueing the deliveries of the outbox events.
+type WebhookService struct {
+	store  repositories.Store
+	client *http.Client
+
+	// AllowPrivateTargets lets webhooks reach loopback, private and link-local
+	// addresses. Keep it off in production: owners choose the URLs.
+	AllowPrivateTargets bool
+}
+
+// NewWebhookService creates a WebhookService.
+func NewWebhookService(store repositories.Store) *WebhookService {
+	s := &WebhookService{store: store}
+
+	// The address is checked once resolved so DNS cannot point a webhook at
+	// the internal network; redirects are not followed for the same reason.
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: s.checkTarget}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	s.client = &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return s
+}
+
+// List returns the webhooks of a shop managed by ownerID.
+func (s *WebhookService) List(ctx context.Context, ownerID, shopID uint) ([]models.Webhook, error) {
+	if _, err := ownedShop(ctx, s.store.Shops().Get, ownerID, shopID); err != nil {
+		return nil, err
+	}
+	return s.store.Webhooks().ListByShop(ctx, shopID)
+}
+
+// Get returns a webhook of a shop managed by ownerID.
+func (s *WebhookService) Get(ctx context.Context, ownerID, shopID, id uint) (models.Webhook, error) {
+	if _, err := ownedShop(ctx, s.store.Shops().Get, ownerID, shopID); err != nil {
+		return models.Webhook{}, err
+	}
+	webhook, err := s.store.Webhooks().Get(ctx, id)
+	if err == nil && webhook.ShopID != shopID {
+		err = repositories.ErrNotFound
+	}
+	return webhook, orNotFound(err, "Webhook not found")
+}
+
+// Create registers a webhook for a shop managed by ownerID, generating its
+// secret unless one is given.
+func (s *WebhookService) Create(ctx context.Context, ownerID, shopID uint, req dto.CreateWebhookRequest) (models.Webhook, error) {
+	webhook := req.ToModel()
+
+	if _, err := ownedShop(ctx, s.store.Shops().Get, ownerID, shopID); err != nil {
+		return webhook, err
+	}
+	webhook.ShopID = shopID
+
+	webhook.Secret = models.Secret(req.Secret)
+	if req.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return webhook, utils.ErrInternal("Error generating the webhook secret")
+		}
+		webhook.Secret = models.Secret(secret)
+	}
+
+	err := s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Webhooks().Create(ctx, &webhook); err != nil {
+			return err
+		}
+		return recordWebhook(ctx, tx, models.AuditCreate, webhook, nil, webhook)
+	})
+	return webhook, err
+}
+
+// Update applies the allowed changes to a webhook. Activating a webhook
+// clears its failure streak, so a disabled one gets a fresh start.
+func (s *WebhookService) Update(ctx context.Context, ownerID, shopID, id uint, req dto.UpdateWebhookRequest) (models.Webhook, error) {
+	webhook, err := s.Get(ctx, ownerID, shopID, id)
+	if err != nil {
+		return webhook, err
+	}
+
+	before := webhook
+	req.ApplyTo(&webhook)
+	if webhook.Active && !before.Active {
+		webhook.ConsecutiveFailures = 0
+		webhook.DisabledAt = nil
+		webhook.DisabledReason = ""
+	}
+
+	err = s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Webhooks().Update(ctx, &webhook); err != nil {
+			return err
+		}
+		return recordWebhook(ctx, tx, models.AuditUpdate, webhook, before, webhook)
+	})
+	return webhook, err
+}
+
+// Delete permanently removes a webhook together with its delivery log.
+func (s *WebhookService) Delete(ctx context.Context, ownerID, shopID, id uint) error {
+	webhook, err := s.Get(ctx, ownerID, shopID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Transaction(ctx, func(tx repositories.Store) error {
+		if err := tx.Webhooks().Delete(ctx, webhook.ID); err != nil {
+			return err
+		}
+		return recordWebhook(ctx, tx, models.AuditDelete, webhook, webhook, nil)
+	})
+}
+
+// Deliveries returns a page of the delivery log of a webhook, newest first.
+func (s *WebhookService) Deliveries(ctx context.Context, ownerID, shopID, id uint, query dto.WebhookDeliveryQuery) ([]models.WebhookDelivery, error) {
+	webhook, err := s.Get(ctx, ownerID, shopID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.WebhookDeliveries().ListByWebhook(ctx, webhook.ID, query.BeforeID, query.PageLimit())
+}
+
+// Ping sends a test event to a webhook right away, even a disabled one, and
+// returns the logged delivery. A failed ping is not retried and does not
+// count towards disabling the webhook.
+func (s *WebhookService) Ping(ctx context.Context, ownerID, shopID, id uint) (models.WebhookDelivery, error) {
+	webhook, err := s.Get(ctx, ownerID, shopID, id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	data, _ := json.Marshal(map[string]any{"webhook_id": webhook.ID, "event_types": webhook.EventTypeList()})
+	payload, err := json.Marshal(events.Event{
+		Type:          events.WebhookPing,
+		AggregateType: models.AuditWebhook,
+		AggregateID:   webhook.ID,
+		ShopID:        &webhook.ShopID,
+		OccurredAt:    time.Now(),
+		Data:          data,
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventType: events.WebhookPing,
+		Payload:   string(payload),
+	}
+	if err := s.begin(ctx, &delivery); err != nil {
+		return delivery, err
+	}
+	delivery.Status = models.DeliverySucceeded
+	if s.attempt(ctx, webhook, &delivery) != nil {
+		delivery.Status = models.DeliveryFailed
+	}
+	now := time.Now()
+	delivery.LockedUntil = nil
+	delivery.FinishedAt = &now
+
+	err = s.store.WebhookDeliveries().Save(ctx, &delivery)
+	return delivery, err
+}
+
+// Redeliver sends the payload of a past delivery again right away as a new
+// delivery, which is retried like any other if it fails. The receiver can
+// recognize the event by its unchanged ID.
+func (s *WebhookService) Redeliver(ctx context.Context, ownerID, shopID, id, deliveryID uint) (models.WebhookDelivery, error) {
+	webhook, err := s.Get(ctx, ownerID, shopID, id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	original, err := s.store.WebhookDeliveries().Get(ctx, webhook.ID, deliveryID)
+	if err != nil {
+		return original, orNotFound(err, "Delivery not found")
+	}
+	if original.Status == models.DeliveryPending {
+		return original, utils.ErrConflict("The delivery is still being attempted")
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID:    webhook.ID,
+		EventID:      original.EventID,
+		EventType:    original.EventType,
+		Payload:      original.Payload,
+		RedeliveryOf: &original.ID,
+	}
+	if err := s.begin(ctx, &delivery); err != nil {
+		return delivery, err
+	}
+	err = s.finish(ctx, webhook, &delivery, s.attempt(ctx, webhook, &delivery))
+	return delivery, err
+}
+
+// begin logs a delivery attempted right away, claimed so the worker leaves it
+// alone. Logging it first gives the attempt its delivery ID.
+func (s *WebhookService) begin(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	lockedUntil := now.Add(webhookLease)
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = now
+	delivery.LockedUntil = &lockedUntil
+	return s.store.WebhookDeliveries().Create(ctx, delivery)
+}
+
+// Name identifies the sink in logs.
+func (s *WebhookService) Name() string {
+	return "shop-webhooks"
+}
+
+// Publish queues a delivery of event to every active webhook of its shop
+// subscribed to its type. Events published again after a failure of another
+// sink are only queued once.
+func (s *WebhookService) Publish(ctx context.Context, event events.Event) error {
+	if event.ShopID == nil {
+		return nil
+	}
+	webhooks, err := s.store.Webhooks().ListByShop(ctx, *event.ShopID)
+	if err != nil {
+		return err
+	}
+
+	var subscribed []models.Webhook
+	for _, webhook := range webhooks {
+		if webhook.Active && webhook.Subscribes(event.Type) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.store.Transaction(ctx, func(tx repositories.Store) error {
+		queued, err := tx.WebhookDeliveries().ListByEvent(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		seen := map[uint]bool{}
+		for _, delivery := range queued {
+			seen[delivery.WebhookID] = true
+		}
+
+		for _, webhook := range subscribed {
+			if seen[webhook.ID] {
+				continue
+			}
+			delivery := models.WebhookDelivery{
+				WebhookID:     webhook.ID,
+				EventID:       event.ID,
+				EventType:     event.Type,
+				Payload:       string(payload),
+				Status:        models.DeliveryPending,
+				NextAttemptAt: time.Now(),
+			}
+			if err := tx.WebhookDeliveries().Create(ctx, &delivery); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Deliver attempts one batch of due deliveries and returns how many were
+// attempted. Failed deliveries are retried with an exponential backoff until
+// webhookMaxAttempts.
+func (s *WebhookService) Deliver(ctx context.Context) (int, error) {
+	claimed, err := s.store.WebhookDeliveries().Claim(ctx, time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := map[uint]models.Webhook{}
+	for i := range claimed {
+		delivery := &claimed[i]
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = s.store.Webhooks().Get(ctx, delivery.WebhookID)
+			if errors.Is(err, repositories.ErrNotFound) {
+				continue // Deleted since, along with its deliveries
+			}
+			if err != nil {
+				return i, err
+			}
+			webhooks[webhook.ID] = webhook
+		}
+
+		deliveryErr := s.attempt(ctx, webhook, delivery)
+		if ctx.Err() != nil {
+			// Shutting down: the claim expires and another worker takes over.
+			return i, ctx.Err()
+		}
+		if err := s.finish(ctx, webhook, delivery, deliveryErr); err != nil {
+			return i + 1, err
+		}
+	}
+	return len(claimed), nil
+}
+
+// Run delivers webhooks until ctx is cancelled, checking for due deliveries
+// every interval and draining full batches right away.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	slog.Info("Webhook worker started", "interval", interval.String())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		attempted, err := s.Deliver(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Webhook worker failed", "error", err)
+		}
+		if err == nil && attempted == webhookBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Webhook worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// attempt POSTs the payload of delivery to webhook, signed with its secret,
+// and records the outcome on delivery. It returns why the attempt failed.
+func (s *WebhookService) attempt(ctx context.Context, webhook models.Webhook, delivery *models.WebhookDelivery) error {
+	started := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &started
+	delivery.ResponseCode, delivery.ResponseBody, delivery.Error = 0, "", ""
+
+	err := s.post(ctx, webhook, delivery)
+	delivery.DurationMS = time.Since(started).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	return err
+}
+
+// post sends one attempt of delivery and records the response on it.
+func (s *WebhookService) post(ctx context.Context, webhook models.Webhook, delivery *models.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(events.HeaderEventID, strconv.FormatUint(uint64(delivery.EventID), 10))
+	req.Header.Set(events.HeaderEventType, delivery.EventType)
+	req.Header.Set(events.HeaderDeliveryID, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(events.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(events.HeaderSignature, events.Sign(webhook.Secret.Reveal(), timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	delivery.ResponseCode = resp.StatusCode
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	delivery.ResponseBody = string(responseBody)
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// finish stores the outcome of an attempt of delivery: it succeeded, is
+// retried later, or failed for good. The failure streak of the webhook is
+// updated, disabling it once it reaches webhookFailureLimit.
+func (s *WebhookService) finish(ctx context.Context, webhook models.Webhook, delivery *models.WebhookDelivery, deliveryErr error) error {
+	now := time.Now()
+	delivery.LockedUntil = nil
+
+	if deliveryErr == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.FinishedAt = &now
+		if err := s.store.WebhookDeliveries().Save(ctx, delivery); err != nil {
+			return err
+		}
+		return s.store.Webhooks().RecordSuccess(ctx, webhook.ID)
+	}
+
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.FinishedAt = &now
+		slog.Warn("Webhook gave up on delivery", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "event_type", delivery.EventType, "attempts", delivery.Attempts, "error", deliveryErr)
+	} else {
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+	}
+	if err := s.store.WebhookDeliveries().Save(ctx, delivery); err != nil {
+		return err
+	}
+
+	return s.store.Transaction(ctx, func(tx repositories.Store) error {
+		reason := fmt.Sprintf("%d deliveries failed in a row, last: %v", webhookFailureLimit, deliveryErr)
+		disabled, err := tx.Webhooks().RecordFailure(ctx, webhook.ID, webhookFailureLimit, reason, now)
+		if err != nil || !disabled {
+			return err
+		}
+
+		slog.Warn("Webhook disabled", "webhook_id", webhook.ID, "shop_id", webhook.ShopID, "reason", reason)
+		after, err := tx.Webhooks().Get(ctx, webhook.ID)
+		if err != nil {
+			return err
+		}
+		before := after
+		before.Active, before.DisabledAt, before.DisabledReason = true, nil, ""
+		return recordWebhook(ctx, tx, models.AuditUpdate, after, before, after)
+	})
+}
+
+// checkTarget is the dialer Control refusing connections to the internal
+// network unless AllowPrivateTargets is set.
+func (s *WebhookService) checkTarget(_, address string, _ syscall.RawConn) error {
+	if s.AllowPrivateTargets {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errors.New("webhook target is not a public address")
+	}
+	return nil
+}
+
+// webhookBackoff returns the delay before the next attempt of a delivery that
+// failed attempts times: webhookMinBackoff, doubling up to webhookMaxBackoff.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookMinBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// newWebhookSecret generates a random signing secret.
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// recordWebhook records a mutation of webhook in the audit log of its shop.
+func recordWebhook(ctx context.Context, tx repositories.Store, action string, webhook models.Webhook, before, after any) error {
+	return record(ctx, tx, auditRecord{
+		Action: action, EntityType: models.AuditWebhook, EntityID: webhook.ID, Entity: webhook, ShopID: webhook.ShopID, Before: before, After: after,
+	})
+}
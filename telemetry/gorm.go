@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// GormPlugin times every SQL statement run through GORM into the
+// db_query_duration_seconds histogram and traces it in a client span, child
+// of the span in the statement context, that of the request.
+type GormPlugin struct{}
+
+// Keys of the statement instance values of the plugin.
+const (
+	gormSpanKey  = "telemetry:span"
+	gormStartKey = "telemetry:start"
+)
+
+// Name implements gorm.Plugin.
+func (GormPlugin) Name() string {
+	return "telemetry"
+}
+
+// Initialize implements gorm.Plugin, registering callbacks around every kind
+// of statement.
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("telemetry:before_create", p.before("create")),
+		callbacks.Create().After("gorm:create").Register("telemetry:after_create", p.after("create")),
+		callbacks.Query().Before("gorm:query").Register("telemetry:before_query", p.before("query")),
+		callbacks.Query().After("gorm:query").Register("telemetry:after_query", p.after("query")),
+		callbacks.Update().Before("gorm:update").Register("telemetry:before_update", p.before("update")),
+		callbacks.Update().After("gorm:update").Register("telemetry:after_update", p.after("update")),
+		callbacks.Delete().Before("gorm:delete").Register("telemetry:before_delete", p.before("delete")),
+		callbacks.Delete().After("gorm:delete").Register("telemetry:after_delete", p.after("delete")),
+		callbacks.Row().Before("gorm:row").Register("telemetry:before_row", p.before("row")),
+		callbacks.Row().After("gorm:row").Register("telemetry:after_row", p.after("row")),
+		callbacks.Raw().Before("gorm:raw").Register("telemetry:before_raw", p.before("raw")),
+		callbacks.Raw().After("gorm:raw").Register("telemetry:after_raw", p.after("raw")),
+	)
+}
+
+func (GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		db.InstanceSet(gormStartKey, time.Now())
+		// The pollers of the background workers query every second: only the
+		// statements of a traced operation, such as a request, get a span.
+		if !trace.SpanContextFromContext(db.Statement.Context).IsValid() {
+			return
+		}
+		ctx, span := Tracer().Start(db.Statement.Context, "db."+operation, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (GormPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if start, ok := db.InstanceGet(gormStartKey); ok {
+			dbQueryDuration.WithLabelValues(operation, db.Statement.Table).Observe(time.Since(start.(time.Time)).Seconds())
+		}
+		value, ok := db.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		defer span.End()
+
+		span.SetAttributes(
+			semconv.DBSystemKey.String(db.Dialector.Name()),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(db.Statement.SQL.String()), // Placeholders, not the values
+			attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+		)
+		if db.Statement.Table != "" {
+			span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+		}
+		if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package telemetry instruments the API: Prometheus metrics of the HTTP
+// requests, the database and the business, and OpenTelemetry traces of the
+// requests and their SQL queries.
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/mohamedhabas11/golang-api/utils"
+)
+
+// Registry holds the metrics served by Handler: the ones below, the Go
+// runtime and the process.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time to answer HTTP requests by method, route template and status; streams count until their headers are sent.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of the SQL statements by GORM operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Login attempts by role and result: success, failure (invalid credentials) or error.",
+	}, []string{"role", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, dbQueryDuration, logins,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveRequest records an HTTP request answered with status after duration.
+// route is the template of the route that served it, not the actual path, to
+// keep the number of series bounded.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// RecordLogin records a login attempt of role, err being the outcome of the
+// credentials check.
+func RecordLogin(role string, err error) {
+	result := "success"
+	var apiErr *utils.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized:
+		result = "failure"
+	case err != nil:
+		result = "error"
+	}
+	logins.WithLabelValues(role, result).Inc()
+}
+
+// RegisterDBStats exports the statistics of the connection pool of db, under
+// the db_name label name.
+func RegisterDBStats(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// gaugeTimeout bounds the queries of the gauges run on each scrape.
+const gaugeTimeout = 5 * time.Second
+
+// RegisterGauge exports a gauge whose value value computes on each scrape,
+// such as a count of rows. A failing computation is logged and leaves the
+// gauge out of the scrape.
+func RegisterGauge(name, help string, value func(ctx context.Context) (float64, error)) {
+	Registry.MustRegister(&queryGauge{desc: prometheus.NewDesc(name, help, nil, nil), value: value})
+}
+
+// queryGauge is a gauge computed on collection.
+type queryGauge struct {
+	desc  *prometheus.Desc
+	value func(ctx context.Context) (float64, error)
+}
+
+func (g *queryGauge) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g *queryGauge) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), gaugeTimeout)
+	defer cancel()
+	value, err := g.value(ctx)
+	if err != nil {
+		slog.Error("Metrics: collecting a gauge failed", "gauge", g.desc.String(), "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, value)
+}
@@ -0,0 +1,69 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the API in the traces, unless OTEL_SERVICE_NAME is set.
+const ServiceName = "golang-api"
+
+// Tracer starts the spans of the API.
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/mohamedhabas11/golang-api")
+}
+
+// SetupTracing installs the tracer provider exporting the spans with
+// exporter: "otlp" sends them to the OTLP/HTTP collector configured by the
+// standard OTEL_EXPORTER_OTLP_* variables, "console" writes them to stdout and
+// "none" or "" drops them. Either way the W3C traceparent and baggage headers
+// are propagated. The returned function flushes the pending spans on
+// shutdown.
+func SetupTracing(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		spanExporter, err = otlptracehttp.New(ctx)
+	case "console":
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected otlp, console or none", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating the %s trace exporter: %w", exporter, err)
+	}
+
+	// The attributes of OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("describing the trace resource: %w", err)
+	}
+
+	// The sampler follows OTEL_TRACES_SAMPLER, every trace by default.
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
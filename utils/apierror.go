@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error codes returned in the "code" member of an APIError.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeRequestTimeout       = "request_timeout"
+	CodeConflict             = "conflict"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeRateLimited          = "rate_limited"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+)
+
+// FieldError describes a single invalid field of a request payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError is an RFC 7807 problem details object returned to API clients.
+// It implements error so handlers can simply return it and let the central
+// error handler serialize it.
+type APIError struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Code      string       `json:"code"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%d %s", e.Status, e.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Title, e.Detail)
+}
+
+// NewAPIError creates an APIError with the given status, code and detail message.
+func NewAPIError(status int, code, detail string) *APIError {
+	return &APIError{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// ErrBadRequest returns a 400 error for malformed requests.
+func ErrBadRequest(detail string) *APIError {
+	return NewAPIError(http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+// ErrValidation returns a 422 error carrying every invalid field.
+func ErrValidation(fields []FieldError) *APIError {
+	err := NewAPIError(http.StatusUnprocessableEntity, CodeValidationFailed, "The request payload is invalid")
+	err.Errors = fields
+	return err
+}
+
+// ErrUnauthorized returns a 401 error.
+func ErrUnauthorized(detail string) *APIError {
+	return NewAPIError(http.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+// ErrForbidden returns a 403 error.
+func ErrForbidden(detail string) *APIError {
+	return NewAPIError(http.StatusForbidden, CodeForbidden, detail)
+}
+
+// ErrNotFound returns a 404 error.
+func ErrNotFound(detail string) *APIError {
+	return NewAPIError(http.StatusNotFound, CodeNotFound, detail)
+}
+
+// ErrConflict returns a 409 error.
+func ErrConflict(detail string) *APIError {
+	return NewAPIError(http.StatusConflict, CodeConflict, detail)
+}
+
+// ErrRateLimited returns a 429 error for clients sending requests faster than
+// their rate limit.
+func ErrRateLimited(detail string) *APIError {
+	return NewAPIError(http.StatusTooManyRequests, CodeRateLimited, detail)
+}
+
+// ErrQuotaExceeded returns a 429 error for shops out of their monthly quota.
+func ErrQuotaExceeded(detail string) *APIError {
+	return NewAPIError(http.StatusTooManyRequests, CodeQuotaExceeded, detail)
+}
+
+// ErrInternal returns a 500 error. The detail must never contain internal error text.
+func ErrInternal(detail string) *APIError {
+	return NewAPIError(http.StatusInternalServerError, CodeInternal, detail)
+}
+
+// ErrUnavailable returns a 503 error for temporary failures such as an
+// unreachable database or an exceeded deadline.
+func ErrUnavailable(detail string) *APIError {
+	return NewAPIError(http.StatusServiceUnavailable, CodeUnavailable, detail)
+}